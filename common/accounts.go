@@ -0,0 +1,12 @@
+package common
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RejectInactiveAccount rejects the request of a user who is not active, and tells them why.
+func RejectInactiveAccount(c *gin.Context, err services.AccountStatusError) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error(), "reason": err.Reason})
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"github.com/sirupsen/logrus"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DurationFromEnv parses the environment variable as a time.Duration, e.g. "90m" or "24h".
+// Falls back to the given value if the variable is not set or malformed.
+func DurationFromEnv(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		logrus.WithField("key", key).Warnf("cannot parse environment variable as duration, falling back to %v: %v", fallback, err.Error())
+		return fallback
+	}
+
+	return duration
+}
+
+// IntFromEnv parses the environment variable as an integer.
+// Falls back to the given value if the variable is not set or malformed.
+func IntFromEnv(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		logrus.WithField("key", key).Warnf("cannot parse environment variable as integer, falling back to %v: %v", fallback, err.Error())
+		return fallback
+	}
+
+	return value
+}
+
+// BoolFromEnv parses the environment variable as a boolean, e.g. "true" or "0".
+// Falls back to the given value if the variable is not set or malformed.
+func BoolFromEnv(key string, fallback bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		logrus.WithField("key", key).Warnf("cannot parse environment variable as boolean, falling back to %v: %v", fallback, err.Error())
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,15 @@
+package common
+
+import (
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// SetSessionCookie sets the session cookie to expire together with the session itself.
+func SetSessionCookie(c *gin.Context, sessionId string, ttl time.Duration) {
+	c.SetCookie("session", sessionId, int(ttl.Seconds()), "/", "localhost", false, false)
+}
+
+func ClearSessionCookie(c *gin.Context) {
+	c.Header("Set-Cookie", "session=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;")
+}
@@ -3,9 +3,28 @@ package common
 import "github.com/aliparlakci/armut-backend-assessment/services"
 
 type Env struct {
+	*services.APIKeyService
+	*services.AccountDeletionService
 	*services.AuthService
 	*services.ActivityService
+	*services.AuditService
+	*services.EmailVerificationService
+	*services.ExportService
+	*services.InviteService
+	*services.LockoutService
 	*services.MessagingService
+	*services.MigrationService
+	*services.OIDCService
+	*services.PasswordResetService
+	*services.PresenceService
+	*services.ProofOfWorkService
+	*services.ProfileService
 	*services.SessionService
+	*services.TokenService
+	*services.TwoFactorService
+	*services.UnreadCounterService
 	*services.UserService
+
+	Mailer   services.Mailer
+	Notifier services.Notifier
 }
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// RunPeriodically runs the job every interval in a separate goroutine until the context is done.
+// Errors are logged and do not stop the job.
+func RunPeriodically(c context.Context, name string, interval time.Duration, job func(c context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-ticker.C:
+				if err := job(c); err != nil {
+					logrus.WithField("job", name).Errorf("periodic job raised an error: %v", err.Error())
+				}
+			}
+		}
+	}()
+}
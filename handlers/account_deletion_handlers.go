@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// DeleteAccount schedules the account of the user for deletion and signs them out everywhere.
+func DeleteAccount(authenticator services.Authenticator, throttler services.SigninThrottler, activityLogger services.ActivityLogger, reauthenticator services.Reauthenticator, deleter services.AccountDeleter, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.AccountDeletionForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		if !confirmIdentity(c, logger, authenticator, throttler, activityLogger, reauthenticator, user, form.Password) {
+			return
+		}
+
+		deleteAt, err := deleter.ScheduleDeletion(c.Copy(), user.Username)
+		if err != nil {
+			logger.WithField("username", user.Username).Errorf("AccountDeleter.ScheduleDeletion() raised an error while scheduling the deletion of user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		signOutEverywhere(c, logger, revoker, tokenRevoker, user.Username)
+
+		logger.WithField("username", user.Username).Infof("user with username is scheduled for deletion at %v", deleteAt)
+		common.ClearSessionCookie(c)
+		c.JSON(http.StatusOK, gin.H{"result": gin.H{"deletion_scheduled_at": deleteAt}})
+	}
+}
+
+// CancelDeletion activates the account of a user who has asked for it to be deleted, within the grace period.
+// Since the user cannot sign in until then, it takes their credentials, which are locked out like signins are.
+func CancelDeletion(authenticator services.Authenticator, throttler services.SigninThrottler, activityLogger services.ActivityLogger, deleter services.AccountDeleter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var creds models.AuthForm
+		if err := c.Bind(&creds); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		creds.Username = services.CanonicalUsername(creds.Username)
+
+		if !verifyPassword(c, logger, authenticator, throttler, activityLogger, creds.Username, creds.Password, true) {
+			return
+		}
+
+		if err := deleter.CancelDeletion(c.Copy(), creds.Username); err == services.ErrNoDeletionScheduled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.WithField("username", creds.Username).Errorf("AccountDeleter.CancelDeletion() raised an error while cancelling the deletion of user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "account deletion is cancelled"})
+	}
+}
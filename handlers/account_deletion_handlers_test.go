@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type deleteAccountMocks struct {
+	authenticator   *mocks.MockAuthenticator
+	throttler       *mocks.MockSigninThrottler
+	activityLogger  *mocks.MockActivityLogger
+	reauthenticator *mocks.MockReauthenticator
+	deleter         *mocks.MockAccountDeleter
+	revoker         *mocks.MockSessionRevoker
+	tokenRevoker    *mocks.MockTokenRevoker
+}
+
+func TestDeleteAccount(t *testing.T) {
+	withPassword := models.User{UserID: primitive.NewObjectID(), Username: "johndoe", Password: "hashed"}
+	withoutPassword := models.User{UserID: primitive.NewObjectID(), Username: "johndoe"}
+	body := multipart.Form{
+		Value: map[string][]string{
+			"password": {"hunter2"},
+		},
+	}
+	deleteAt := time.Date(2021, 12, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		User                models.User
+		Prepare             func(m deleteAccountMocks)
+		ExpectedCode        int
+		ExpectedBody        gin.H
+		ExpectedClearCookie bool
+	}{
+		{
+			User: withPassword,
+			Prepare: func(m deleteAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deleter.EXPECT().ScheduleDeletion(gomock.Any(), "johndoe").Return(deleteAt, nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+			},
+			ExpectedCode:        http.StatusOK,
+			ExpectedBody:        gin.H{"result": gin.H{"deletion_scheduled_at": deleteAt}},
+			ExpectedClearCookie: true,
+		}, {
+			User: withPassword,
+			Prepare: func(m deleteAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.deleter.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any()).Times(0)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "username and password mismatch"},
+		}, {
+			User: withPassword,
+			Prepare: func(m deleteAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Minute, nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.deleter.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusTooManyRequests,
+			ExpectedBody: gin.H{"error": "too many failed signin attempts"},
+		}, {
+			User: withoutPassword,
+			Prepare: func(m deleteAccountMocks) {
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.reauthenticator.EXPECT().ConsumeReauthentication(gomock.Any(), withoutPassword.UserID.Hex()).Return(true, nil)
+				m.deleter.EXPECT().ScheduleDeletion(gomock.Any(), "johndoe").Return(deleteAt, nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+			},
+			ExpectedCode:        http.StatusOK,
+			ExpectedBody:        gin.H{"result": gin.H{"deletion_scheduled_at": deleteAt}},
+			ExpectedClearCookie: true,
+		}, {
+			User: withoutPassword,
+			Prepare: func(m deleteAccountMocks) {
+				m.reauthenticator.EXPECT().ConsumeReauthentication(gomock.Any(), withoutPassword.UserID.Hex()).Return(false, nil)
+				m.deleter.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "identity must be confirmed at the identity provider first"},
+		}, {
+			User: withPassword,
+			Prepare: func(m deleteAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deleter.EXPECT().ScheduleDeletion(gomock.Any(), "johndoe").Return(deleteAt, fmt.Errorf("some error"))
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := deleteAccountMocks{
+				authenticator:   mocks.NewMockAuthenticator(ctrl),
+				throttler:       mocks.NewMockSigninThrottler(ctrl),
+				activityLogger:  mocks.NewMockActivityLogger(ctrl),
+				reauthenticator: mocks.NewMockReauthenticator(ctrl),
+				deleter:         mocks.NewMockAccountDeleter(ctrl),
+				revoker:         mocks.NewMockSessionRevoker(ctrl),
+				tokenRevoker:    mocks.NewMockTokenRevoker(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", tt.User)
+			})
+			r.DELETE("/api/me", DeleteAccount(m.authenticator, m.throttler, m.activityLogger, m.reauthenticator, m.deleter, m.revoker, m.tokenRevoker))
+
+			request, err := http.NewRequest(http.MethodDelete, "/api/me", nil)
+			request.MultipartForm = &body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+
+			cleared := false
+			for _, cookie := range recorder.Result().Cookies() {
+				cleared = cleared || (cookie.Name == "session" && cookie.Value == "")
+			}
+			if cleared != tt.ExpectedClearCookie {
+				t.Errorf("want the session cookie to be cleared: %v, got %v", tt.ExpectedClearCookie, cleared)
+			}
+		})
+	}
+}
+
+type cancelDeletionMocks struct {
+	authenticator  *mocks.MockAuthenticator
+	throttler      *mocks.MockSigninThrottler
+	activityLogger *mocks.MockActivityLogger
+	deleter        *mocks.MockAccountDeleter
+}
+
+func TestCancelDeletion(t *testing.T) {
+	body := multipart.Form{
+		Value: map[string][]string{
+			"username": {"JohnDoe"},
+			"password": {"hunter2"},
+		},
+	}
+	deactivated := services.AccountStatusError{Status: models.StatusDeactivated, Reason: "account is scheduled for deletion"}
+
+	tests := []struct {
+		Prepare      func(m cancelDeletionMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Prepare: func(m cancelDeletionMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, deactivated)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deleter.EXPECT().CancelDeletion(gomock.Any(), "johndoe").Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "account deletion is cancelled"},
+		}, {
+			Prepare: func(m cancelDeletionMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deleter.EXPECT().CancelDeletion(gomock.Any(), "johndoe").Return(services.ErrNoDeletionScheduled)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrNoDeletionScheduled.Error()},
+		}, {
+			Prepare: func(m cancelDeletionMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.deleter.EXPECT().CancelDeletion(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "username and password mismatch"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := cancelDeletionMocks{
+				authenticator:  mocks.NewMockAuthenticator(ctrl),
+				throttler:      mocks.NewMockSigninThrottler(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+				deleter:        mocks.NewMockAccountDeleter(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/deletion/cancel", CancelDeletion(m.authenticator, m.throttler, m.activityLogger, m.deleter))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/deletion/cancel", nil)
+			request.MultipartForm = &body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
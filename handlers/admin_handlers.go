@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+func ListUsers(lister services.UserLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		skip, limit, ok := pagination(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+			return
+		}
+
+		users, err := lister.ListUsers(c.Copy(), skip, limit)
+		if err != nil {
+			logger.Errorf("UserLister.ListUsers() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		summaries := make([]models.UserSummary, len(users))
+		for i, user := range users {
+			summaries[i] = user.Summary()
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": summaries})
+	}
+}
+
+// SetUserStatus signs the user out everywhere, unless they are made active.
+func SetUserStatus(updater services.UserStatusUpdater, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var form models.StatusForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		setUserStatus(c, updater, revoker, tokenRevoker, form.Status, form.Reason)
+	}
+}
+
+func setUserStatus(c *gin.Context, updater services.UserStatusUpdater, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker, status, reason string) {
+	logger := common.LoggerWithRequestId(c.Copy())
+
+	var user models.User
+	if u, exists := c.Get("user"); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{})
+		return
+	} else {
+		user = u.(models.User)
+	}
+
+	username := services.CanonicalUsername(c.Param("username"))
+	if username == user.Username {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "admins cannot change their own status"})
+		return
+	}
+
+	if err := updater.SetStatus(c.Copy(), username, status, reason); err == services.ErrInvalidStatus {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err == services.ErrNoUser {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user does not exist"})
+		return
+	} else if err != nil {
+		logger.Errorf("UserStatusUpdater.SetStatus() raised an error while setting the status of %v: %v", username, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+
+	if status != models.StatusActive {
+		signOutEverywhere(c, logger, revoker, tokenRevoker, username)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "user is " + status})
+}
+
+// signOutEverywhere revokes every session and token of a user who is no longer active. They cannot be used while the
+// user is not active anyway, but they must not be usable once the user is active again either.
+func signOutEverywhere(c *gin.Context, logger *logrus.Entry, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker, username string) {
+	if err := revoker.RevokeOtherSessions(c.Copy(), username, ""); err != nil {
+		logger.Errorf("SessionRevoker.RevokeOtherSessions() raised an error while revoking sessions of %v: %v", username, err.Error())
+	}
+	if err := tokenRevoker.RevokeUserTokens(c.Copy(), username); err != nil {
+		logger.Errorf("TokenRevoker.RevokeUserTokens() raised an error while revoking tokens of %v: %v", username, err.Error())
+	}
+}
+
+func GetUserActivities(fetcher services.ActivityFetcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		username := services.CanonicalUsername(c.Param("username"))
+		activities, err := fetcher.Fetch(c.Copy(), username)
+		if err != nil {
+			logger.Errorf("ActivityFetcher.Fetch() raised an error while fetching activity of %v: %v", username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": activities})
+	}
+}
+
+func GetAuditLog(fetcher services.AuditFetcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		skip, limit, ok := pagination(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+			return
+		}
+
+		entries, err := fetcher.FetchAudit(c.Copy(), skip, limit)
+		if err != nil {
+			logger.Errorf("AuditFetcher.FetchAudit() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": entries})
+	}
+}
+
+// pagination reads the "offset" and "limit" query parameters.
+func pagination(c *gin.Context) (int64, int64, bool) {
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, false
+	}
+
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)), 10, 64)
+	if err != nil || limit < 1 {
+		return 0, 0, false
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	return offset, limit, true
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type statusMocks struct {
+	updater      *mocks.MockUserStatusUpdater
+	revoker      *mocks.MockSessionRevoker
+	tokenRevoker *mocks.MockTokenRevoker
+}
+
+func TestSetUserStatus(t *testing.T) {
+	tests := []struct {
+		Username     string
+		Status       string
+		Prepare      func(m statusMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Status:   models.StatusSuspended,
+			Username: "johndoe",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), "johndoe", models.StatusSuspended, "").Return(nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "user is suspended"},
+		}, {
+			Status:   models.StatusSuspended,
+			Username: "JohnDoe",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), "johndoe", models.StatusSuspended, "").Return(nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "user is suspended"},
+		}, {
+			Status:   models.StatusSuspended,
+			Username: "AliParlakci",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "admins cannot change their own status"},
+		}, {
+			Status:   models.StatusSuspended,
+			Username: "aliparlakci",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "admins cannot change their own status"},
+		}, {
+			Status:   models.StatusSuspended,
+			Username: "janedoe",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), "janedoe", models.StatusSuspended, "").Return(services.ErrNoUser)
+			},
+			ExpectedCode: http.StatusNotFound,
+			ExpectedBody: gin.H{"error": "user does not exist"},
+		}, {
+			Status:   models.StatusSuspended,
+			Username: "johndoe",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), "johndoe", models.StatusSuspended, "").Return(errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		}, {
+			Status:   models.StatusActive,
+			Username: "johndoe",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), "johndoe", models.StatusActive, "").Return(nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "user is active"},
+		}, {
+			Status:   "banned",
+			Username: "johndoe",
+			Prepare: func(m statusMocks) {
+				m.updater.EXPECT().SetStatus(gomock.Any(), "johndoe", "banned", "").Return(services.ErrInvalidStatus)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrInvalidStatus.Error()},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := statusMocks{
+				updater:      mocks.NewMockUserStatusUpdater(ctrl),
+				revoker:      mocks.NewMockSessionRevoker(ctrl),
+				tokenRevoker: mocks.NewMockTokenRevoker(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "aliparlakci", Roles: []string{services.RoleAdmin}})
+			})
+			r.PUT("/api/admin/users/:username/status", SetUserStatus(m.updater, m.revoker, m.tokenRevoker))
+
+			request, err := http.NewRequest(http.MethodPut, "/api/admin/users/"+tt.Username+"/status", nil)
+			request.MultipartForm = &multipart.Form{Value: map[string][]string{"status": {tt.Status}}}
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
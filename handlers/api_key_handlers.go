@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func GetAPIKeys(manager services.APIKeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		apiKeys, err := manager.ListAPIKeys(c.Copy(), user.Username)
+		if err != nil {
+			logger.Errorf("APIKeyManager.ListAPIKeys() raised an error while listing api keys of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": apiKeys})
+	}
+}
+
+func CreateAPIKey(manager services.APIKeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.NewAPIKey
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		key, apiKey, err := manager.CreateAPIKey(c.Copy(), user.Username, form.Name, form.Scopes)
+		if err == services.ErrUnknownScope {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scope is unknown"})
+			return
+		} else if err != nil {
+			logger.Errorf("APIKeyManager.CreateAPIKey() raised an error while creating an api key for %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		// The key is only ever shown here
+		c.JSON(http.StatusCreated, gin.H{"result": gin.H{"key": key, "api_key": apiKey}})
+	}
+}
+
+func RevokeAPIKey(manager services.APIKeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		if err := manager.RevokeAPIKey(c.Copy(), user.Username, c.Param("id")); err == services.ErrNoAPIKey {
+			c.JSON(http.StatusNotFound, gin.H{"error": "api key does not exist"})
+			return
+		} else if err != nil {
+			logger.Errorf("APIKeyManager.RevokeAPIKey() raised an error while revoking an api key of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
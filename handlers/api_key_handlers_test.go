@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		Body         multipart.Form
+		Prepare      func(manager *mocks.MockAPIKeyManager)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"name":   {"ci"},
+					"scopes": {services.ScopeMessagesRead, services.ScopeMessagesSend},
+				},
+			},
+			Prepare: func(manager *mocks.MockAPIKeyManager) {
+				manager.EXPECT().CreateAPIKey(gomock.Any(), "johndoe", "ci", []string{services.ScopeMessagesRead, services.ScopeMessagesSend}).Return(
+					"armut_secret",
+					models.APIKey{
+						ID:     id,
+						Name:   "ci",
+						Prefix: "armut_secr",
+						Scopes: []string{services.ScopeMessagesRead, services.ScopeMessagesSend},
+					}, nil).MinTimes(1)
+			},
+			ExpectedCode: http.StatusCreated,
+			ExpectedBody: gin.H{"result": gin.H{
+				"key": "armut_secret",
+				"api_key": gin.H{
+					"id":         id.Hex(),
+					"name":       "ci",
+					"prefix":     "armut_secr",
+					"scopes":     []string{services.ScopeMessagesRead, services.ScopeMessagesSend},
+					"created_at": time.Time{},
+					"last_used":  time.Time{},
+				},
+			}},
+		}, {
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"name":   {"ci"},
+					"scopes": {"messages:delete"},
+				},
+			},
+			Prepare: func(manager *mocks.MockAPIKeyManager) {
+				manager.EXPECT().CreateAPIKey(gomock.Any(), "johndoe", "ci", []string{"messages:delete"}).Return("", models.APIKey{}, services.ErrUnknownScope).MinTimes(1)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "scope is unknown"},
+		}, {
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"name":   {"ci"},
+					"scopes": {services.ScopeActivityRead},
+				},
+			},
+			Prepare: func(manager *mocks.MockAPIKeyManager) {
+				manager.EXPECT().CreateAPIKey(gomock.Any(), "johndoe", "ci", []string{services.ScopeActivityRead}).Return("", models.APIKey{}, errors.New("")).MinTimes(1)
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockedAPIKeyManager := mocks.NewMockAPIKeyManager(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(mockedAPIKeyManager)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "johndoe"})
+			})
+			r.POST("/api/keys", CreateAPIKey(mockedAPIKeyManager))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/keys", nil)
+			request.MultipartForm = &tt.Body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -1,16 +1,18 @@
 package handlers
 
 import (
-	"fmt"
 	"github.com/aliparlakci/armut-backend-assessment/common"
 	"github.com/aliparlakci/armut-backend-assessment/models"
 	"github.com/aliparlakci/armut-backend-assessment/services"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-func Signin(authenticator services.Authenticator, sessions services.SessionCreator, activityLogger services.ActivityLogger) gin.HandlerFunc {
+func Signin(authenticator services.Authenticator, userGetter services.UserGetter, pendingSignins services.PendingSigninCreator, sessions services.SessionCreator, throttler services.SigninThrottler, activityLogger services.ActivityLogger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := common.LoggerWithRequestId(c.Copy())
 
@@ -19,42 +21,34 @@ func Signin(authenticator services.Authenticator, sessions services.SessionCreat
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 			return
 		}
+		creds.Username = services.CanonicalUsername(creds.Username)
 
 		if _, isLoggedIn := c.Get("user"); isLoggedIn {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "user is already logged in"})
 			return
 		}
 
-		success, err := authenticator.Authenticate(c.Copy(), creds.Username, creds.Password)
-		if err != nil {
-			logger.Errorf("Authenticator.Authenticate() raised an error while logging in the user with username: %v", err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{})
+		if !verifyPassword(c, logger, authenticator, throttler, activityLogger, creds.Username, creds.Password, false) {
 			return
 		}
 
-		if !success {
-			if err := activityLogger.LogUnsuccesfulSignin(c.Copy(), creds.Username, c.ClientIP()); err != nil {
-				logger.Errorf("ActivityLogger.LogUnsuccesfulSignin() raised an error: %v", err.Error())
-			}
-			c.JSON(http.StatusBadRequest, gin.H{"error": "username and password mismatch"})
+		user, err := userGetter.GetUser(c.Copy(), creds.Username)
+		if err != nil {
+			logger.WithField("username", creds.Username).Errorf("UserGetter.GetUser() raised an error while finding user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
 			return
 		}
-
-		sessionId, err := sessions.CreateSession(c.Copy(), creds.Username)
-		if err != nil {
-			logger.Errorf("SessionService.CreateSession raised an error while creating a new session for user with username: %v", err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+		if err := services.CheckAccountStatus(user); err != nil {
+			common.RejectInactiveAccount(c, err.(services.AccountStatusError))
 			return
 		}
 
-		if err := activityLogger.LogSignin(c.Copy(), creds.Username, c.ClientIP()); err != nil {
-			logger.Errorf("ActivityLogger.LogSignin() raised an error: %v", err.Error())
+		if user.TwoFactorEnabled() {
+			requireTwoFactor(c, logger, pendingSignins, creds.Username)
+			return
 		}
 
-		logger.WithFields(logrus.Fields{"username": creds.Username, "sessionId": sessionId}).Infof("user with username logged in on the session with sessionID")
-		c.SetCookie("session", sessionId, 7776000, "/", "localhost", false, false)
-		c.JSON(http.StatusOK, gin.H{"result": "logged in"})
-		return
+		signin(c, logger, sessions, activityLogger, creds.Username)
 	}
 }
 
@@ -68,8 +62,18 @@ func Me() gin.HandlerFunc {
 			user = u.(models.User)
 		}
 
+		usernameHistory := user.UsernameHistory
+		if usernameHistory == nil {
+			usernameHistory = []models.UsernameChange{}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"username": user.Username,
+			"username":         user.Username,
+			"username_history": usernameHistory,
+			"email":            user.Email,
+			"email_verified":   user.EmailVerified,
+			"private":          user.Profile.Private,
+			"hide_presence":    user.Profile.HidePresence,
 		})
 	}
 }
@@ -102,7 +106,193 @@ func Signout(revoker services.SessionRevoker, activityLogger services.ActivityLo
 		}
 
 		logger.WithField("sessionId", sessionId).Infof("user signed out from session with sessionId")
-		c.Header("Set-Cookie", fmt.Sprintf("session=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;"))
+		common.ClearSessionCookie(c)
 		c.JSON(http.StatusOK, gin.H{})
 	}
-}
\ No newline at end of file
+}
+
+func ChangePassword(authenticator services.Authenticator, throttler services.SigninThrottler, hasher services.PasswordHasher, updater services.UserUpdater, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, isLoggedIn := c.Get("user"); !isLoggedIn {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.PasswordChangeForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		if !verifyPassword(c, logger, authenticator, throttler, activityLogger, user.Username, form.CurrentPassword, false) {
+			return
+		}
+
+		hashedPassword, err := hasher.HashPassword(form.NewPassword)
+		if err != nil {
+			logger.Errorf("cannot hash the password: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := updater.UpdatePassword(c.Copy(), user.Username, hashedPassword); err != nil {
+			logger.WithField("username", user.Username).Errorf("UserUpdater.UpdatePassword() raised an error while updating the password of user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := revoker.RevokeOtherSessions(c.Copy(), user.Username, c.GetString("session_id")); err != nil {
+			logger.Errorf("SessionRevoker.RevokeOtherSessions() raised an error after the password change: %v", err.Error())
+		}
+		if err := tokenRevoker.RevokeUserTokens(c.Copy(), user.Username); err != nil {
+			logger.Errorf("TokenRevoker.RevokeUserTokens() raised an error after the password change: %v", err.Error())
+		}
+
+		if err := activityLogger.LogPasswordChange(c.Copy(), user.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogPasswordChange() raised an error: %v", err.Error())
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "password changed"})
+	}
+}
+
+// signin creates a new session for the user, whose credentials are already verified, and responds.
+func signin(c *gin.Context, logger *logrus.Entry, sessions services.SessionCreator, activityLogger services.ActivityLogger, username string) {
+	sessionId, ttl, err := sessions.CreateSession(c.Copy(), username, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		logger.Errorf("SessionService.CreateSession raised an error while creating a new session for user with username: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+		return
+	}
+
+	if err := activityLogger.LogSignin(c.Copy(), username, c.ClientIP()); err != nil {
+		logger.Errorf("ActivityLogger.LogSignin() raised an error: %v", err.Error())
+	}
+
+	logger.WithFields(logrus.Fields{"username": username, "sessionId": sessionId}).Infof("user with username logged in on the session with sessionID")
+	common.SetSessionCookie(c, sessionId, ttl)
+	c.JSON(http.StatusOK, gin.H{"result": "logged in"})
+}
+
+// requireTwoFactor keeps the user in a pending signin, which POST /api/signin/2fa completes with a code.
+func requireTwoFactor(c *gin.Context, logger *logrus.Entry, pendingSignins services.PendingSigninCreator, username string) {
+	pendingId, err := pendingSignins.CreatePendingSignin(c.Copy(), username)
+	if err != nil {
+		logger.Errorf("PendingSigninCreator.CreatePendingSignin() raised an error: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+		return
+	}
+
+	c.SetCookie("pending_signin", pendingId, 0, "/", "localhost", false, true)
+	c.JSON(http.StatusAccepted, gin.H{"result": "two-factor authentication is required"})
+}
+
+func lockedOut(c *gin.Context, lockout time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockout.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed signin attempts"})
+}
+
+// verifyPassword responds with an error and returns false unless the password is correct and signins are not locked out.
+// Unless acceptInactive is set, the user must also be active.
+func verifyPassword(c *gin.Context, logger *logrus.Entry, authenticator services.Authenticator, throttler services.SigninThrottler, activityLogger services.ActivityLogger, username, password string, acceptInactive bool) bool {
+	if !checkPassword(c, logger, authenticator, throttler, activityLogger, username, password, acceptInactive) {
+		return false
+	}
+
+	if err := throttler.ResetFailures(c.Copy(), username); err != nil {
+		logger.Errorf("SigninThrottler.ResetFailures() raised an error: %v", err.Error())
+	}
+
+	return true
+}
+
+// confirmIdentity responds with an error and returns false unless the signed in user has confirmed their identity,
+// with their password, or at the identity provider if they do not have one. reauthenticator is nil unless single
+// sign-on is available.
+func confirmIdentity(c *gin.Context, logger *logrus.Entry, authenticator services.Authenticator, throttler services.SigninThrottler, activityLogger services.ActivityLogger, reauthenticator services.Reauthenticator, user models.User, password string) bool {
+	if user.Password != "" {
+		if password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return false
+		}
+		return verifyPassword(c, logger, authenticator, throttler, activityLogger, user.Username, password, false)
+	}
+
+	if reauthenticator == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "identity cannot be confirmed without a password"})
+		return false
+	}
+
+	confirmed, err := reauthenticator.ConsumeReauthentication(c.Copy(), user.UserID.Hex())
+	if err != nil {
+		logger.Errorf("Reauthenticator.ConsumeReauthentication() raised an error: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return false
+	}
+	if !confirmed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "identity must be confirmed at the identity provider first"})
+		return false
+	}
+
+	return true
+}
+
+// checkPassword is verifyPassword without resetting the failed signins, for the signins which still need a second
+// factor, whose failures count towards the same lockout.
+func checkPassword(c *gin.Context, logger *logrus.Entry, authenticator services.Authenticator, throttler services.SigninThrottler, activityLogger services.ActivityLogger, username, password string, acceptInactive bool) bool {
+	if lockout, err := throttler.CheckLockout(c.Copy(), username, c.ClientIP()); err != nil {
+		logger.Errorf("SigninThrottler.CheckLockout() raised an error: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return false
+	} else if lockout > 0 {
+		lockedOut(c, lockout)
+		return false
+	}
+
+	success, err := authenticator.Authenticate(c.Copy(), username, password)
+	if statusErr, inactive := err.(services.AccountStatusError); inactive && !acceptInactive {
+		common.RejectInactiveAccount(c, statusErr)
+		return false
+	} else if inactive {
+		success = true
+	} else if err != nil {
+		logger.Errorf("Authenticator.Authenticate() raised an error while logging in the user with username: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return false
+	}
+
+	if !success {
+		if err := activityLogger.LogUnsuccesfulSignin(c.Copy(), username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogUnsuccesfulSignin() raised an error: %v", err.Error())
+		}
+
+		if !recordFailure(c, logger, throttler, activityLogger, username) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "username and password mismatch"})
+		}
+		return false
+	}
+
+	return true
+}
+
+// recordFailure counts a failed signin towards the lockout, and responds and returns true if it locks signins out.
+func recordFailure(c *gin.Context, logger *logrus.Entry, throttler services.SigninThrottler, activityLogger services.ActivityLogger, username string) bool {
+	lockout, err := throttler.RecordFailure(c.Copy(), username, c.ClientIP())
+	if err != nil {
+		logger.Errorf("SigninThrottler.RecordFailure() raised an error: %v", err.Error())
+	}
+	if lockout <= 0 {
+		return false
+	}
+
+	if err := activityLogger.LogLockout(c.Copy(), username, c.ClientIP()); err != nil {
+		logger.Errorf("ActivityLogger.LogLockout() raised an error: %v", err.Error())
+	}
+	lockedOut(c, lockout)
+	return true
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type signinMocks struct {
+	authenticator  *mocks.MockAuthenticator
+	userGetter     *mocks.MockUserGetter
+	pendingSignins *mocks.MockPendingSigninCreator
+	sessions       *mocks.MockSessionCreator
+	throttler      *mocks.MockSigninThrottler
+	activityLogger *mocks.MockActivityLogger
+}
+
+func TestSignin(t *testing.T) {
+	body := multipart.Form{
+		Value: map[string][]string{
+			"username": {"johndoe"},
+			"password": {"hunter2"},
+		},
+	}
+
+	tests := []struct {
+		Prepare            func(m signinMocks)
+		ExpectedCode       int
+		ExpectedBody       gin.H
+		ExpectedRetryAfter string
+	}{
+		{
+			Prepare: func(m signinMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.userGetter.EXPECT().GetUser(gomock.Any(), "johndoe").Return(models.User{Username: "johndoe"}, nil)
+				m.sessions.EXPECT().CreateSession(gomock.Any(), "johndoe", gomock.Any(), gomock.Any()).Return("someuuid", time.Hour, nil)
+				m.activityLogger.EXPECT().LogSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "logged in"},
+		}, {
+			Prepare: func(m signinMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.userGetter.EXPECT().GetUser(gomock.Any(), "johndoe").Return(models.User{Username: "johndoe", TOTPSecret: "JBSWY3DPEHPK3PXP"}, nil)
+				m.pendingSignins.EXPECT().CreatePendingSignin(gomock.Any(), "johndoe").Return("pendingid", nil)
+			},
+			ExpectedCode: http.StatusAccepted,
+			ExpectedBody: gin.H{"result": "two-factor authentication is required"},
+		}, {
+			Prepare: func(m signinMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "username and password mismatch"},
+		}, {
+			Prepare: func(m signinMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Minute, nil)
+				m.activityLogger.EXPECT().LogLockout(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode:       http.StatusTooManyRequests,
+			ExpectedBody:       gin.H{"error": "too many failed signin attempts"},
+			ExpectedRetryAfter: "60",
+		}, {
+			Prepare: func(m signinMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(1500*time.Millisecond, nil)
+			},
+			ExpectedCode:       http.StatusTooManyRequests,
+			ExpectedBody:       gin.H{"error": "too many failed signin attempts"},
+			ExpectedRetryAfter: "2",
+		}, {
+			Prepare: func(m signinMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, services.AccountStatusError{Status: models.StatusSuspended, Reason: "spam"})
+				m.sessions.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "account is suspended", "reason": "spam"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := signinMocks{
+				authenticator:  mocks.NewMockAuthenticator(ctrl),
+				userGetter:     mocks.NewMockUserGetter(ctrl),
+				pendingSignins: mocks.NewMockPendingSigninCreator(ctrl),
+				sessions:       mocks.NewMockSessionCreator(ctrl),
+				throttler:      mocks.NewMockSigninThrottler(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/signin", Signin(m.authenticator, m.userGetter, m.pendingSignins, m.sessions, m.throttler, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/signin", nil)
+			request.MultipartForm = &body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+			if retryAfter := recorder.Result().Header.Get("Retry-After"); retryAfter != tt.ExpectedRetryAfter {
+				t.Errorf("want %v, got %v", tt.ExpectedRetryAfter, retryAfter)
+			}
+		})
+	}
+}
+
+type changePasswordMocks struct {
+	authenticator  *mocks.MockAuthenticator
+	throttler      *mocks.MockSigninThrottler
+	hasher         *mocks.MockPasswordHasher
+	updater        *mocks.MockUserUpdater
+	revoker        *mocks.MockSessionRevoker
+	tokenRevoker   *mocks.MockTokenRevoker
+	activityLogger *mocks.MockActivityLogger
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		Body         multipart.Form
+		Prepare      func(m changePasswordMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Body: multipart.Form{Value: map[string][]string{"current_password": {"hunter22"}, "new_password": {"correct horse"}}},
+			Prepare: func(m changePasswordMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter22").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.hasher.EXPECT().HashPassword("correct horse").Return("hashed", nil)
+				m.updater.EXPECT().UpdatePassword(gomock.Any(), "johndoe", "hashed").Return(nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "currentsession").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+				m.activityLogger.EXPECT().LogPasswordChange(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "password changed"},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"current_password": {"hunter23"}, "new_password": {"correct horse"}}},
+			Prepare: func(m changePasswordMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter23").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.hasher.EXPECT().HashPassword(gomock.Any()).Times(0)
+				m.updater.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "username and password mismatch"},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"current_password": {"hunter23"}, "new_password": {"correct horse"}}},
+			Prepare: func(m changePasswordMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Minute, nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.updater.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusTooManyRequests,
+			ExpectedBody: gin.H{"error": "too many failed signin attempts"},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"current_password": {"hunter22"}}},
+			Prepare: func(m changePasswordMocks) {
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "invalid request"},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"current_password": {"hunter22"}, "new_password": {"correct horse"}}},
+			Prepare: func(m changePasswordMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter22").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.hasher.EXPECT().HashPassword("correct horse").Return("hashed", nil)
+				m.updater.EXPECT().UpdatePassword(gomock.Any(), "johndoe", "hashed").Return(fmt.Errorf("some error"))
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := changePasswordMocks{
+				authenticator:  mocks.NewMockAuthenticator(ctrl),
+				throttler:      mocks.NewMockSigninThrottler(ctrl),
+				hasher:         mocks.NewMockPasswordHasher(ctrl),
+				updater:        mocks.NewMockUserUpdater(ctrl),
+				revoker:        mocks.NewMockSessionRevoker(ctrl),
+				tokenRevoker:   mocks.NewMockTokenRevoker(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "johndoe"})
+				c.Set("session_id", "currentsession")
+			})
+			r.PUT("/api/me/password", ChangePassword(m.authenticator, m.throttler, m.hasher, m.updater, m.revoker, m.tokenRevoker, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPut, "/api/me/password", nil)
+			request.MultipartForm = &tt.Body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequestExport only starts building the export. The user is notified with the download link once it is ready.
+func RequestExport(exporter services.DataExporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		token, err := exporter.RequestExport(c.Copy(), user.Username)
+		if err == services.ErrExportInProgress {
+			c.JSON(http.StatusConflict, gin.H{"error": "previous export is still being prepared"})
+			return
+		} else if err != nil {
+			logger.Errorf("DataExporter.RequestExport() raised an error while requesting an export for %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"result": gin.H{"token": token}})
+	}
+}
+
+// DownloadExport only serves the exports of the user who has requested them.
+func DownloadExport(exporter services.DataExporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		export, err := exporter.FetchExport(c.Copy(), user.Username, c.Param("token"))
+		if err == services.ErrNoExport {
+			c.JSON(http.StatusNotFound, gin.H{"error": "export does not exist or has expired"})
+			return
+		} else if err != nil {
+			logger.Errorf("DataExporter.FetchExport() raised an error while fetching an export of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if export.Status != models.ExportReady {
+			c.JSON(http.StatusAccepted, gin.H{"result": export})
+			return
+		}
+
+		archive, size, err := exporter.OpenArchive(c.Copy(), export)
+		if err == services.ErrNoExport {
+			c.JSON(http.StatusNotFound, gin.H{"error": "export does not exist or has expired"})
+			return
+		} else if err != nil {
+			logger.Errorf("DataExporter.OpenArchive() raised an error while opening an export of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		defer archive.Close()
+
+		filename := fmt.Sprintf("%v-export-%v.zip", user.Username, export.RequestedAt.UTC().Format("20060102"))
+		c.Header("Cache-Control", "no-store")
+		c.DataFromReader(http.StatusOK, size, "application/zip", archive, map[string]string{
+			"Content-Disposition": fmt.Sprintf(`attachment; filename="%v"`, filename),
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadExport(t *testing.T) {
+	requestedAt := time.Date(2021, 11, 20, 12, 0, 0, 0, time.UTC)
+	archiveID := primitive.NewObjectID()
+	ready := models.Export{
+		Status:      models.ExportReady,
+		RequestedAt: requestedAt,
+		ExpiresAt:   requestedAt.Add(time.Hour),
+		ArchiveID:   archiveID,
+	}
+
+	tests := []struct {
+		Prepare             func(exporter *mocks.MockDataExporter)
+		ExpectedCode        int
+		ExpectedBody        gin.H
+		ExpectedArchive     []byte
+		ExpectedDisposition string
+	}{
+		{
+			Prepare: func(exporter *mocks.MockDataExporter) {
+				exporter.EXPECT().FetchExport(gomock.Any(), "johndoe", "sometoken").Return(ready, nil)
+				exporter.EXPECT().OpenArchive(gomock.Any(), ready).Return(io.NopCloser(strings.NewReader("PK archive")), int64(10), nil)
+			},
+			ExpectedCode:        http.StatusOK,
+			ExpectedArchive:     []byte("PK archive"),
+			ExpectedDisposition: `attachment; filename="johndoe-export-20211120.zip"`,
+		}, {
+			Prepare: func(exporter *mocks.MockDataExporter) {
+				exporter.EXPECT().FetchExport(gomock.Any(), "johndoe", "sometoken").Return(ready, nil)
+				exporter.EXPECT().OpenArchive(gomock.Any(), ready).Return(nil, int64(0), services.ErrNoExport)
+			},
+			ExpectedCode: http.StatusNotFound,
+			ExpectedBody: gin.H{"error": "export does not exist or has expired"},
+		}, {
+			Prepare: func(exporter *mocks.MockDataExporter) {
+				exporter.EXPECT().FetchExport(gomock.Any(), "johndoe", "sometoken").Return(ready, nil)
+				exporter.EXPECT().OpenArchive(gomock.Any(), ready).Return(nil, int64(0), errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		}, {
+			Prepare: func(exporter *mocks.MockDataExporter) {
+				exporter.EXPECT().FetchExport(gomock.Any(), "johndoe", "sometoken").Return(models.Export{
+					Status:      models.ExportPending,
+					RequestedAt: requestedAt,
+					ExpiresAt:   requestedAt.Add(10 * time.Minute),
+				}, nil)
+			},
+			ExpectedCode: http.StatusAccepted,
+			ExpectedBody: gin.H{"result": gin.H{
+				"status":       models.ExportPending,
+				"requested_at": requestedAt,
+				"expires_at":   requestedAt.Add(10 * time.Minute),
+			}},
+		}, {
+			Prepare: func(exporter *mocks.MockDataExporter) {
+				exporter.EXPECT().FetchExport(gomock.Any(), "johndoe", "sometoken").Return(models.Export{}, services.ErrNoExport)
+			},
+			ExpectedCode: http.StatusNotFound,
+			ExpectedBody: gin.H{"error": "export does not exist or has expired"},
+		}, {
+			Prepare: func(exporter *mocks.MockDataExporter) {
+				exporter.EXPECT().FetchExport(gomock.Any(), "johndoe", "sometoken").Return(models.Export{}, errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockedDataExporter := mocks.NewMockDataExporter(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(mockedDataExporter)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "johndoe"})
+			})
+			r.GET("/api/me/export/:token", DownloadExport(mockedDataExporter))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/me/export/sometoken", nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if tt.ExpectedArchive != nil {
+				if archive, err := io.ReadAll(recorder.Result().Body); err != nil {
+					t.Fatal(err)
+				} else if !bytes.Equal(archive, tt.ExpectedArchive) {
+					t.Errorf("archives don't match")
+				}
+			} else if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+			if disposition := recorder.Result().Header.Get("Content-Disposition"); disposition != tt.ExpectedDisposition {
+				t.Errorf("want %v, got %v", tt.ExpectedDisposition, disposition)
+			}
+		})
+	}
+}
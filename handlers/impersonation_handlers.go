@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// StartImpersonation signs the admin in as the user, with a read-only session. The session of the admin is restored
+// when the impersonation ends.
+func StartImpersonation(manager services.ImpersonationManager, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		username := services.CanonicalUsername(c.Param("username"))
+		if username == user.Username {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "admins cannot impersonate themselves"})
+			return
+		}
+
+		sessionId, ttl, err := manager.StartImpersonation(c.Copy(), username, user.UserID, c.GetString("session_id"), c.ClientIP(), c.Request.UserAgent())
+		if err == services.ErrNoUser {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user does not exist"})
+			return
+		} else if err != nil {
+			logger.Errorf("ImpersonationManager.StartImpersonation() raised an error while impersonating %v: %v", username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		for _, account := range []string{user.Username, username} {
+			if err := activityLogger.LogImpersonationStart(c.Copy(), account, user.UserID, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogImpersonationStart() raised an error: %v", err.Error())
+			}
+		}
+
+		logger.WithField("username", username).Infof("%v started impersonating user with username", user.Username)
+		common.SetSessionCookie(c, sessionId, ttl)
+		c.JSON(http.StatusOK, gin.H{"result": "impersonation started"})
+	}
+}
+
+func EndImpersonation(manager services.ImpersonationManager, renewer services.SessionRenewer, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var impersonator models.User
+		if i, impersonated := c.Get("impersonator"); !impersonated {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no one is being impersonated"})
+			return
+		} else {
+			impersonator = i.(models.User)
+		}
+
+		returnSessionId, err := manager.EndImpersonation(c.Copy(), c.GetString("session_id"))
+		if err == services.ErrNotImpersonating || err == services.ErrNoSession {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no one is being impersonated"})
+			return
+		} else if err != nil {
+			logger.Errorf("ImpersonationManager.EndImpersonation() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		for _, account := range []string{impersonator.Username, user.Username} {
+			if err := activityLogger.LogImpersonationEnd(c.Copy(), account, impersonator.UserID, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogImpersonationEnd() raised an error: %v", err.Error())
+			}
+		}
+
+		// The impersonator is signed out if their own session has expired in the meantime
+		restored := false
+		if returnSessionId != "" {
+			if ttl, err := renewer.RenewSession(c.Copy(), returnSessionId); err == nil {
+				common.SetSessionCookie(c, returnSessionId, ttl)
+				restored = true
+			} else if err != services.ErrNoSession {
+				logger.Errorf("SessionRenewer.RenewSession() raised an error while restoring the session of the impersonator: %v", err.Error())
+			}
+		}
+		if !restored {
+			common.ClearSessionCookie(c)
+		}
+
+		logger.WithField("username", user.Username).Infof("%v stopped impersonating user with username", impersonator.Username)
+		c.JSON(http.StatusOK, gin.H{"result": "impersonation ended"})
+	}
+}
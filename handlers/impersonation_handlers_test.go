@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type impersonationMocks struct {
+	manager        *mocks.MockImpersonationManager
+	activityLogger *mocks.MockActivityLogger
+}
+
+func TestStartImpersonation(t *testing.T) {
+	admin := models.User{UserID: primitive.NewObjectID(), Username: "aliparlakci", Roles: []string{services.RoleAdmin}}
+
+	tests := []struct {
+		Username     string
+		Prepare      func(m impersonationMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Username: "JohnDoe",
+			Prepare: func(m impersonationMocks) {
+				m.manager.EXPECT().StartImpersonation(gomock.Any(), "johndoe", admin.UserID, gomock.Any(), gomock.Any(), gomock.Any()).Return("someuuid", time.Hour, nil)
+				m.activityLogger.EXPECT().LogImpersonationStart(gomock.Any(), "aliparlakci", admin.UserID, gomock.Any()).Return(nil)
+				m.activityLogger.EXPECT().LogImpersonationStart(gomock.Any(), "johndoe", admin.UserID, gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "impersonation started"},
+		}, {
+			Username: "AliParlakci",
+			Prepare: func(m impersonationMocks) {
+				m.manager.EXPECT().StartImpersonation(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "admins cannot impersonate themselves"},
+		}, {
+			Username: "janedoe",
+			Prepare: func(m impersonationMocks) {
+				m.manager.EXPECT().StartImpersonation(gomock.Any(), "janedoe", admin.UserID, gomock.Any(), gomock.Any(), gomock.Any()).Return("", time.Duration(0), services.ErrNoUser)
+				m.activityLogger.EXPECT().LogImpersonationStart(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusNotFound,
+			ExpectedBody: gin.H{"error": "user does not exist"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := impersonationMocks{
+				manager:        mocks.NewMockImpersonationManager(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", admin)
+			})
+			r.POST("/api/admin/users/:username/impersonation", StartImpersonation(m.manager, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/admin/users/"+tt.Username+"/impersonation", nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
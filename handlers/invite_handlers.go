@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+func GetInvites(creator services.InviteCreator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		list, err := creator.ListUserInvites(c.Copy(), user.Username)
+		if err != nil {
+			logger.Errorf("InviteCreator.ListUserInvites() raised an error while fetching the invites of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": list})
+	}
+}
+
+func CreateInvite(creator services.InviteCreator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		invite, err := creator.CreateUserInvite(c.Copy(), user.Username)
+		if err == services.ErrInviteQuotaExceeded {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.Errorf("InviteCreator.CreateUserInvite() raised an error while creating an invite for %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"result": invite})
+	}
+}
+
+// IssueInvite creates an invite which is not counted against the quota of the admin.
+func IssueInvite(manager services.InviteManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.NewInvite
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		if form.MaxUses == 0 {
+			form.MaxUses = 1
+		}
+
+		var ttl time.Duration
+		if form.ExpiresIn != "" {
+			var err error
+			if ttl, err = time.ParseDuration(form.ExpiresIn); err != nil || ttl <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must be a positive duration, e.g. 72h"})
+				return
+			}
+		}
+
+		invite, err := manager.CreateInvite(c.Copy(), user.Username, form.MaxUses, ttl)
+		if err != nil {
+			logger.Errorf("InviteManager.CreateInvite() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"result": invite})
+	}
+}
+
+func ListInvites(manager services.InviteManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		skip, limit, ok := pagination(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+			return
+		}
+
+		invites, err := manager.ListInvites(c.Copy(), skip, limit)
+		if err != nil {
+			logger.Errorf("InviteManager.ListInvites() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": invites})
+	}
+}
+
+func SetInviteQuota(manager services.InviteManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var form models.InviteQuotaForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quota must be a non-negative number"})
+			return
+		}
+
+		username := services.CanonicalUsername(c.Param("username"))
+		if err := manager.SetInviteQuota(c.Copy(), username, *form.Quota); err == services.ErrNoUser {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user does not exist"})
+			return
+		} else if err != nil {
+			logger.Errorf("InviteManager.SetInviteQuota() raised an error while setting the invite quota of %v: %v", username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "invite quota is set"})
+	}
+}
@@ -76,6 +76,34 @@ func CheckNewMessages(getter services.MessageGetter) gin.HandlerFunc {
 	}
 }
 
+func CheckNewMessagesFrom(getter services.MessageGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		senderUsername := services.CanonicalUsername(c.Param("username"))
+		if senderUsername == "" {
+			c.JSON(http.StatusBadRequest, gin.H{})
+			return
+		}
+
+		count, err := getter.CheckNewMessagesFrom(c.Copy(), user.Username, senderUsername)
+		if err != nil {
+			logger.Errorf("services.MessageGetter.CheckNewMessagesFrom() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": count})
+	}
+}
+
 func SendMessage(sender services.MessageSender) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := common.LoggerWithRequestId(c.Copy())
@@ -88,15 +116,32 @@ func SendMessage(sender services.MessageSender) gin.HandlerFunc {
 			user = u.(models.User)
 		}
 
+		// Impersonation sessions are read-only anyway, but nobody must ever be able to send messages on behalf of others
+		if _, impersonated := c.Get("impersonator"); impersonated {
+			c.JSON(http.StatusForbidden, gin.H{"error": "messages cannot be sent while impersonating"})
+			return
+		}
+
+		if user.EmailUnverified() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "email is not verified"})
+			return
+		}
+
 		var message models.NewMessage
 		if err := c.Bind(&message); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{})
 			return
 		}
 
-		if _, err := sender.SendMessage(c.Copy(), message.Body, user.Username, message.To); err == services.ErrNoUser {
+		if _, err := sender.SendMessage(c.Copy(), message.Body, user.Username, services.CanonicalUsername(message.To)); err == services.ErrNoUser {
 			c.JSON(http.StatusBadRequest, gin.H{"result": "user does not exist"})
 			return
+		} else if err == services.ErrRecipientDeactivated {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user has deactivated their account"})
+			return
+		} else if err == services.ErrMessagingNotAllowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "user does not accept messages from you"})
+			return
 		} else if err != nil {
 			logger.Errorf("services.MessageSender.SendMessage() raised an error: %v", err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{})
@@ -148,7 +193,7 @@ func ReadMessages(reader services.MessageReader) gin.HandlerFunc {
 			user = u.(models.User)
 		}
 
-		senderUsername := c.Param("username")
+		senderUsername := services.CanonicalUsername(c.Param("username"))
 		if senderUsername == "" {
 			c.JSON(http.StatusBadRequest, gin.H{})
 			return
@@ -239,6 +239,8 @@ func TestCheckNewMessages(t *testing.T) {
 func TestSendMessage(t *testing.T) {
 	tests := []struct {
 		Body         multipart.Form
+		Unverified   bool
+		Impersonated bool
 		Prepare      func(sender *mocks.MockMessageSender)
 		ExpectedCode int
 		ExpectedBody gin.H
@@ -303,6 +305,52 @@ func TestSendMessage(t *testing.T) {
 			},
 			ExpectedCode: http.StatusInternalServerError,
 			ExpectedBody: gin.H{},
+		}, {
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"to":   {"tarkan"},
+					"body": {"tarkanla mesajlasmak bu kadar kolay miymis yav"},
+				}},
+			Unverified: true,
+			Prepare: func(sender *mocks.MockMessageSender) {
+				sender.EXPECT().SendMessage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "email is not verified"},
+		}, {
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"to":   {"tarkan"},
+					"body": {"tarkanla mesajlasmak bu kadar kolay miymis yav"},
+				}},
+			Prepare: func(sender *mocks.MockMessageSender) {
+				sender.EXPECT().SendMessage(gomock.Any(), "tarkanla mesajlasmak bu kadar kolay miymis yav", "mazhar", "tarkan").Return("", services.ErrMessagingNotAllowed)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "user does not accept messages from you"},
+		}, {
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"to":   {"tarkan"},
+					"body": {"tarkanla mesajlasmak bu kadar kolay miymis yav"},
+				}},
+			Prepare: func(sender *mocks.MockMessageSender) {
+				sender.EXPECT().SendMessage(gomock.Any(), "tarkanla mesajlasmak bu kadar kolay miymis yav", "mazhar", "tarkan").Return("", services.ErrRecipientDeactivated)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "user has deactivated their account"},
+		}, {
+			Body: multipart.Form{
+				Value: map[string][]string{
+					"to":   {"tarkan"},
+					"body": {"tarkanla mesajlasmak bu kadar kolay miymis yav"},
+				}},
+			Impersonated: true,
+			Prepare: func(sender *mocks.MockMessageSender) {
+				sender.EXPECT().SendMessage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "messages cannot be sent while impersonating"},
 		},
 	}
 
@@ -320,7 +368,10 @@ func TestSendMessage(t *testing.T) {
 			recorder := httptest.NewRecorder()
 			_, r := gin.CreateTestContext(recorder)
 			r.Use(func(c *gin.Context) {
-				c.Set("user", models.User{Username: "mazhar"})
+				c.Set("user", models.User{Username: "mazhar", Email: "mazhar@example.com", EmailVerified: !tt.Unverified})
+				if tt.Impersonated {
+					c.Set("impersonator", models.User{Username: "ozkanugur", Roles: []string{services.RoleAdmin}})
+				}
 			})
 			r.POST("/api/messages/send", SendMessage(mockedMessageSender))
 
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// BeginOIDCLogin redirects the user to the identity provider to sign in, even if they are signed in already.
+func BeginOIDCLogin(authenticator services.OIDCAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authURL, state, err := authenticator.BeginLogin(c.Copy(), "")
+		redirectToIdentityProvider(c, authURL, state, err)
+	}
+}
+
+// LinkOIDCIdentity redirects the user to the identity provider to link the identity to their account. It is not
+// available to impersonation sessions, since the impersonator could sign in as the user with their own identity
+// afterwards, and must be mounted without scopes, so that API keys cannot be used to take the account over either.
+func LinkOIDCIdentity(authenticator services.OIDCAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		if _, impersonated := c.Get("impersonator"); impersonated {
+			c.JSON(http.StatusForbidden, gin.H{"error": "identities cannot be linked during an impersonation"})
+			return
+		}
+
+		authURL, state, err := authenticator.BeginLogin(c.Copy(), user.Username)
+		redirectToIdentityProvider(c, authURL, state, err)
+	}
+}
+
+// ReauthenticateOIDC redirects the user to the identity provider to confirm their identity, which the users without
+// a password do instead of entering it. Like LinkOIDCIdentity, it must be mounted without scopes.
+func ReauthenticateOIDC(authenticator services.OIDCAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		authURL, state, err := authenticator.BeginReauthentication(c.Copy(), user.UserID.Hex())
+		redirectToIdentityProvider(c, authURL, state, err)
+	}
+}
+
+// redirectToIdentityProvider redirects the user to the login the authenticator has begun, unless it has failed.
+func redirectToIdentityProvider(c *gin.Context, authURL, state string, err error) {
+	logger := common.LoggerWithRequestId(c.Copy())
+
+	if err != nil {
+		logger.Errorf("OIDCAuthenticator raised an error while beginning the login: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+		return
+	}
+
+	// The state is bound to the browser which has started the login, so that nobody can
+	// complete a login they have started in someone else's browser.
+	c.SetCookie("oidc_state", state, 0, "/", "localhost", false, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// CompleteOIDCLogin does not create accounts for unknown identities when signups are invite only,
+// since identity providers cannot pass an invite code along. Users with two-factor authentication
+// still need a code, just like when they sign in with their password.
+func CompleteOIDCLogin(authenticator services.OIDCAuthenticator, reauthenticator services.Reauthenticator, linker services.IdentityLinker, pendingSignins services.PendingSigninCreator, sessions services.SessionCreator, activityLogger services.ActivityLogger, invites services.InviteRedeemer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		state, err := c.Cookie("oidc_state")
+		if err != nil || state == "" || state != c.Query("state") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "login state is invalid or expired"})
+			return
+		}
+		c.SetCookie("oidc_state", "", -1, "/", "localhost", false, true)
+
+		if c.Query("error") != "" || c.Query("code") == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider has refused the login"})
+			return
+		}
+
+		login, err := authenticator.CompleteLogin(c.Copy(), state, c.Query("code"))
+		switch err {
+		case nil:
+		case services.ErrInvalidOIDCState:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "login state is invalid or expired"})
+			return
+		case services.ErrOIDCCodeRejected, services.ErrInvalidIDToken:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider has refused the login"})
+			return
+		default:
+			logger.Errorf("OIDCAuthenticator.CompleteLogin() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+			return
+		}
+
+		user, err := linker.GetUserByIdentity(c.Copy(), login.Identity.Issuer, login.Identity.Subject)
+		if err != nil && err != services.ErrNoUser {
+			logger.Errorf("IdentityLinker.GetUserByIdentity() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+			return
+		}
+		linked := err == nil
+
+		if login.Reauthenticate != "" {
+			if !linked || user.UserID.Hex() != login.Reauthenticate {
+				c.JSON(http.StatusForbidden, gin.H{"error": "identity is not linked to the user"})
+				return
+			}
+
+			if err := reauthenticator.ConfirmReauthentication(c.Copy(), login.Reauthenticate); err != nil {
+				logger.Errorf("Reauthenticator.ConfirmReauthentication() raised an error: %v", err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"result": "identity is confirmed"})
+			return
+		}
+
+		if login.LinkTo != "" {
+			if linked && user.Username != login.LinkTo {
+				c.JSON(http.StatusConflict, gin.H{"error": "identity is already linked to another user"})
+				return
+			}
+
+			if !linked {
+				if err := linker.LinkIdentity(c.Copy(), login.LinkTo, login.Identity); err == services.ErrIdentityLinked {
+					c.JSON(http.StatusConflict, gin.H{"error": "identity is already linked to another user"})
+					return
+				} else if err != nil {
+					logger.Errorf("IdentityLinker.LinkIdentity() raised an error: %v", err.Error())
+					c.JSON(http.StatusInternalServerError, gin.H{})
+					return
+				}
+
+				if err := activityLogger.LogIdentityLink(c.Copy(), login.LinkTo, c.ClientIP()); err != nil {
+					logger.Errorf("ActivityLogger.LogIdentityLink() raised an error: %v", err.Error())
+				}
+			}
+
+			c.JSON(http.StatusOK, gin.H{"result": "identity is linked"})
+			return
+		}
+
+		if !linked && invites.InvitesRequired() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "an invite code is required to sign up"})
+			return
+		}
+		if !linked {
+			user, err = linker.ProvisionUser(c.Copy(), login.PreferredUsername, login.Identity)
+			if err != nil {
+				logger.Errorf("IdentityLinker.ProvisionUser() raised an error: %v", err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot log in"})
+				return
+			}
+		}
+		if err := services.CheckAccountStatus(user); err != nil {
+			common.RejectInactiveAccount(c, err.(services.AccountStatusError))
+			return
+		}
+
+		if user.TwoFactorEnabled() {
+			requireTwoFactor(c, logger, pendingSignins, user.Username)
+			return
+		}
+
+		signin(c, logger, sessions, activityLogger, user.Username)
+	}
+}
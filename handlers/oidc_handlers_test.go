@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type oidcLoginMocks struct {
+	authenticator   *mocks.MockOIDCAuthenticator
+	reauthenticator *mocks.MockReauthenticator
+	linker          *mocks.MockIdentityLinker
+	pendingSignins  *mocks.MockPendingSigninCreator
+	sessions        *mocks.MockSessionCreator
+	activityLogger  *mocks.MockActivityLogger
+	invites         *mocks.MockInviteRedeemer
+}
+
+func TestCompleteOIDCLogin(t *testing.T) {
+	identity := models.Identity{Issuer: "https://idp.example.com", Subject: "1234"}
+	login := models.OIDCLogin{Identity: identity, PreferredUsername: "johndoe"}
+	userID := primitive.NewObjectID()
+	reauthentication := models.OIDCLogin{Identity: identity, Reauthenticate: userID.Hex()}
+
+	tests := []struct {
+		State          string
+		Prepare        func(m oidcLoginMocks)
+		ExpectedCode   int
+		ExpectedBody   gin.H
+		ExpectedCookie string
+	}{
+		{
+			State: "somestate",
+			Prepare: func(m oidcLoginMocks) {
+				m.authenticator.EXPECT().CompleteLogin(gomock.Any(), "somestate", "somecode").Return(login, nil)
+				m.linker.EXPECT().GetUserByIdentity(gomock.Any(), identity.Issuer, identity.Subject).Return(models.User{Username: "johndoe"}, nil)
+				m.sessions.EXPECT().CreateSession(gomock.Any(), "johndoe", gomock.Any(), gomock.Any()).Return("someuuid", time.Hour, nil)
+				m.activityLogger.EXPECT().LogSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode:   http.StatusOK,
+			ExpectedBody:   gin.H{"result": "logged in"},
+			ExpectedCookie: "session",
+		}, {
+			State: "somestate",
+			Prepare: func(m oidcLoginMocks) {
+				m.authenticator.EXPECT().CompleteLogin(gomock.Any(), "somestate", "somecode").Return(login, nil)
+				m.linker.EXPECT().GetUserByIdentity(gomock.Any(), identity.Issuer, identity.Subject).Return(models.User{Username: "johndoe", TOTPSecret: "JBSWY3DPEHPK3PXP"}, nil)
+				m.pendingSignins.EXPECT().CreatePendingSignin(gomock.Any(), "johndoe").Return("pendingid", nil)
+				m.sessions.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode:   http.StatusAccepted,
+			ExpectedBody:   gin.H{"result": "two-factor authentication is required"},
+			ExpectedCookie: "pending_signin",
+		}, {
+			State: "somestate",
+			Prepare: func(m oidcLoginMocks) {
+				m.authenticator.EXPECT().CompleteLogin(gomock.Any(), "somestate", "somecode").Return(reauthentication, nil)
+				m.linker.EXPECT().GetUserByIdentity(gomock.Any(), identity.Issuer, identity.Subject).Return(models.User{UserID: userID, Username: "johndoe"}, nil)
+				m.reauthenticator.EXPECT().ConfirmReauthentication(gomock.Any(), userID.Hex()).Return(nil)
+				m.sessions.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "identity is confirmed"},
+		}, {
+			State: "somestate",
+			Prepare: func(m oidcLoginMocks) {
+				m.authenticator.EXPECT().CompleteLogin(gomock.Any(), "somestate", "somecode").Return(reauthentication, nil)
+				m.linker.EXPECT().GetUserByIdentity(gomock.Any(), identity.Issuer, identity.Subject).Return(models.User{UserID: primitive.NewObjectID(), Username: "janedoe"}, nil)
+				m.reauthenticator.EXPECT().ConfirmReauthentication(gomock.Any(), gomock.Any()).Times(0)
+				m.sessions.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "identity is not linked to the user"},
+		}, {
+			State: "otherstate",
+			Prepare: func(m oidcLoginMocks) {
+				m.authenticator.EXPECT().CompleteLogin(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "login state is invalid or expired"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := oidcLoginMocks{
+				authenticator:   mocks.NewMockOIDCAuthenticator(ctrl),
+				reauthenticator: mocks.NewMockReauthenticator(ctrl),
+				linker:          mocks.NewMockIdentityLinker(ctrl),
+				pendingSignins:  mocks.NewMockPendingSigninCreator(ctrl),
+				sessions:        mocks.NewMockSessionCreator(ctrl),
+				activityLogger:  mocks.NewMockActivityLogger(ctrl),
+				invites:         mocks.NewMockInviteRedeemer(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.GET("/api/oidc/callback", CompleteOIDCLogin(m.authenticator, m.reauthenticator, m.linker, m.pendingSignins, m.sessions, m.activityLogger, m.invites))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/oidc/callback?state="+tt.State+"&code=somecode", nil)
+			request.AddCookie(&http.Cookie{Name: "oidc_state", Value: "somestate"})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+
+			if tt.ExpectedCookie != "" {
+				found := false
+				for _, cookie := range recorder.Result().Cookies() {
+					found = found || (cookie.Name == tt.ExpectedCookie && cookie.Value != "")
+				}
+				if !found {
+					t.Errorf("want the %v cookie to be set", tt.ExpectedCookie)
+				}
+			}
+		})
+	}
+}
+
+func TestBeginOIDCLogin(t *testing.T) {
+	tests := []struct {
+		Path             string
+		Impersonated     bool
+		Prepare          func(authenticator *mocks.MockOIDCAuthenticator)
+		ExpectedCode     int
+		ExpectedLocation string
+	}{
+		{
+			Path: "/api/oidc/login",
+			Prepare: func(authenticator *mocks.MockOIDCAuthenticator) {
+				authenticator.EXPECT().BeginLogin(gomock.Any(), "").Return("https://idp.example.com/authorize", "somestate", nil)
+			},
+			ExpectedCode:     http.StatusFound,
+			ExpectedLocation: "https://idp.example.com/authorize",
+		}, {
+			Path: "/api/me/oidc/link",
+			Prepare: func(authenticator *mocks.MockOIDCAuthenticator) {
+				authenticator.EXPECT().BeginLogin(gomock.Any(), "johndoe").Return("https://idp.example.com/authorize", "somestate", nil)
+			},
+			ExpectedCode:     http.StatusFound,
+			ExpectedLocation: "https://idp.example.com/authorize",
+		}, {
+			Path:         "/api/me/oidc/link",
+			Impersonated: true,
+			Prepare: func(authenticator *mocks.MockOIDCAuthenticator) {
+				authenticator.EXPECT().BeginLogin(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			authenticator := mocks.NewMockOIDCAuthenticator(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(authenticator)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "johndoe"})
+				if tt.Impersonated {
+					c.Set("impersonator", models.User{Username: "aliparlakci"})
+				}
+			})
+			r.GET("/api/oidc/login", BeginOIDCLogin(authenticator))
+			r.GET("/api/me/oidc/link", LinkOIDCIdentity(authenticator))
+
+			request, err := http.NewRequest(http.MethodGet, tt.Path, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+			if location := recorder.Result().Header.Get("Location"); location != tt.ExpectedLocation {
+				t.Errorf("want %q, got %q", tt.ExpectedLocation, location)
+			}
+		})
+	}
+}
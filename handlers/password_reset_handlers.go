@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequestPasswordReset responds the same whether the user exists or not,
+// so that it cannot be used to find out which usernames are taken.
+func RequestPasswordReset(userGetter services.UserGetter, creator services.ResetTokenCreator, notifier services.Notifier, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var form models.PasswordResetRequestForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		form.Username = services.CanonicalUsername(form.Username)
+
+		exists, err := userGetter.UserExists(c.Copy(), form.Username)
+		if err != nil {
+			logger.Errorf("UserGetter.UserExists() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusOK, gin.H{"result": "reset token is sent"})
+			return
+		}
+
+		token, err := creator.CreateResetToken(c.Copy(), form.Username)
+		if err != nil {
+			logger.Errorf("ResetTokenCreator.CreateResetToken() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		message := fmt.Sprintf("Use the following token to reset your password: %v", token)
+		if err := notifier.Notify(c.Copy(), form.Username, "Password reset", message); err != nil {
+			logger.Errorf("Notifier.Notify() raised an error while sending the reset token: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := activityLogger.LogPasswordResetRequest(c.Copy(), form.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogPasswordResetRequest() raised an error: %v", err.Error())
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "reset token is sent"})
+	}
+}
+
+func ResetPassword(consumer services.ResetTokenConsumer, hasher services.PasswordHasher, updater services.UserUpdater, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var form models.PasswordResetForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		username, err := consumer.ConsumeResetToken(c.Copy(), form.Token)
+		if err == services.ErrInvalidResetToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reset token is invalid or expired"})
+			return
+		} else if err != nil {
+			logger.Errorf("ResetTokenConsumer.ConsumeResetToken() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		hashedPassword, err := hasher.HashPassword(form.NewPassword)
+		if err != nil {
+			logger.Errorf("cannot hash the password: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := updater.UpdatePassword(c.Copy(), username, hashedPassword); err != nil {
+			logger.WithField("username", username).Errorf("UserUpdater.UpdatePassword() raised an error while resetting the password of user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := revoker.RevokeOtherSessions(c.Copy(), username, ""); err != nil {
+			logger.Errorf("SessionRevoker.RevokeOtherSessions() raised an error after the password reset: %v", err.Error())
+		}
+		if err := tokenRevoker.RevokeUserTokens(c.Copy(), username); err != nil {
+			logger.Errorf("TokenRevoker.RevokeUserTokens() raised an error after the password reset: %v", err.Error())
+		}
+
+		if err := activityLogger.LogPasswordReset(c.Copy(), username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogPasswordReset() raised an error: %v", err.Error())
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "password is reset"})
+	}
+}
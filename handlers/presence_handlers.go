@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxPresenceUsers = 100
+
+func GetPresence(getter services.PresenceGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		usernames, ok := presenceUsernames(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "users must be 1 to 100 comma separated usernames"})
+			return
+		}
+
+		presences, err := getter.GetPresences(c.Copy(), usernames)
+		if err != nil {
+			logger.Errorf("PresenceGetter.GetPresences() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": presences})
+	}
+}
+
+// PresenceEvents streams the presence of the users as server-sent "presence" events. The current presence of every
+// user is sent first, and then again whenever it changes. The stream also keeps its own user online while it is open,
+// unless it is opened by an impersonator. The user is fetched again on every tick, so that hiding their presence
+// while the stream is open takes effect.
+func PresenceEvents(getter services.PresenceGetter, tracker services.PresenceTracker, userGetter services.UserGetter, interval time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		usernames, ok := presenceUsernames(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "users must be 1 to 100 comma separated usernames"})
+			return
+		}
+
+		_, impersonated := c.Get("impersonator")
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+
+		statuses := make(map[string]string, len(usernames))
+		c.Stream(func(w io.Writer) bool {
+			presences, err := getter.GetPresences(c.Copy(), usernames)
+			if err != nil {
+				logger.Errorf("PresenceGetter.GetPresences() raised an error: %v", err.Error())
+				return false
+			}
+
+			for _, presence := range presences {
+				if statuses[presence.Username] != presence.Status {
+					statuses[presence.Username] = presence.Status
+					c.SSEvent("presence", presence)
+				}
+			}
+
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+			}
+
+			if impersonated {
+				return true
+			}
+			current, err := userGetter.GetUserByID(c.Copy(), user.UserID)
+			if err != nil {
+				logger.Errorf("UserGetter.GetUserByID() raised an error while fetching the user of the stream: %v", err.Error())
+				return true
+			}
+			if !current.Profile.PresenceHidden() {
+				if err := tracker.TouchPresence(c.Copy(), user.UserID); err != nil {
+					logger.Errorf("PresenceTracker.TouchPresence() raised an error: %v", err.Error())
+				}
+			}
+			return true
+		})
+	}
+}
+
+// presenceUsernames reads the "users" query parameter, which is a comma separated list of usernames.
+func presenceUsernames(c *gin.Context) ([]string, bool) {
+	seen := map[string]bool{}
+	usernames := []string{}
+	for _, username := range strings.Split(c.Query("users"), ",") {
+		username = services.CanonicalUsername(username)
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+
+	return usernames, len(usernames) > 0 && len(usernames) <= maxPresenceUsers
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetPresence(t *testing.T) {
+	lastSeen := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Query        string
+		Prepare      func(m *mocks.MockPresenceGetter)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Query: "?users=AliParlakci,johndoe,aliparlakci",
+			Prepare: func(m *mocks.MockPresenceGetter) {
+				m.EXPECT().GetPresences(gomock.Any(), []string{"aliparlakci", "johndoe"}).Return([]models.Presence{
+					{Username: "aliparlakci", Status: models.PresenceOnline, LastSeen: &lastSeen},
+					{Username: "johndoe", Status: models.PresenceOffline},
+				}, nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": []gin.H{
+				{"username": "aliparlakci", "status": "online", "last_seen": lastSeen},
+				{"username": "johndoe", "status": "offline", "last_seen": nil},
+			}},
+		}, {
+			Query: "?users=",
+			Prepare: func(m *mocks.MockPresenceGetter) {
+				m.EXPECT().GetPresences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "users must be 1 to 100 comma separated usernames"},
+		}, {
+			Query: "?users=" + func() string {
+				usernames := make([]string, maxPresenceUsers+1)
+				for i := range usernames {
+					usernames[i] = fmt.Sprintf("user%v", i)
+				}
+				return strings.Join(usernames, ",")
+			}(),
+			Prepare: func(m *mocks.MockPresenceGetter) {
+				m.EXPECT().GetPresences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "users must be 1 to 100 comma separated usernames"},
+		}, {
+			Query: "?users=aliparlakci",
+			Prepare: func(m *mocks.MockPresenceGetter) {
+				m.EXPECT().GetPresences(gomock.Any(), []string{"aliparlakci"}).Return(nil, errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			getter := mocks.NewMockPresenceGetter(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(getter)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.GET("/api/presence", GetPresence(getter))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/presence"+tt.Query, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+// streamRecorder can record the streams of gin, which need the response writer to be a CloseNotifier.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (streamRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+type presenceEventsMocks struct {
+	getter     *mocks.MockPresenceGetter
+	tracker    *mocks.MockPresenceTracker
+	userGetter *mocks.MockUserGetter
+}
+
+func TestPresenceEvents(t *testing.T) {
+	presences := []models.Presence{{Username: "johndoe", Status: models.PresenceOnline}}
+	userID := primitive.NewObjectID()
+
+	tests := []struct {
+		Prepare func(m presenceEventsMocks)
+	}{
+		{
+			Prepare: func(m presenceEventsMocks) {
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{UserID: userID, Username: "aliparlakci"}, nil)
+				m.tracker.EXPECT().TouchPresence(gomock.Any(), userID).Return(nil)
+			},
+		}, {
+			Prepare: func(m presenceEventsMocks) {
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{UserID: userID, Username: "aliparlakci", Profile: models.Profile{HidePresence: true}}, nil)
+				m.tracker.EXPECT().TouchPresence(gomock.Any(), gomock.Any()).Times(0)
+			},
+		}, {
+			Prepare: func(m presenceEventsMocks) {
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{UserID: userID, Username: "aliparlakci", Profile: models.Profile{Private: true}}, nil)
+				m.tracker.EXPECT().TouchPresence(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := presenceEventsMocks{
+				getter:     mocks.NewMockPresenceGetter(ctrl),
+				tracker:    mocks.NewMockPresenceTracker(ctrl),
+				userGetter: mocks.NewMockUserGetter(ctrl),
+			}
+
+			// The stream ends on the second tick, once the presence cannot be fetched
+			gomock.InOrder(
+				m.getter.EXPECT().GetPresences(gomock.Any(), []string{"johndoe"}).Return(presences, nil),
+				m.getter.EXPECT().GetPresences(gomock.Any(), []string{"johndoe"}).Return(nil, errors.New("")),
+			)
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := streamRecorder{httptest.NewRecorder()}
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				// The user of the stream does not hide their presence when the stream is opened
+				c.Set("user", models.User{UserID: userID, Username: "aliparlakci"})
+			})
+			r.GET("/api/presence/events", PresenceEvents(m.getter, m.tracker, m.userGetter, time.Millisecond))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/presence/events?users=johndoe", nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if !strings.Contains(recorder.Body.String(), "event:presence") {
+				t.Errorf("want a presence event, got %v", recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+func GetProfile(getter services.ProfileGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		username := services.CanonicalUsername(c.Param("username"))
+		profile, err := getter.GetProfile(c.Copy(), username)
+		if err == services.ErrNoUser {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user does not exist"})
+			return
+		} else if err != nil {
+			logger.Errorf("ProfileGetter.GetProfile() raised an error while fetching the profile of %v: %v", username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": profile})
+	}
+}
+
+func SearchUsers(searcher services.UserSearcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		skip, limit, ok := pagination(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+			return
+		}
+
+		profiles, err := searcher.SearchUsers(c.Copy(), c.Query("q"), skip, limit)
+		if err == services.ErrInvalidSearchQuery {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.Errorf("UserSearcher.SearchUsers() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": profiles})
+	}
+}
+
+// GetAvatar can be cached forever when it is requested with the current version of the avatar, since the avatar urls
+// change with every upload. The avatars of private users are only returned to signed in users.
+func GetAvatar(getter services.AvatarGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		username := services.CanonicalUsername(c.Param("username"))
+		avatar, err := getter.GetAvatar(c.Copy(), username)
+		if err == services.ErrNoAvatar {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user does not have an avatar"})
+			return
+		} else if err != nil {
+			logger.Errorf("AvatarGetter.GetAvatar() raised an error while fetching the avatar of %v: %v", username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		visibility := "public"
+		if avatar.Private {
+			if _, exists := c.Get("user"); !exists {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user does not have an avatar"})
+				return
+			}
+			visibility = "private"
+		}
+
+		if c.Query("v") == avatar.Version {
+			c.Header("Cache-Control", visibility+", max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", visibility+", no-cache")
+		}
+		c.Data(http.StatusOK, "image/png", avatar.Image)
+	}
+}
+
+func UpdateProfile(updater services.ProfileUpdater, getter services.ProfileGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAvatarUpload+1<<20)
+
+		var form models.ProfileForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		var image io.Reader
+		if header, err := c.FormFile("avatar"); err == nil && !form.RemoveAvatar {
+			file, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+				return
+			}
+			defer file.Close()
+			image = file
+		}
+
+		switch err := updater.UpdateProfile(c.Copy(), user.Username, form, image); err {
+		case nil:
+		case services.ErrInvalidDisplayName, services.ErrInvalidBio, services.ErrInvalidAvatar, services.ErrAvatarTooLarge:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		default:
+			logger.Errorf("ProfileUpdater.UpdateProfile() raised an error while updating the profile of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		profile, err := getter.GetProfile(c.Copy(), user.Username)
+		if err != nil {
+			logger.Errorf("ProfileGetter.GetProfile() raised an error while fetching the profile of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": profile})
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearchUsers(t *testing.T) {
+	tests := []struct {
+		Query        string
+		Prepare      func(m *mocks.MockUserSearcher)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Query: "?q=ali",
+			Prepare: func(m *mocks.MockUserSearcher) {
+				m.EXPECT().SearchUsers(gomock.Any(), "ali", int64(0), int64(defaultPageSize)).Return([]models.PublicProfile{
+					{Username: "aliparlakci", DisplayName: "Ali Parlakçı", Bio: "", AvatarURL: "/api/users/aliparlakci/avatar?v=abc"},
+				}, nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": []gin.H{
+				{"username": "aliparlakci", "display_name": "Ali Parlakçı", "bio": "", "avatar_url": "/api/users/aliparlakci/avatar?v=abc"},
+			}},
+		}, {
+			Query: "?q=john&offset=20&limit=10",
+			Prepare: func(m *mocks.MockUserSearcher) {
+				m.EXPECT().SearchUsers(gomock.Any(), "john", int64(20), int64(10)).Return([]models.PublicProfile{}, nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": []gin.H{}},
+		}, {
+			Query: "?q=a",
+			Prepare: func(m *mocks.MockUserSearcher) {
+				m.EXPECT().SearchUsers(gomock.Any(), "a", gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidSearchQuery)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrInvalidSearchQuery.Error()},
+		}, {
+			Query: "?q=ali&limit=0",
+			Prepare: func(m *mocks.MockUserSearcher) {
+				m.EXPECT().SearchUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "invalid pagination"},
+		}, {
+			Query: "?q=ali",
+			Prepare: func(m *mocks.MockUserSearcher) {
+				m.EXPECT().SearchUsers(gomock.Any(), "ali", gomock.Any(), gomock.Any()).Return(nil, errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			searcher := mocks.NewMockUserSearcher(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(searcher)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.GET("/api/users", SearchUsers(searcher))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/users"+tt.Query, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestGetAvatar(t *testing.T) {
+	image := []byte("someimage")
+
+	tests := []struct {
+		Query                string
+		SignedIn             bool
+		Avatar               models.Avatar
+		Err                  error
+		ExpectedCode         int
+		ExpectedCacheControl string
+	}{
+		{
+			Query:                "?v=abc",
+			Avatar:               models.Avatar{Image: image, Version: "abc"},
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, max-age=31536000, immutable",
+		}, {
+			Query:                "?v=old",
+			Avatar:               models.Avatar{Image: image, Version: "abc"},
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, no-cache",
+		}, {
+			Avatar:               models.Avatar{Image: image, Version: "abc"},
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, no-cache",
+		}, {
+			Query:        "?v=abc",
+			Avatar:       models.Avatar{Image: image, Version: "abc", Private: true},
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Query:                "?v=abc",
+			SignedIn:             true,
+			Avatar:               models.Avatar{Image: image, Version: "abc", Private: true},
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "private, max-age=31536000, immutable",
+		}, {
+			Err:          services.ErrNoAvatar,
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Err:          errors.New(""),
+			ExpectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			getter := mocks.NewMockAvatarGetter(ctrl)
+			getter.EXPECT().GetAvatar(gomock.Any(), "johndoe").Return(tt.Avatar, tt.Err)
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			if tt.SignedIn {
+				r.Use(func(c *gin.Context) {
+					c.Set("user", models.User{Username: "janedoe"})
+				})
+			}
+			r.GET("/api/users/:username/avatar", GetAvatar(getter))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/users/JohnDoe/avatar"+tt.Query, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+			if cacheControl := recorder.Result().Header.Get("Cache-Control"); cacheControl != tt.ExpectedCacheControl {
+				t.Errorf("want %q, got %q", tt.ExpectedCacheControl, cacheControl)
+			}
+			if tt.ExpectedCode == http.StatusOK && recorder.Body.String() != string(image) {
+				t.Errorf("want the avatar, got %v", recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func GetChallenge(issuer services.ProofOfWorkIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		challenge, err := issuer.IssueChallenge(c.Copy(), c.Query("purpose"))
+		if err == services.ErrUnknownPurpose {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.Errorf("ProofOfWorkIssuer.IssueChallenge() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": challenge})
+	}
+}
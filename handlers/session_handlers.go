@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func GetSessions(lister services.SessionLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		sessions, err := lister.ListSessions(c.Copy(), user.Username)
+		if err != nil {
+			logger.Errorf("SessionLister.ListSessions() raised an error while listing sessions of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		current := services.PublicSessionId(c.GetString("session_id"))
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == current
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": sessions})
+	}
+}
+
+func RevokeSession(revoker services.SessionRevoker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		publicId := c.Param("id")
+		if publicId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{})
+			return
+		}
+
+		if err := revoker.RevokeUserSession(c.Copy(), user.Username, publicId); err == services.ErrNoSession {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session does not exist"})
+			return
+		} else if err != nil {
+			logger.Errorf("SessionRevoker.RevokeUserSession() raised an error while revoking a session of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if publicId == services.PublicSessionId(c.GetString("session_id")) {
+			common.ClearSessionCookie(c)
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+func RevokeOtherSessions(revoker services.SessionRevoker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		if err := revoker.RevokeOtherSessions(c.Copy(), user.Username, c.GetString("session_id")); err != nil {
+			logger.Errorf("SessionRevoker.RevokeOtherSessions() raised an error while revoking sessions of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetSessions(t *testing.T) {
+	tests := []struct {
+		Prepare      func(lister *mocks.MockSessionLister)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Prepare: func(lister *mocks.MockSessionLister) {
+				lister.EXPECT().ListSessions(gomock.Any(), "johndoe").Return([]models.Session{
+					{
+						ID:        services.PublicSessionId("currentuuid"),
+						IP:        "127.0.0.1",
+						UserAgent: "curl/7.79.1",
+						CreatedAt: time.Time{},
+						LastSeen:  time.Time{},
+					},
+					{
+						ID:        services.PublicSessionId("otheruuid"),
+						IP:        "10.0.0.1",
+						UserAgent: "Mozilla/5.0",
+						CreatedAt: time.Time{},
+						LastSeen:  time.Time{},
+					},
+				}, nil).MinTimes(1)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": []gin.H{
+				{
+					"id":         services.PublicSessionId("currentuuid"),
+					"ip":         "127.0.0.1",
+					"user_agent": "curl/7.79.1",
+					"created_at": time.Time{},
+					"last_seen":  time.Time{},
+					"current":    true,
+				},
+				{
+					"id":         services.PublicSessionId("otheruuid"),
+					"ip":         "10.0.0.1",
+					"user_agent": "Mozilla/5.0",
+					"created_at": time.Time{},
+					"last_seen":  time.Time{},
+					"current":    false,
+				},
+			}},
+		}, {
+			Prepare: func(lister *mocks.MockSessionLister) {
+				lister.EXPECT().ListSessions(gomock.Any(), "johndoe").Return(nil, errors.New("")).MinTimes(1)
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockedSessionLister := mocks.NewMockSessionLister(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(mockedSessionLister)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "johndoe"})
+				c.Set("session_id", "currentuuid")
+			})
+			r.GET("/api/sessions", GetSessions(mockedSessionLister))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/sessions", nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	tests := []struct {
+		PublicId     string
+		Prepare      func(revoker *mocks.MockSessionRevoker)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			PublicId: "0123456789abcdef",
+			Prepare: func(revoker *mocks.MockSessionRevoker) {
+				revoker.EXPECT().RevokeUserSession(gomock.Any(), "johndoe", "0123456789abcdef").Return(nil).MinTimes(1)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{},
+		}, {
+			PublicId: "0123456789abcdef",
+			Prepare: func(revoker *mocks.MockSessionRevoker) {
+				revoker.EXPECT().RevokeUserSession(gomock.Any(), "johndoe", "0123456789abcdef").Return(services.ErrNoSession).MinTimes(1)
+			},
+			ExpectedCode: http.StatusNotFound,
+			ExpectedBody: gin.H{"error": "session does not exist"},
+		}, {
+			PublicId: "0123456789abcdef",
+			Prepare: func(revoker *mocks.MockSessionRevoker) {
+				revoker.EXPECT().RevokeUserSession(gomock.Any(), "johndoe", "0123456789abcdef").Return(errors.New("")).MinTimes(1)
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockedSessionRevoker := mocks.NewMockSessionRevoker(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(mockedSessionRevoker)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{Username: "johndoe"})
+				c.Set("session_id", "currentuuid")
+			})
+			r.DELETE("/api/sessions/:id", RevokeSession(mockedSessionRevoker))
+
+			request, err := http.NewRequest(http.MethodDelete, "/api/sessions/"+tt.PublicId, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func GetSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": settingsResult(user)})
+	}
+}
+
+// UpdateSettings also forgets when the user was last active once they hide their presence, or make their profile private.
+func UpdateSettings(updater services.SettingsUpdater, presence services.PresenceTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.SettingsForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		updated, err := updater.UpdateSettings(c.Copy(), user.Username, form)
+		if err == services.ErrInvalidMessagePolicy {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.Errorf("SettingsUpdater.UpdateSettings() raised an error while updating the settings of %v: %v", user.Username, err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if (form.Private != nil || form.HidePresence != nil) && updated.Profile.PresenceHidden() {
+			if err := presence.ForgetPresence(c.Copy(), user.UserID); err != nil {
+				logger.Errorf("PresenceTracker.ForgetPresence() raised an error while hiding the presence of %v: %v", user.Username, err.Error())
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": settingsResult(updated)})
+	}
+}
+
+func settingsResult(user models.User) gin.H {
+	return gin.H{
+		"who_can_message":    user.Settings.MessagesFrom(),
+		"send_read_receipts": !user.Settings.HideReadReceipts,
+		"private":            user.Profile.Private,
+		"hide_presence":      user.Profile.HidePresence,
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdateSettings(t *testing.T) {
+	contacts, everyone := models.MessagesFromContacts, "anyone"
+	sendReadReceipts, private, hidePresence := false, true, false
+	userID := primitive.NewObjectID()
+
+	tests := []struct {
+		Body         multipart.Form
+		Prepare      func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Body: multipart.Form{Value: map[string][]string{"who_can_message": {"contacts"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), "aliparlakci", models.SettingsForm{WhoCanMessage: &contacts}).
+					Return(models.User{Settings: models.Settings{WhoCanMessage: models.MessagesFromContacts}}, nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": gin.H{"who_can_message": "contacts", "send_read_receipts": true, "private": false, "hide_presence": false}},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"send_read_receipts": {"false"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), "aliparlakci", models.SettingsForm{SendReadReceipts: &sendReadReceipts}).
+					Return(models.User{Settings: models.Settings{HideReadReceipts: true}}, nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": gin.H{"who_can_message": "everyone", "send_read_receipts": false, "private": false, "hide_presence": false}},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"private": {"true"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), "aliparlakci", models.SettingsForm{Private: &private}).
+					Return(models.User{Profile: models.Profile{Private: true}}, nil)
+				presence.EXPECT().ForgetPresence(gomock.Any(), userID).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": gin.H{"who_can_message": "everyone", "send_read_receipts": true, "private": true, "hide_presence": false}},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"hide_presence": {"false"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), "aliparlakci", models.SettingsForm{HidePresence: &hidePresence}).
+					Return(models.User{}, nil)
+				presence.EXPECT().ForgetPresence(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": gin.H{"who_can_message": "everyone", "send_read_receipts": true, "private": false, "hide_presence": false}},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"who_can_message": {"anyone"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), "aliparlakci", models.SettingsForm{WhoCanMessage: &everyone}).
+					Return(models.User{}, services.ErrInvalidMessagePolicy)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrInvalidMessagePolicy.Error()},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"send_read_receipts": {"maybe"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "invalid request"},
+		}, {
+			Body: multipart.Form{Value: map[string][]string{"who_can_message": {"contacts"}}},
+			Prepare: func(updater *mocks.MockSettingsUpdater, presence *mocks.MockPresenceTracker) {
+				updater.EXPECT().UpdateSettings(gomock.Any(), gomock.Any(), gomock.Any()).Return(models.User{}, errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockedUpdater := mocks.NewMockSettingsUpdater(ctrl)
+			mockedPresenceTracker := mocks.NewMockPresenceTracker(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(mockedUpdater, mockedPresenceTracker)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{UserID: userID, Username: "aliparlakci"})
+			})
+			r.PUT("/api/me/settings", UpdateSettings(mockedUpdater, mockedPresenceTracker))
+
+			request, err := http.NewRequest(http.MethodPut, "/api/me/settings", nil)
+			request.MultipartForm = &tt.Body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// IssueToken is the token endpoint for API and mobile clients, which cannot use the session cookie.
+func IssueToken(authenticator services.Authenticator, userGetter services.UserGetter, twoFactor services.TwoFactorVerifier, throttler services.SigninThrottler, issuer services.TokenIssuer, tokenRevoker services.TokenRevoker, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var form models.TokenForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		switch form.GrantType {
+		case "password":
+			passwordGrant(c, form, authenticator, userGetter, twoFactor, throttler, issuer, activityLogger)
+		case "refresh_token":
+			refreshTokenGrant(c, form, userGetter, issuer, tokenRevoker, activityLogger)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported grant type"})
+		}
+	}
+}
+
+func passwordGrant(c *gin.Context, form models.TokenForm, authenticator services.Authenticator, userGetter services.UserGetter, twoFactor services.TwoFactorVerifier, throttler services.SigninThrottler, issuer services.TokenIssuer, activityLogger services.ActivityLogger) {
+	logger := common.LoggerWithRequestId(c.Copy())
+
+	if form.Username == "" || form.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	form.Username = services.CanonicalUsername(form.Username)
+
+	// the failures are reset only after the second factor, so that the codes cannot be guessed with the password
+	if !checkPassword(c, logger, authenticator, throttler, activityLogger, form.Username, form.Password, false) {
+		return
+	}
+
+	user, err := userGetter.GetUser(c.Copy(), form.Username)
+	if err != nil {
+		logger.WithField("username", form.Username).Errorf("UserGetter.GetUser() raised an error while finding user with username: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+	if err := services.CheckAccountStatus(user); err != nil {
+		common.RejectInactiveAccount(c, err.(services.AccountStatusError))
+		return
+	}
+
+	if user.TwoFactorEnabled() {
+		if form.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is required"})
+			return
+		}
+
+		valid, err := twoFactor.VerifyTwoFactor(c.Copy(), form.Username, form.Code)
+		if err != nil {
+			logger.Errorf("TwoFactorVerifier.VerifyTwoFactor() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		if !valid {
+			if err := activityLogger.LogUnsuccesfulTwoFactor(c.Copy(), form.Username, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogUnsuccesfulTwoFactor() raised an error: %v", err.Error())
+			}
+			if !recordFailure(c, logger, throttler, activityLogger, form.Username) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "code is invalid"})
+			}
+			return
+		}
+
+		if err := activityLogger.LogTwoFactor(c.Copy(), form.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogTwoFactor() raised an error: %v", err.Error())
+		}
+	}
+
+	if err := throttler.ResetFailures(c.Copy(), form.Username); err != nil {
+		logger.Errorf("SigninThrottler.ResetFailures() raised an error: %v", err.Error())
+	}
+
+	tokens, err := issuer.IssueTokens(c.Copy(), form.Username)
+	if err != nil {
+		logger.Errorf("TokenIssuer.IssueTokens() raised an error: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+
+	if err := activityLogger.LogSignin(c.Copy(), form.Username, c.ClientIP()); err != nil {
+		logger.Errorf("ActivityLogger.LogSignin() raised an error: %v", err.Error())
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// refreshTokenGrant revokes the tokens of the users who are no longer active, like AuthMiddleware revokes their sessions.
+func refreshTokenGrant(c *gin.Context, form models.TokenForm, userGetter services.UserGetter, issuer services.TokenIssuer, tokenRevoker services.TokenRevoker, activityLogger services.ActivityLogger) {
+	logger := common.LoggerWithRequestId(c.Copy())
+
+	if form.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	tokens, userID, err := issuer.RefreshTokens(c.Copy(), form.RefreshToken)
+	if err == services.ErrRefreshTokenReused {
+		logger.WithField("user_id", userID.Hex()).Warn("a refresh token of user with id is reused, its token family is revoked")
+		if user, err := userGetter.GetUserByID(c.Copy(), userID); err != nil && err != services.ErrNoUser {
+			logger.WithField("user_id", userID.Hex()).Errorf("UserGetter.GetUserByID() raised an error while finding user with id: %v", err.Error())
+		} else if err == nil {
+			if err := activityLogger.LogRefreshTokenReuse(c.Copy(), user.Username, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogRefreshTokenReuse() raised an error: %v", err.Error())
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is invalid or expired"})
+		return
+	} else if err == services.ErrInvalidRefreshToken {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is invalid or expired"})
+		return
+	} else if err != nil {
+		logger.Errorf("TokenIssuer.RefreshTokens() raised an error: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+
+	user, err := userGetter.GetUserByID(c.Copy(), userID)
+	if err == services.ErrNoUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is invalid or expired"})
+		return
+	} else if err != nil {
+		logger.WithField("user_id", userID.Hex()).Errorf("UserGetter.GetUserByID() raised an error while finding user with id: %v", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+	if err := services.CheckAccountStatus(user); err != nil {
+		logger.WithField("username", user.Username).Debugf("user with username cannot refresh tokens: %v", err.Error())
+		if err := tokenRevoker.RevokeUserTokens(c.Copy(), user.Username); err != nil {
+			logger.WithField("username", user.Username).Errorf("TokenRevoker.RevokeUserTokens() raised an error while revoking tokens of user with username: %v", err.Error())
+		}
+		common.RejectInactiveAccount(c, err.(services.AccountStatusError))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type passwordGrantMocks struct {
+	authenticator  *mocks.MockAuthenticator
+	userGetter     *mocks.MockUserGetter
+	twoFactor      *mocks.MockTwoFactorVerifier
+	throttler      *mocks.MockSigninThrottler
+	issuer         *mocks.MockTokenIssuer
+	activityLogger *mocks.MockActivityLogger
+}
+
+func TestPasswordGrant(t *testing.T) {
+	body := multipart.Form{
+		Value: map[string][]string{
+			"grant_type": {"password"},
+			"username":   {"johndoe"},
+			"password":   {"hunter2"},
+			"code":       {"123456"},
+		},
+	}
+	tokens := models.TokenPair{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}
+	user := models.User{Username: "johndoe", TOTPSecret: "secret"}
+
+	tests := []struct {
+		Prepare      func(m passwordGrantMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Prepare: func(m passwordGrantMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.userGetter.EXPECT().GetUser(gomock.Any(), "johndoe").Return(user, nil)
+				m.twoFactor.EXPECT().VerifyTwoFactor(gomock.Any(), "johndoe", "123456").Return(true, nil)
+				m.activityLogger.EXPECT().LogTwoFactor(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.issuer.EXPECT().IssueTokens(gomock.Any(), "johndoe").Return(tokens, nil)
+				m.activityLogger.EXPECT().LogSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"access_token": "access", "token_type": "Bearer", "expires_in": 900, "refresh_token": "refresh"},
+		}, {
+			Prepare: func(m passwordGrantMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.userGetter.EXPECT().GetUser(gomock.Any(), "johndoe").Return(user, nil)
+				m.twoFactor.EXPECT().VerifyTwoFactor(gomock.Any(), "johndoe", "123456").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulTwoFactor(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Times(0)
+				m.issuer.EXPECT().IssueTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "code is invalid"},
+		}, {
+			Prepare: func(m passwordGrantMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.userGetter.EXPECT().GetUser(gomock.Any(), "johndoe").Return(user, nil)
+				m.twoFactor.EXPECT().VerifyTwoFactor(gomock.Any(), "johndoe", "123456").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulTwoFactor(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Minute, nil)
+				m.activityLogger.EXPECT().LogLockout(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.issuer.EXPECT().IssueTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusTooManyRequests,
+			ExpectedBody: gin.H{"error": "too many failed signin attempts"},
+		}, {
+			Prepare: func(m passwordGrantMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Minute, nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.twoFactor.EXPECT().VerifyTwoFactor(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusTooManyRequests,
+			ExpectedBody: gin.H{"error": "too many failed signin attempts"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := passwordGrantMocks{
+				authenticator:  mocks.NewMockAuthenticator(ctrl),
+				userGetter:     mocks.NewMockUserGetter(ctrl),
+				twoFactor:      mocks.NewMockTwoFactorVerifier(ctrl),
+				throttler:      mocks.NewMockSigninThrottler(ctrl),
+				issuer:         mocks.NewMockTokenIssuer(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/token", IssueToken(m.authenticator, m.userGetter, m.twoFactor, m.throttler, m.issuer, nil, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/token", nil)
+			request.MultipartForm = &body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+type refreshTokenMocks struct {
+	userGetter     *mocks.MockUserGetter
+	issuer         *mocks.MockTokenIssuer
+	tokenRevoker   *mocks.MockTokenRevoker
+	activityLogger *mocks.MockActivityLogger
+}
+
+func TestRefreshTokenGrant(t *testing.T) {
+	body := multipart.Form{
+		Value: map[string][]string{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {"sometoken"},
+		},
+	}
+	tokens := models.TokenPair{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}
+	userID := primitive.NewObjectID()
+
+	tests := []struct {
+		Prepare      func(m refreshTokenMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Prepare: func(m refreshTokenMocks) {
+				m.issuer.EXPECT().RefreshTokens(gomock.Any(), "sometoken").Return(tokens, userID, nil)
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{Username: "johndoe"}, nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"access_token": "access", "token_type": "Bearer", "expires_in": 900, "refresh_token": "refresh"},
+		}, {
+			Prepare: func(m refreshTokenMocks) {
+				m.issuer.EXPECT().RefreshTokens(gomock.Any(), "sometoken").Return(tokens, userID, nil)
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{Username: "johndoe", Status: models.StatusSuspended, StatusReason: "spam"}, nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": services.AccountStatusError{Status: models.StatusSuspended}.Error(), "reason": "spam"},
+		}, {
+			Prepare: func(m refreshTokenMocks) {
+				m.issuer.EXPECT().RefreshTokens(gomock.Any(), "sometoken").Return(tokens, userID, nil)
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{}, services.ErrNoUser)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "refresh token is invalid or expired"},
+		}, {
+			Prepare: func(m refreshTokenMocks) {
+				m.issuer.EXPECT().RefreshTokens(gomock.Any(), "sometoken").Return(models.TokenPair{}, primitive.NilObjectID, services.ErrInvalidRefreshToken)
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "refresh token is invalid or expired"},
+		}, {
+			Prepare: func(m refreshTokenMocks) {
+				m.issuer.EXPECT().RefreshTokens(gomock.Any(), "sometoken").Return(models.TokenPair{}, userID, services.ErrRefreshTokenReused)
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{UserID: userID, Username: "johndoe"}, nil)
+				m.activityLogger.EXPECT().LogRefreshTokenReuse(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "refresh token is invalid or expired"},
+		}, {
+			Prepare: func(m refreshTokenMocks) {
+				m.issuer.EXPECT().RefreshTokens(gomock.Any(), "sometoken").Return(tokens, userID, nil)
+				m.userGetter.EXPECT().GetUserByID(gomock.Any(), userID).Return(models.User{}, errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := refreshTokenMocks{
+				userGetter:     mocks.NewMockUserGetter(ctrl),
+				issuer:         mocks.NewMockTokenIssuer(ctrl),
+				tokenRevoker:   mocks.NewMockTokenRevoker(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/token", IssueToken(nil, m.userGetter, nil, nil, m.issuer, m.tokenRevoker, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/token", nil)
+			request.MultipartForm = &body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func BeginTwoFactorEnrollment(enroller services.TwoFactorEnroller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		enrollment, err := enroller.BeginEnrollment(c.Copy(), user.Username)
+		if err == services.ErrTwoFactorEnabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		} else if err != nil {
+			logger.Errorf("TwoFactorEnroller.BeginEnrollment() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": enrollment})
+	}
+}
+
+func ConfirmTwoFactorEnrollment(enroller services.TwoFactorEnroller, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.TwoFactorForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		recoveryCodes, err := enroller.ConfirmEnrollment(c.Copy(), user.Username, form.Code)
+		switch err {
+		case nil:
+		case services.ErrTwoFactorEnabled:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		case services.ErrNoEnrollment:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication enrollment is not started"})
+			return
+		case services.ErrInvalidTwoFactorCode:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is invalid"})
+			return
+		default:
+			logger.Errorf("TwoFactorEnroller.ConfirmEnrollment() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := activityLogger.LogTwoFactorEnable(c.Copy(), user.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogTwoFactorEnable() raised an error: %v", err.Error())
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": gin.H{"recovery_codes": recoveryCodes}})
+	}
+}
+
+func DisableTwoFactor(enroller services.TwoFactorEnroller, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.TwoFactorForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		if err := enroller.DisableTwoFactor(c.Copy(), user.Username, form.Code); err == services.ErrTwoFactorDisabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+			return
+		} else if err == services.ErrInvalidTwoFactorCode {
+			if err := activityLogger.LogUnsuccesfulTwoFactor(c.Copy(), user.Username, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogUnsuccesfulTwoFactor() raised an error: %v", err.Error())
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is invalid"})
+			return
+		} else if err != nil {
+			logger.Errorf("TwoFactorEnroller.DisableTwoFactor() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := activityLogger.LogTwoFactorDisable(c.Copy(), user.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogTwoFactorDisable() raised an error: %v", err.Error())
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "two-factor authentication is disabled"})
+	}
+}
+
+// SigninTwoFactor completes the pending signin started by Signin with a TOTP or recovery code.
+func SigninTwoFactor(completer services.PendingSigninCompleter, sessions services.SessionCreator, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		if _, isLoggedIn := c.Get("user"); isLoggedIn {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user is already logged in"})
+			return
+		}
+
+		pendingId, err := c.Cookie("pending_signin")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no signin is pending"})
+			return
+		}
+
+		var form models.TwoFactorForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		username, err := completer.CompletePendingSignin(c.Copy(), pendingId, form.Code)
+		if err == services.ErrNoPendingSignin {
+			c.Header("Set-Cookie", "pending_signin=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no signin is pending"})
+			return
+		} else if err == services.ErrInvalidTwoFactorCode {
+			if err := activityLogger.LogUnsuccesfulTwoFactor(c.Copy(), username, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogUnsuccesfulTwoFactor() raised an error: %v", err.Error())
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is invalid"})
+			return
+		} else if err != nil {
+			logger.Errorf("PendingSigninCompleter.CompletePendingSignin() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := activityLogger.LogTwoFactor(c.Copy(), username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogTwoFactor() raised an error: %v", err.Error())
+		}
+
+		c.Header("Set-Cookie", "pending_signin=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;")
+		signin(c, logger, sessions, activityLogger, username)
+	}
+}
@@ -1,14 +1,17 @@
 package handlers
 
 import (
+	"fmt"
 	"github.com/aliparlakci/armut-backend-assessment/common"
 	"github.com/aliparlakci/armut-backend-assessment/models"
 	"github.com/aliparlakci/armut-backend-assessment/services"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"net/http"
 )
 
-func Signup(usersCreator services.UserCreator, hasher services.PasswordHasher) gin.HandlerFunc {
+// Signup redeems the invite code if one is sent, even when invites are not required, so that the invite is recorded.
+func Signup(usersCreator services.UserCreator, hasher services.PasswordHasher, verificationTokens services.VerificationTokenCreator, mailer services.Mailer, invites services.InviteRedeemer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := common.LoggerWithRequestId(c.Copy())
 
@@ -17,6 +20,20 @@ func Signup(usersCreator services.UserCreator, hasher services.PasswordHasher) g
 			c.JSON(http.StatusBadRequest, gin.H{})
 			return
 		}
+		if creds.Email == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+			return
+		}
+		if creds.InviteCode == "" && invites.InvitesRequired() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "an invite code is required to sign up"})
+			return
+		}
+
+		username, err := services.NormalizeUsername(creds.Username)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		hashedPassword, err := hasher.HashPassword(creds.Password)
 		if err != nil {
@@ -25,17 +42,263 @@ func Signup(usersCreator services.UserCreator, hasher services.PasswordHasher) g
 			return
 		}
 
-		_, err = usersCreator.CreateUser(c.Copy(), creds.Username, hashedPassword)
+		var invite models.Invite
+		if creds.InviteCode != "" {
+			invite, err = invites.RedeemInvite(c.Copy(), creds.InviteCode)
+			if err == services.ErrInvalidInvite {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			} else if err != nil {
+				logger.Errorf("InviteRedeemer.RedeemInvite() raised an error: %v", err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{})
+				return
+			}
+		}
+
+		email := services.NormalizeEmail(creds.Email)
+		_, err = usersCreator.CreateUser(c.Copy(), username, email, hashedPassword)
+		if err != nil && invite.Code != "" {
+			if err := invites.ReleaseInvite(c.Copy(), invite.Code); err != nil {
+				logger.Errorf("InviteRedeemer.ReleaseInvite() raised an error: %v", err.Error())
+			}
+		}
 		if err == services.ErrUserAlreadyExists {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "user already exists"})
 			return
 		}
+		if err == services.ErrEmailAlreadyExists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is already in use"})
+			return
+		}
 		if err != nil {
-			logger.WithField("username", creds.Username).Errorf("UserService.CreateUser() raised an error while creating user with username: %v", err.Error())
+			logger.WithField("username", username).Errorf("UserService.CreateUser() raised an error while creating user with username: %v", err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{})
 			return
 		}
 
+		if invite.Code != "" {
+			if err := invites.RecordInvitee(c.Copy(), username, invite); err != nil {
+				logger.WithField("username", username).Errorf("InviteRedeemer.RecordInvitee() raised an error: %v", err.Error())
+			}
+		}
+
+		// The user can ask for another verification email, so failing to send this one must not fail the signup
+		_ = sendVerificationEmail(c, logger, verificationTokens, mailer, username, email)
+
 		c.JSON(http.StatusCreated, gin.H{"result": "user created"})
 	}
-}
\ No newline at end of file
+}
+
+func ResendVerificationEmail(verificationTokens services.VerificationTokenCreator, mailer services.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		if !user.EmailUnverified() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is already verified"})
+			return
+		}
+
+		if err := sendVerificationEmail(c, logger, verificationTokens, mailer, user.Username, user.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "verification email is sent"})
+	}
+}
+
+func VerifyEmail(consumer services.VerificationTokenConsumer, updater services.UserUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var form models.EmailVerificationForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		username, email, err := consumer.ConsumeVerificationToken(c.Copy(), form.Token)
+		if err == services.ErrInvalidVerificationToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "verification token is invalid or expired"})
+			return
+		} else if err != nil {
+			logger.Errorf("VerificationTokenConsumer.ConsumeVerificationToken() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		// The user may have changed their email address since the token was issued
+		if err := updater.VerifyEmail(c.Copy(), username, email); err == services.ErrNoUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "verification token is invalid or expired"})
+			return
+		} else if err != nil {
+			logger.Errorf("UserUpdater.VerifyEmail() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "email is verified"})
+	}
+}
+
+func sendVerificationEmail(c *gin.Context, logger *logrus.Entry, verificationTokens services.VerificationTokenCreator, mailer services.Mailer, username, email string) error {
+	token, err := verificationTokens.CreateVerificationToken(c.Copy(), username, email)
+	if err != nil {
+		logger.Errorf("VerificationTokenCreator.CreateVerificationToken() raised an error: %v", err.Error())
+		return err
+	}
+
+	message := fmt.Sprintf("Hi %v, use the following token to verify your email address: %v", username, token)
+	if err := mailer.Mail(c.Copy(), email, "Verify your email address", message); err != nil {
+		logger.Errorf("Mailer.Mail() raised an error while sending the verification token: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ChangeUsername keeps the sessions, tokens and api keys of the user, which reference them by their id.
+// Usernames cannot be changed until every record has been migrated to reference users by their ids,
+// since the records which have not been would be lost.
+func ChangeUsername(migrations services.MigrationChecker, renamer services.UserRenamer, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, isLoggedIn := c.Get("user"); !isLoggedIn {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.UsernameForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		username, err := services.NormalizeUsername(form.Username)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		migrated, err := migrations.MigrationCompleted(c.Copy(), services.UserIDMigration)
+		if err != nil {
+			logger.Errorf("MigrationChecker.MigrationCompleted() raised an error: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		if !migrated {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usernames cannot be changed right now, try again later"})
+			return
+		}
+
+		err = renamer.ChangeUsername(c.Copy(), user.UserID, username)
+		if err == services.ErrUserAlreadyExists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "username is already taken"})
+			return
+		}
+		if err == services.ErrUsernameChanged {
+			c.JSON(http.StatusConflict, gin.H{"error": "username has been changed in the meantime"})
+			return
+		}
+		if err != nil {
+			logger.WithField("username", user.Username).Errorf("UserRenamer.ChangeUsername() raised an error while changing the username of user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if username != user.Username {
+			if err := activityLogger.LogUsernameChange(c.Copy(), username, c.ClientIP()); err != nil {
+				logger.Errorf("ActivityLogger.LogUsernameChange() raised an error: %v", err.Error())
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": gin.H{"username": username}})
+	}
+}
+
+// DeactivateAccount lets users deactivate their own accounts, which signs them out everywhere.
+// They can activate them again with ReactivateAccount.
+func DeactivateAccount(authenticator services.Authenticator, throttler services.SigninThrottler, reauthenticator services.Reauthenticator, deactivator services.AccountDeactivator, revoker services.SessionRevoker, tokenRevoker services.TokenRevoker, activityLogger services.ActivityLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var user models.User
+		if u, exists := c.Get("user"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{})
+			return
+		} else {
+			user = u.(models.User)
+		}
+
+		var form models.DeactivationForm
+		if err := c.Bind(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		if !confirmIdentity(c, logger, authenticator, throttler, activityLogger, reauthenticator, user, form.Password) {
+			return
+		}
+
+		if err := deactivator.Deactivate(c.Copy(), user.Username, form.Reason); err != nil {
+			logger.WithField("username", user.Username).Errorf("AccountDeactivator.Deactivate() raised an error while deactivating user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		signOutEverywhere(c, logger, revoker, tokenRevoker, user.Username)
+
+		if err := activityLogger.LogDeactivation(c.Copy(), user.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogDeactivation() raised an error: %v", err.Error())
+		}
+
+		common.ClearSessionCookie(c)
+		c.JSON(http.StatusOK, gin.H{"result": "account is deactivated"})
+	}
+}
+
+// ReactivateAccount activates the account of a user who has deactivated it themselves. Since the user cannot sign in
+// until then, it takes their credentials, which are locked out like signins are.
+func ReactivateAccount(authenticator services.Authenticator, throttler services.SigninThrottler, activityLogger services.ActivityLogger, deactivator services.AccountDeactivator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		var creds models.AuthForm
+		if err := c.Bind(&creds); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		creds.Username = services.CanonicalUsername(creds.Username)
+
+		if !verifyPassword(c, logger, authenticator, throttler, activityLogger, creds.Username, creds.Password, true) {
+			return
+		}
+
+		if err := deactivator.Reactivate(c.Copy(), creds.Username); err == services.ErrCannotReactivate {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.WithField("username", creds.Username).Errorf("AccountDeactivator.Reactivate() raised an error while reactivating user with username: %v", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		if err := activityLogger.LogReactivation(c.Copy(), creds.Username, c.ClientIP()); err != nil {
+			logger.Errorf("ActivityLogger.LogReactivation() raised an error: %v", err.Error())
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": "account is reactivated"})
+	}
+}
@@ -0,0 +1,492 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type signupMocks struct {
+	creator            *mocks.MockUserCreator
+	hasher             *mocks.MockPasswordHasher
+	verificationTokens *mocks.MockVerificationTokenCreator
+	mailer             *mocks.MockMailer
+	invites            *mocks.MockInviteRedeemer
+}
+
+func TestSignupWithInvites(t *testing.T) {
+	invite := models.Invite{Code: "k3yTq8xPz0aB", CreatedBy: primitive.NewObjectID(), MaxUses: 1, Uses: 1}
+
+	signedUp := func(m signupMocks) {
+		m.hasher.EXPECT().HashPassword("hunter22").Return("hashed", nil)
+		m.creator.EXPECT().CreateUser(gomock.Any(), "johndoe", "john@example.com", "hashed").Return("", nil)
+		m.verificationTokens.EXPECT().CreateVerificationToken(gomock.Any(), "johndoe", "john@example.com").Return("token", nil)
+		m.mailer.EXPECT().Mail(gomock.Any(), "john@example.com", gomock.Any(), gomock.Any()).Return(nil)
+	}
+
+	tests := []struct {
+		InviteCode   string
+		Prepare      func(m signupMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			InviteCode: "k3yTq8xPz0aB",
+			Prepare: func(m signupMocks) {
+				m.invites.EXPECT().RedeemInvite(gomock.Any(), "k3yTq8xPz0aB").Return(invite, nil)
+				signedUp(m)
+				m.invites.EXPECT().RecordInvitee(gomock.Any(), "johndoe", invite).Return(nil)
+			},
+			ExpectedCode: http.StatusCreated,
+			ExpectedBody: gin.H{"result": "user created"},
+		}, {
+			Prepare: func(m signupMocks) {
+				m.invites.EXPECT().InvitesRequired().Return(false)
+				signedUp(m)
+			},
+			ExpectedCode: http.StatusCreated,
+			ExpectedBody: gin.H{"result": "user created"},
+		}, {
+			Prepare: func(m signupMocks) {
+				m.invites.EXPECT().InvitesRequired().Return(true)
+				m.creator.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "an invite code is required to sign up"},
+		}, {
+			InviteCode: "expired",
+			Prepare: func(m signupMocks) {
+				m.hasher.EXPECT().HashPassword("hunter22").Return("hashed", nil)
+				m.invites.EXPECT().RedeemInvite(gomock.Any(), "expired").Return(models.Invite{}, services.ErrInvalidInvite)
+				m.creator.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrInvalidInvite.Error()},
+		}, {
+			InviteCode: "k3yTq8xPz0aB",
+			Prepare: func(m signupMocks) {
+				m.hasher.EXPECT().HashPassword("hunter22").Return("hashed", nil)
+				m.invites.EXPECT().RedeemInvite(gomock.Any(), "k3yTq8xPz0aB").Return(invite, nil)
+				m.creator.EXPECT().CreateUser(gomock.Any(), "johndoe", "john@example.com", "hashed").Return("", services.ErrUserAlreadyExists)
+				m.invites.EXPECT().ReleaseInvite(gomock.Any(), "k3yTq8xPz0aB").Return(nil)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "user already exists"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := signupMocks{
+				creator:            mocks.NewMockUserCreator(ctrl),
+				hasher:             mocks.NewMockPasswordHasher(ctrl),
+				verificationTokens: mocks.NewMockVerificationTokenCreator(ctrl),
+				mailer:             mocks.NewMockMailer(ctrl),
+				invites:            mocks.NewMockInviteRedeemer(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/signup", Signup(m.creator, m.hasher, m.verificationTokens, m.mailer, m.invites))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/signup", nil)
+			request.MultipartForm = &multipart.Form{Value: map[string][]string{
+				"username":    {"johndoe"},
+				"password":    {"hunter22"},
+				"email":       {"john@example.com"},
+				"invite_code": {tt.InviteCode},
+			}}
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+type changeUsernameMocks struct {
+	migrations     *mocks.MockMigrationChecker
+	renamer        *mocks.MockUserRenamer
+	activityLogger *mocks.MockActivityLogger
+}
+
+func TestChangeUsername(t *testing.T) {
+	userID := primitive.NewObjectID()
+
+	tests := []struct {
+		Username     string
+		Prepare      func(m changeUsernameMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Username: "Ali.Parlakci",
+			Prepare: func(m changeUsernameMocks) {
+				m.migrations.EXPECT().MigrationCompleted(gomock.Any(), services.UserIDMigration).Return(true, nil)
+				m.renamer.EXPECT().ChangeUsername(gomock.Any(), userID, "ali.parlakci").Return(nil)
+				m.activityLogger.EXPECT().LogUsernameChange(gomock.Any(), "ali.parlakci", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": gin.H{"username": "ali.parlakci"}},
+		}, {
+			Username: "aliparlakci",
+			Prepare: func(m changeUsernameMocks) {
+				m.migrations.EXPECT().MigrationCompleted(gomock.Any(), services.UserIDMigration).Return(true, nil)
+				m.renamer.EXPECT().ChangeUsername(gomock.Any(), userID, "aliparlakci").Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": gin.H{"username": "aliparlakci"}},
+		}, {
+			Username: "a",
+			Prepare: func(m changeUsernameMocks) {
+				m.renamer.EXPECT().ChangeUsername(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrInvalidUsername.Error()},
+		}, {
+			Username: "johndoe",
+			Prepare: func(m changeUsernameMocks) {
+				m.migrations.EXPECT().MigrationCompleted(gomock.Any(), services.UserIDMigration).Return(false, nil)
+				m.renamer.EXPECT().ChangeUsername(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusServiceUnavailable,
+			ExpectedBody: gin.H{"error": "usernames cannot be changed right now, try again later"},
+		}, {
+			Username: "johndoe",
+			Prepare: func(m changeUsernameMocks) {
+				m.migrations.EXPECT().MigrationCompleted(gomock.Any(), services.UserIDMigration).Return(true, nil)
+				m.renamer.EXPECT().ChangeUsername(gomock.Any(), userID, "johndoe").Return(services.ErrUserAlreadyExists)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "username is already taken"},
+		}, {
+			Username: "johndoe",
+			Prepare: func(m changeUsernameMocks) {
+				m.migrations.EXPECT().MigrationCompleted(gomock.Any(), services.UserIDMigration).Return(true, nil)
+				m.renamer.EXPECT().ChangeUsername(gomock.Any(), userID, "johndoe").Return(services.ErrUsernameChanged)
+			},
+			ExpectedCode: http.StatusConflict,
+			ExpectedBody: gin.H{"error": "username has been changed in the meantime"},
+		}, {
+			Username: "johndoe",
+			Prepare: func(m changeUsernameMocks) {
+				m.migrations.EXPECT().MigrationCompleted(gomock.Any(), services.UserIDMigration).Return(false, errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := changeUsernameMocks{
+				migrations:     mocks.NewMockMigrationChecker(ctrl),
+				renamer:        mocks.NewMockUserRenamer(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", models.User{UserID: userID, Username: "aliparlakci"})
+			})
+			r.PUT("/api/me/username", ChangeUsername(m.migrations, m.renamer, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPut, "/api/me/username", nil)
+			request.MultipartForm = &multipart.Form{Value: map[string][]string{"username": {tt.Username}}}
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+type deactivateAccountMocks struct {
+	authenticator   *mocks.MockAuthenticator
+	throttler       *mocks.MockSigninThrottler
+	reauthenticator *mocks.MockReauthenticator
+	deactivator     *mocks.MockAccountDeactivator
+	revoker         *mocks.MockSessionRevoker
+	tokenRevoker    *mocks.MockTokenRevoker
+	activityLogger  *mocks.MockActivityLogger
+}
+
+func TestDeactivateAccount(t *testing.T) {
+	withPassword := models.User{UserID: primitive.NewObjectID(), Username: "johndoe", Password: "hashed"}
+	withoutPassword := models.User{UserID: primitive.NewObjectID(), Username: "johndoe"}
+
+	tests := []struct {
+		User         models.User
+		Body         multipart.Form
+		Prepare      func(m deactivateAccountMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			User: withPassword,
+			Body: multipart.Form{Value: map[string][]string{"password": {"hunter2"}}},
+			Prepare: func(m deactivateAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(true, nil)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deactivator.EXPECT().Deactivate(gomock.Any(), "johndoe", "").Return(nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+				m.activityLogger.EXPECT().LogDeactivation(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "account is deactivated"},
+		}, {
+			User: withPassword,
+			Body: multipart.Form{Value: map[string][]string{"password": {"hunter2"}}},
+			Prepare: func(m deactivateAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Minute, nil)
+				m.activityLogger.EXPECT().LogLockout(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.deactivator.EXPECT().Deactivate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusTooManyRequests,
+			ExpectedBody: gin.H{"error": "too many failed signin attempts"},
+		}, {
+			User: withPassword,
+			Body: multipart.Form{Value: map[string][]string{}},
+			Prepare: func(m deactivateAccountMocks) {
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.reauthenticator.EXPECT().ConsumeReauthentication(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "invalid request"},
+		}, {
+			User: withoutPassword,
+			Body: multipart.Form{Value: map[string][]string{}},
+			Prepare: func(m deactivateAccountMocks) {
+				m.reauthenticator.EXPECT().ConsumeReauthentication(gomock.Any(), withoutPassword.UserID.Hex()).Return(true, nil)
+				m.deactivator.EXPECT().Deactivate(gomock.Any(), "johndoe", "").Return(nil)
+				m.revoker.EXPECT().RevokeOtherSessions(gomock.Any(), "johndoe", "").Return(nil)
+				m.tokenRevoker.EXPECT().RevokeUserTokens(gomock.Any(), "johndoe").Return(nil)
+				m.activityLogger.EXPECT().LogDeactivation(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "account is deactivated"},
+		}, {
+			User: withoutPassword,
+			Body: multipart.Form{Value: map[string][]string{"password": {"hunter2"}}},
+			Prepare: func(m deactivateAccountMocks) {
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				m.reauthenticator.EXPECT().ConsumeReauthentication(gomock.Any(), withoutPassword.UserID.Hex()).Return(false, nil)
+				m.deactivator.EXPECT().Deactivate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "identity must be confirmed at the identity provider first"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := deactivateAccountMocks{
+				authenticator:   mocks.NewMockAuthenticator(ctrl),
+				throttler:       mocks.NewMockSigninThrottler(ctrl),
+				reauthenticator: mocks.NewMockReauthenticator(ctrl),
+				deactivator:     mocks.NewMockAccountDeactivator(ctrl),
+				revoker:         mocks.NewMockSessionRevoker(ctrl),
+				tokenRevoker:    mocks.NewMockTokenRevoker(ctrl),
+				activityLogger:  mocks.NewMockActivityLogger(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				c.Set("user", tt.User)
+			})
+			r.POST("/api/me/deactivate", DeactivateAccount(m.authenticator, m.throttler, m.reauthenticator, m.deactivator, m.revoker, m.tokenRevoker, m.activityLogger))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/me/deactivate", nil)
+			request.MultipartForm = &tt.Body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
+
+type reactivateAccountMocks struct {
+	authenticator  *mocks.MockAuthenticator
+	throttler      *mocks.MockSigninThrottler
+	activityLogger *mocks.MockActivityLogger
+	deactivator    *mocks.MockAccountDeactivator
+}
+
+func TestReactivateAccount(t *testing.T) {
+	body := multipart.Form{
+		Value: map[string][]string{
+			"username": {"JohnDoe"},
+			"password": {"hunter2"},
+		},
+	}
+	deactivated := services.AccountStatusError{Status: models.StatusDeactivated}
+	suspended := services.AccountStatusError{Status: models.StatusSuspended, Reason: "spam"}
+
+	tests := []struct {
+		Prepare      func(m reactivateAccountMocks)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Prepare: func(m reactivateAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, deactivated)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deactivator.EXPECT().Reactivate(gomock.Any(), "johndoe").Return(nil)
+				m.activityLogger.EXPECT().LogReactivation(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "account is reactivated"},
+		}, {
+			Prepare: func(m reactivateAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, suspended)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deactivator.EXPECT().Reactivate(gomock.Any(), "johndoe").Return(services.ErrCannotReactivate)
+				m.activityLogger.EXPECT().LogReactivation(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": services.ErrCannotReactivate.Error()},
+		}, {
+			Prepare: func(m reactivateAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, nil)
+				m.activityLogger.EXPECT().LogUnsuccesfulSignin(gomock.Any(), "johndoe", gomock.Any()).Return(nil)
+				m.throttler.EXPECT().RecordFailure(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.deactivator.EXPECT().Reactivate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: gin.H{"error": "username and password mismatch"},
+		}, {
+			Prepare: func(m reactivateAccountMocks) {
+				m.throttler.EXPECT().CheckLockout(gomock.Any(), "johndoe", gomock.Any()).Return(time.Duration(0), nil)
+				m.authenticator.EXPECT().Authenticate(gomock.Any(), "johndoe", "hunter2").Return(false, deactivated)
+				m.throttler.EXPECT().ResetFailures(gomock.Any(), "johndoe").Return(nil)
+				m.deactivator.EXPECT().Reactivate(gomock.Any(), "johndoe").Return(errors.New(""))
+			},
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedBody: gin.H{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := reactivateAccountMocks{
+				authenticator:  mocks.NewMockAuthenticator(ctrl),
+				throttler:      mocks.NewMockSigninThrottler(ctrl),
+				activityLogger: mocks.NewMockActivityLogger(ctrl),
+				deactivator:    mocks.NewMockAccountDeactivator(ctrl),
+			}
+
+			if tt.Prepare != nil {
+				tt.Prepare(m)
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/reactivate", ReactivateAccount(m.authenticator, m.throttler, m.activityLogger, m.deactivator))
+
+			request, err := http.NewRequest(http.MethodPost, "/api/reactivate", nil)
+			request.MultipartForm = &body
+			request.Header.Set("Content-Type", "multipart/form-data")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"github.com/aliparlakci/armut-backend-assessment/common"
 	"github.com/aliparlakci/armut-backend-assessment/handlers"
 	"github.com/aliparlakci/armut-backend-assessment/middlewares"
@@ -9,7 +10,14 @@ import (
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net"
+	"net/http"
+	"net/smtp"
 	"os"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -27,49 +35,367 @@ func main() {
 	)
 	defer close()
 
+	exportArchives, err := gridfs.NewBucket(mdb, options.GridFSBucket().SetName("export_archives"))
+	if err != nil {
+		logrus.Fatalf("cannot open the export archives bucket: %v", err.Error())
+	}
+
 	rdbUri := os.Getenv("RDB_URI")
 	redis := common.RedisInitializer(rdbUri, "")
 
 	env := &common.Env{}
 	{
-		env.ActivityService = &services.ActivityService{Collection: mdb.Collection("activity")}
-		env.AuthService = &services.AuthService{Collection: mdb.Collection("users")}
 		env.UserService = &services.UserService{Collection: mdb.Collection("users")}
-		env.SessionService = &services.SessionService{Store: redis(0)}
+		env.APIKeyService = &services.APIKeyService{Collection: mdb.Collection("api_keys"), Users: env.UserService}
+		env.AuditService = &services.AuditService{Collection: mdb.Collection("audit")}
+		env.AuthService = &services.AuthService{
+			Collection: mdb.Collection("users"),
+			Hashing: services.HashingPolicy{
+				Algorithm:     os.Getenv("PASSWORD_HASH_ALGORITHM"),
+				BcryptCost:    common.IntFromEnv("BCRYPT_COST", 12),
+				Argon2Time:    common.IntFromEnv("ARGON2_TIME", 3),
+				Argon2Memory:  common.IntFromEnv("ARGON2_MEMORY", 64*1024),
+				Argon2Threads: common.IntFromEnv("ARGON2_THREADS", 2),
+			},
+		}
+		if env.AuthService.Hashing.Algorithm == "" {
+			env.AuthService.Hashing.Algorithm = services.Argon2id
+		}
+		if err := env.AuthService.Hashing.Validate(); err != nil {
+			logrus.Fatalf("password hashing policy is invalid: %v", err.Error())
+		}
+		env.SessionService = &services.SessionService{
+			Store:           redis(0),
+			Users:           env.UserService,
+			IdleTimeout:     common.DurationFromEnv("SESSION_IDLE_TIMEOUT", 7*24*time.Hour),
+			AbsoluteTimeout: common.DurationFromEnv("SESSION_ABSOLUTE_TIMEOUT", 90*24*time.Hour),
+
+			ImpersonationTimeout: common.DurationFromEnv("IMPERSONATION_TIMEOUT", time.Hour),
+		}
+		env.ActivityService = &services.ActivityService{
+			Collection:     mdb.Collection("activity"),
+			Users:          env.UserService,
+			Impersonations: env.SessionService,
+		}
+		env.PasswordResetService = &services.PasswordResetService{
+			Store:    redis(2),
+			TokenTTL: common.DurationFromEnv("PASSWORD_RESET_TOKEN_TTL", time.Hour),
+		}
+		env.EmailVerificationService = &services.EmailVerificationService{
+			Store:    redis(2),
+			TokenTTL: common.DurationFromEnv("EMAIL_VERIFICATION_TOKEN_TTL", 48*time.Hour),
+		}
+		env.LockoutService = &services.LockoutService{
+			Store:         redis(2),
+			Window:        common.DurationFromEnv("SIGNIN_FAILURE_WINDOW", 15*time.Minute),
+			UserThreshold: common.IntFromEnv("SIGNIN_FAILURE_USER_THRESHOLD", 5),
+			IPThreshold:   common.IntFromEnv("SIGNIN_FAILURE_IP_THRESHOLD", 20),
+			BaseLockout:   common.DurationFromEnv("SIGNIN_LOCKOUT", time.Minute),
+			MaxLockout:    common.DurationFromEnv("SIGNIN_MAX_LOCKOUT", time.Hour),
+		}
+		env.TokenService = &services.TokenService{
+			Store:      redis(2),
+			Users:      env.UserService,
+			SigningKey: []byte(os.Getenv("TOKEN_SIGNING_KEY")),
+			AccessTTL:  common.DurationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTTL: common.DurationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		}
+		if len(env.TokenService.SigningKey) == 0 {
+			logrus.Warn("TOKEN_SIGNING_KEY is not set, access tokens will not survive a restart")
+			env.TokenService.SigningKey = []byte(uuid.New().String() + uuid.New().String())
+		}
+		env.ProofOfWorkService = &services.ProofOfWorkService{
+			Store:          redis(2),
+			Failures:       env.ActivityService,
+			Signups:        env.UserService,
+			SigningKey:     env.TokenService.SigningKey,
+			Required:       common.BoolFromEnv("POW_REQUIRED", false),
+			BaseDifficulty: common.IntFromEnv("POW_BASE_DIFFICULTY", 16),
+			MaxDifficulty:  common.IntFromEnv("POW_MAX_DIFFICULTY", 24),
+			FailuresPerBit: common.IntFromEnv("POW_FAILURES_PER_BIT", 10),
+			SignupsPerBit:  common.IntFromEnv("POW_SIGNUPS_PER_BIT", 20),
+			FailureWindow:  common.DurationFromEnv("POW_FAILURE_WINDOW", 5*time.Minute),
+			ChallengeTTL:   common.DurationFromEnv("POW_CHALLENGE_TTL", 2*time.Minute),
+		}
+		env.TwoFactorService = &services.TwoFactorService{
+			Collection:  mdb.Collection("users"),
+			Store:       redis(2),
+			Issuer:      "Armut",
+			PendingTTL:  common.DurationFromEnv("PENDING_SIGNIN_TTL", 5*time.Minute),
+			MaxAttempts: 5,
+		}
+		env.ProfileService = &services.ProfileService{
+			Users:   mdb.Collection("users"),
+			Avatars: mdb.Collection("avatars"),
+		}
+		env.PresenceService = &services.PresenceService{
+			Store:        redis(3),
+			Users:        env.UserService,
+			OnlineWindow: common.DurationFromEnv("PRESENCE_ONLINE_WINDOW", time.Minute),
+			AwayWindow:   common.DurationFromEnv("PRESENCE_AWAY_WINDOW", 10*time.Minute),
+			LastSeenTTL:  common.DurationFromEnv("PRESENCE_LAST_SEEN_TTL", 30*24*time.Hour),
+		}
+		env.UnreadCounterService = &services.UnreadCounterService{Store: redis(1)}
 		env.MessagingService = &services.MessagingService{
 			Collection:  mdb.Collection("messages"),
 			UserService: env.UserService,
+			Counters:    env.UnreadCounterService,
+			Profiles:    env.ProfileService,
+		}
+		env.InviteService = &services.InviteService{
+			Invites:       mdb.Collection("invites"),
+			Users:         mdb.Collection("users"),
+			Required:      common.BoolFromEnv("INVITE_ONLY", false),
+			DefaultQuota:  common.IntFromEnv("USER_INVITE_QUOTA", 0),
+			UserInviteTTL: common.DurationFromEnv("USER_INVITE_TTL", 7*24*time.Hour),
+		}
+		env.AccountDeletionService = &services.AccountDeletionService{
+			Users:         mdb.Collection("users"),
+			Messages:      mdb.Collection("messages"),
+			Activity:      mdb.Collection("activity"),
+			APIKeys:       mdb.Collection("api_keys"),
+			Avatars:       mdb.Collection("avatars"),
+			Exports:       mdb.Collection("exports"),
+			Archives:      exportArchives,
+			Sessions:      env.SessionService,
+			GracePeriod:   common.DurationFromEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			MessagePolicy: os.Getenv("DELETED_USER_MESSAGES"),
+		}
+		if env.AccountDeletionService.MessagePolicy != services.DeleteMessages {
+			env.AccountDeletionService.MessagePolicy = services.AnonymizeMessages
+		}
+		env.MigrationService = &services.MigrationService{
+			Migrations: mdb.Collection("migrations"),
+			Users:      env.UserService,
+			APIKeys:    mdb.Collection("api_keys"),
+			Avatars:    mdb.Collection("avatars"),
+			Messages:   env.MessagingService,
+			Activity:   mdb.Collection("activity"),
+			Sessions:   env.SessionService,
 		}
 	}
 
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		env.OIDCService = &services.OIDCService{
+			Store:        redis(2),
+			Client:       &http.Client{Timeout: 10 * time.Second},
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			StateTTL:     common.DurationFromEnv("OIDC_STATE_TTL", 10*time.Minute),
+			ReauthTTL:    common.DurationFromEnv("OIDC_REAUTH_TTL", 5*time.Minute),
+		}
+	}
+
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		mailer := services.SMTPMailer{Addr: addr, From: os.Getenv("SMTP_FROM")}
+		if username := os.Getenv("SMTP_USERNAME"); username != "" {
+			host, _, _ := net.SplitHostPort(addr)
+			mailer.Auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		env.Mailer = mailer
+	} else {
+		env.Mailer = services.LogMailer{}
+	}
+
+	if path := os.Getenv("NOTIFIER_FILE"); path != "" {
+		env.Notifier = &services.FileNotifier{Path: path}
+	} else {
+		env.Notifier = services.LogNotifier{}
+	}
+
+	env.ExportService = &services.ExportService{
+		Exports:  mdb.Collection("exports"),
+		Archives: exportArchives,
+		Users:    env.UserService,
+		Messages: env.MessagingService,
+		Activity: env.ActivityService,
+		Sessions: env.SessionService,
+		Notifier: env.Notifier,
+		LinkTTL:  common.DurationFromEnv("EXPORT_LINK_TTL", 7*24*time.Hour),
+	}
+
+	if err := env.APIKeyService.EnsureIndexes(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.MigrationService.CanonicalizeUsernames(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.UserService.EnsureIndexes(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.ProfileService.EnsureIndexes(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.InviteService.EnsureIndexes(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.ExportService.EnsureIndexes(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.MigrationService.PrepareUserIDs(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := env.MigrationService.MigrateSuspensions(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+	go func() {
+		if err := env.MigrationService.MigrateUserIDs(context.Background()); err != nil {
+			logrus.Errorf("cannot migrate the records to reference users by their ids: %v", err.Error())
+		}
+	}()
+	go func() {
+		if err := env.ProfileService.IndexSearchGrams(context.Background()); err != nil {
+			logrus.Errorf("cannot index the users for the user directory: %v", err.Error())
+		}
+	}()
+
+	for _, username := range strings.Fields(strings.ReplaceAll(os.Getenv("ADMIN_USERNAMES"), ",", " ")) {
+		if err := env.UserService.GrantRole(context.Background(), services.CanonicalUsername(username), services.RoleAdmin); err != nil {
+			logrus.WithField("username", username).Warnf("cannot make the user an admin: %v", err.Error())
+		}
+	}
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	common.RunPeriodically(jobs, "reconcile_unread_counters",
+		common.DurationFromEnv("UNREAD_RECONCILE_INTERVAL", 10*time.Minute),
+		env.MessagingService.ReconcileUnreadCounters)
+	common.RunPeriodically(jobs, "delete_scheduled_accounts",
+		common.DurationFromEnv("ACCOUNT_DELETION_INTERVAL", time.Hour),
+		env.AccountDeletionService.DeleteScheduledAccounts)
+	common.RunPeriodically(jobs, "log_expired_impersonations",
+		common.DurationFromEnv("IMPERSONATION_EXPIRY_INTERVAL", time.Minute),
+		env.ActivityService.LogExpiredImpersonations)
+	common.RunPeriodically(jobs, "remove_expired_export_archives",
+		common.DurationFromEnv("EXPORT_CLEANUP_INTERVAL", time.Hour),
+		env.ExportService.RemoveExpiredArchives)
+
 	router := gin.Default()
 	router.Use(func(c *gin.Context) {
 		c.Set("request_id", uuid.New().String())
 		c.Next()
 	})
 	router.Use(middlewares.Logger())
-	router.Use(middlewares.AuthMiddleware(env.UserService, env.SessionService))
+	router.Use(middlewares.AuthMiddleware(middlewares.AuthServices{
+		Users:          env.UserService,
+		Sessions:       env.SessionService,
+		Renewer:        env.SessionService,
+		Revoker:        env.SessionService,
+		Impersonations: env.SessionService,
+		Tokens:         env.TokenService,
+		APIKeys:        env.APIKeyService,
+		Presence:       env.PresenceService,
+	}))
+	// Only the routes which cannot change anything are allowed during an impersonation
+	router.Use(middlewares.ReadOnlyImpersonation(
+		"GET /api/messages",
+		"GET /api/messages/new",
+		"GET /api/messages/check",
+		"GET /api/messages/check/:username",
+		"DELETE /api/impersonation",
+		"GET /api/me",
+		"GET /api/me/invites",
+		"GET /api/me/settings",
+		"GET /api/users",
+		"GET /api/users/:username",
+		"GET /api/users/:username/avatar",
+		"GET /api/presence",
+		"GET /api/presence/events",
+		"GET /api/sessions",
+		"GET /api/keys",
+		"GET /api/activity",
+	))
+
+	// The users without a password can only confirm their identity if single sign-on is available
+	var reauthenticator services.Reauthenticator
+	if env.OIDCService != nil {
+		reauthenticator = env.OIDCService
+	}
 
 	api := router.Group("/api")
 	{
 		// Route registrations can be moved to a separate function(s)
 		// but this way, it is easier to understand the api, in my opinion.
 
-		api.GET("/messages", middlewares.Protected(handlers.GetAllMessages(env.MessagingService)))
-		api.GET("/messages/new", middlewares.Protected(handlers.GetNewMessages(env.MessagingService)))
-		api.GET("/messages/check", middlewares.Protected(handlers.CheckNewMessages(env.MessagingService)))
-		api.POST("/messages/send", middlewares.Protected(handlers.SendMessage(env.MessagingService)))
-		api.PUT("/messages/read/:id", middlewares.Protected(handlers.ReadMessage(env.MessagingService)))
-		api.PUT("/messages/user/read/:username/", middlewares.Protected(handlers.ReadMessages(env.MessagingService)))
+		api.GET("/messages", middlewares.Protected(handlers.GetAllMessages(env.MessagingService), services.ScopeMessagesRead))
+		api.GET("/messages/new", middlewares.Protected(handlers.GetNewMessages(env.MessagingService), services.ScopeMessagesRead))
+		api.GET("/messages/check", middlewares.Protected(handlers.CheckNewMessages(env.MessagingService), services.ScopeMessagesRead))
+		api.GET("/messages/check/:username", middlewares.Protected(handlers.CheckNewMessagesFrom(env.MessagingService), services.ScopeMessagesRead))
+		api.POST("/messages/send", middlewares.Protected(handlers.SendMessage(env.MessagingService), services.ScopeMessagesSend))
+		api.PUT("/messages/read/:id", middlewares.Protected(handlers.ReadMessage(env.MessagingService), services.ScopeMessagesRead))
+		api.PUT("/messages/user/read/:username/", middlewares.Protected(handlers.ReadMessages(env.MessagingService), services.ScopeMessagesRead))
 
-		api.POST("/signup", handlers.Signup(env.UserService, env.AuthService))
+		api.GET("/pow/challenge", handlers.GetChallenge(env.ProofOfWorkService))
+		api.POST("/signup", middlewares.RequireProofOfWork(handlers.Signup(env.UserService, env.AuthService, env.EmailVerificationService, env.Mailer, env.InviteService), env.ProofOfWorkService, services.PurposeSignup))
+		api.POST("/email/verify", handlers.VerifyEmail(env.EmailVerificationService, env.UserService))
+
+		api.POST("/signin", middlewares.RequireProofOfWork(handlers.Signin(env.AuthService, env.UserService, env.TwoFactorService, env.SessionService, env.LockoutService, env.ActivityService), env.ProofOfWorkService, services.PurposeSignin))
+		api.POST("/token", middlewares.RequireProofOfWork(handlers.IssueToken(env.AuthService, env.UserService, env.TwoFactorService, env.LockoutService, env.TokenService, env.TokenService, env.ActivityService), env.ProofOfWorkService, services.PurposeSignin, middlewares.RefreshTokenGrant))
+		api.POST("/deletion/cancel", middlewares.RequireProofOfWork(handlers.CancelDeletion(env.AuthService, env.LockoutService, env.ActivityService, env.AccountDeletionService), env.ProofOfWorkService, services.PurposeSignin))
+		api.POST("/reactivate", middlewares.RequireProofOfWork(handlers.ReactivateAccount(env.AuthService, env.LockoutService, env.ActivityService, env.UserService), env.ProofOfWorkService, services.PurposeSignin))
+		api.POST("/signin/2fa", handlers.SigninTwoFactor(env.TwoFactorService, env.SessionService, env.ActivityService))
+		if env.OIDCService != nil {
+			api.GET("/oidc/login", handlers.BeginOIDCLogin(env.OIDCService))
+			api.GET("/me/oidc/link", middlewares.Protected(handlers.LinkOIDCIdentity(env.OIDCService)))
+			api.GET("/me/oidc/reauthenticate", middlewares.Protected(handlers.ReauthenticateOIDC(env.OIDCService)))
+			api.GET("/oidc/callback", handlers.CompleteOIDCLogin(env.OIDCService, env.OIDCService, env.UserService, env.TwoFactorService, env.SessionService, env.ActivityService, env.InviteService))
+		}
+		api.POST("/password/reset", handlers.RequestPasswordReset(env.UserService, env.PasswordResetService, env.Notifier, env.ActivityService))
+		api.POST("/password/reset/confirm", handlers.ResetPassword(env.PasswordResetService, env.AuthService, env.UserService, env.SessionService, env.TokenService, env.ActivityService))
 
-		api.POST("/signin", handlers.Signin(env.AuthService, env.SessionService, env.ActivityService))
 		api.POST("/signout", middlewares.Protected(handlers.Signout(env.SessionService, env.ActivityService)))
+		api.DELETE("/impersonation", middlewares.Protected(handlers.EndImpersonation(env.SessionService, env.SessionService, env.ActivityService)))
 
 		api.GET("/me", handlers.Me())
+		api.PUT("/me/password", middlewares.Protected(handlers.ChangePassword(env.AuthService, env.LockoutService, env.AuthService, env.UserService, env.SessionService, env.TokenService, env.ActivityService)))
+
+		api.GET("/me/invites", middlewares.Protected(handlers.GetInvites(env.InviteService)))
+		api.POST("/me/invites", middlewares.Protected(handlers.CreateInvite(env.InviteService)))
+		api.GET("/me/settings", middlewares.Protected(handlers.GetSettings()))
+		api.PUT("/me/settings", middlewares.Protected(handlers.UpdateSettings(env.UserService, env.PresenceService)))
+		api.DELETE("/me", middlewares.Protected(handlers.DeleteAccount(env.AuthService, env.LockoutService, env.ActivityService, reauthenticator, env.AccountDeletionService, env.SessionService, env.TokenService)))
+		api.POST("/me/deactivate", middlewares.Protected(handlers.DeactivateAccount(env.AuthService, env.LockoutService, reauthenticator, env.UserService, env.SessionService, env.TokenService, env.ActivityService)))
+		api.PUT("/me/username", middlewares.Protected(handlers.ChangeUsername(env.MigrationService, env.UserService, env.ActivityService)))
+		api.POST("/me/export", middlewares.Protected(handlers.RequestExport(env.ExportService)))
+		api.GET("/me/export/:token", middlewares.Protected(handlers.DownloadExport(env.ExportService)))
+		api.PATCH("/me/profile", middlewares.Protected(handlers.UpdateProfile(env.ProfileService, env.ProfileService)))
+		api.POST("/me/email/verification", middlewares.Protected(handlers.ResendVerificationEmail(env.EmailVerificationService, env.Mailer)))
+
+		api.POST("/me/2fa", middlewares.Protected(handlers.BeginTwoFactorEnrollment(env.TwoFactorService)))
+		api.POST("/me/2fa/verify", middlewares.Protected(handlers.ConfirmTwoFactorEnrollment(env.TwoFactorService, env.ActivityService)))
+		api.POST("/me/2fa/disable", middlewares.Protected(handlers.DisableTwoFactor(env.TwoFactorService, env.ActivityService)))
 
-		api.GET("/activity", middlewares.Protected(handlers.GetActivities(env.ActivityService)))
+		api.GET("/users", middlewares.Protected(handlers.SearchUsers(env.ProfileService)))
+		api.GET("/users/:username", middlewares.Protected(handlers.GetProfile(env.ProfileService)))
+		api.GET("/users/:username/avatar", handlers.GetAvatar(env.ProfileService))
+
+		api.GET("/presence", middlewares.Protected(handlers.GetPresence(env.PresenceService)))
+		api.GET("/presence/events", middlewares.Protected(handlers.PresenceEvents(env.PresenceService, env.PresenceService, env.UserService,
+			common.DurationFromEnv("PRESENCE_STREAM_INTERVAL", 15*time.Second))))
+
+		api.GET("/sessions", middlewares.Protected(handlers.GetSessions(env.SessionService)))
+		api.DELETE("/sessions", middlewares.Protected(handlers.RevokeOtherSessions(env.SessionService)))
+		api.DELETE("/sessions/:id", middlewares.Protected(handlers.RevokeSession(env.SessionService)))
+
+		api.GET("/keys", middlewares.Protected(handlers.GetAPIKeys(env.APIKeyService)))
+		api.POST("/keys", middlewares.Protected(handlers.CreateAPIKey(env.APIKeyService)))
+		api.DELETE("/keys/:id", middlewares.Protected(handlers.RevokeAPIKey(env.APIKeyService)))
+
+		api.GET("/activity", middlewares.Protected(handlers.GetActivities(env.ActivityService), services.ScopeActivityRead))
+	}
+
+	admin := api.Group("/admin", middlewares.Audit(env.AuditService), middlewares.RequireRole(services.RoleAdmin, services.RoleSupport))
+	{
+		admin.GET("/users", middlewares.RequirePermission(handlers.ListUsers(env.UserService), services.PermissionListUsers))
+		admin.PUT("/users/:username/status", middlewares.RequirePermission(handlers.SetUserStatus(env.UserService, env.SessionService, env.TokenService), services.PermissionSuspendUsers))
+		admin.POST("/users/:username/impersonation", middlewares.RequirePermission(handlers.StartImpersonation(env.SessionService, env.ActivityService), services.PermissionImpersonate))
+		admin.GET("/users/:username/activity", middlewares.RequirePermission(handlers.GetUserActivities(env.ActivityService), services.PermissionReadActivities))
+		admin.PUT("/users/:username/invite-quota", middlewares.RequirePermission(handlers.SetInviteQuota(env.InviteService), services.PermissionManageInvites))
+		admin.GET("/invites", middlewares.RequirePermission(handlers.ListInvites(env.InviteService), services.PermissionManageInvites))
+		admin.POST("/invites", middlewares.RequirePermission(handlers.IssueInvite(env.InviteService), services.PermissionManageInvites))
+		admin.GET("/audit", middlewares.RequirePermission(handlers.GetAuditLog(env.AuditService), services.PermissionReadAuditLog))
 	}
 
 	router.Run(":5000")
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// Audit records every request to the routes it is used on in the audit log, including the rejected ones.
+// The action is the method and the route of the request, and the target is its "username" parameter, if any.
+// The actor of an impersonation session is the impersonator, on behalf of the user they are impersonating.
+func Audit(auditLogger services.AuditLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		c.Next()
+
+		var actor, onBehalfOf string
+		if u, exists := c.Get("user"); exists {
+			actor = u.(models.User).Username
+		}
+		if i, impersonated := c.Get("impersonator"); impersonated {
+			actor, onBehalfOf = i.(models.User).Username, actor
+		}
+
+		entry := models.AuditEntry{
+			Actor:      actor,
+			OnBehalfOf: onBehalfOf,
+			Action:     c.Request.Method + " " + c.FullPath(),
+			Target:     c.Param("username"),
+			Status:     c.Writer.Status(),
+			IP:         c.ClientIP(),
+			When:       time.Now(),
+		}
+		if err := auditLogger.LogAudit(c.Copy(), entry); err != nil {
+			logger.WithField("action", entry.Action).Errorf("AuditLogger.LogAudit() raised an error: %v", err.Error())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	admin := models.User{Username: "aliparlakci"}
+	user := models.User{Username: "johndoe"}
+
+	tests := []struct {
+		User               *models.User
+		Impersonator       *models.User
+		ExpectedActor      string
+		ExpectedOnBehalfOf string
+	}{
+		{User: &admin, ExpectedActor: "aliparlakci"},
+		{User: &user, Impersonator: &admin, ExpectedActor: "aliparlakci", ExpectedOnBehalfOf: "johndoe"},
+		{ExpectedActor: ""},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			auditLogger := mocks.NewMockAuditLogger(ctrl)
+
+			var entry models.AuditEntry
+			auditLogger.EXPECT().LogAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, e models.AuditEntry) error {
+				entry = e
+				return nil
+			})
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				if tt.User != nil {
+					c.Set("user", *tt.User)
+				}
+				if tt.Impersonator != nil {
+					c.Set("impersonator", *tt.Impersonator)
+				}
+			})
+			r.GET("/api/admin/users/:username/activity", Audit(auditLogger), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{})
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/api/admin/users/janedoe/activity", nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if entry.Actor != tt.ExpectedActor || entry.OnBehalfOf != tt.ExpectedOnBehalfOf {
+				t.Errorf("want %q on behalf of %q, got %q on behalf of %q", tt.ExpectedActor, tt.ExpectedOnBehalfOf, entry.Actor, entry.OnBehalfOf)
+			}
+			if entry.Target != "janedoe" || entry.Action != "GET /api/admin/users/:username/activity" {
+				t.Errorf("want the action and the target of the request, got %v", entry)
+			}
+		})
+	}
+}
@@ -2,15 +2,43 @@ package middlewares
 
 import (
 	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/models"
 	"github.com/aliparlakci/armut-backend-assessment/services"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"net/http"
+	"strings"
 )
 
-func AuthMiddleware(userGetter services.UserGetter, sessions services.SessionFetcher) gin.HandlerFunc {
+// AuthServices are what AuthMiddleware resolves the users of the requests with.
+type AuthServices struct {
+	Users          services.UserGetter
+	Sessions       services.SessionFetcher
+	Renewer        services.SessionRenewer
+	Revoker        services.SessionRevoker
+	Impersonations services.ImpersonationFetcher
+	Tokens         services.AccessTokenVerifier
+	APIKeys        services.APIKeyAuthenticator
+	Presence       services.PresenceTracker
+}
+
+// AuthMiddleware resolves the user from either the "Authorization: Bearer" header, which carries an access token
+// or an API key, or the session cookie. The scopes of API keys are kept in the context for Protected.
+// Sessions of the users who are not active are revoked.
+// Every authenticated request marks its user as present, except the ones of impersonation sessions, whose
+// impersonator is kept in the context as "impersonator".
+func AuthMiddleware(s AuthServices) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := common.LoggerWithRequestId(c.Copy())
 
+		if authorization := c.GetHeader("Authorization"); strings.HasPrefix(authorization, "Bearer "+services.APIKeyPrefix) {
+			apiKeyAuth(c, logger, s, strings.TrimPrefix(authorization, "Bearer "))
+			return
+		} else if strings.HasPrefix(authorization, "Bearer ") {
+			bearerAuth(c, logger, s, strings.TrimPrefix(authorization, "Bearer "))
+			return
+		}
+
 		sessionId, err := c.Cookie("session")
 		if err == http.ErrNoCookie {
 			c.Next()
@@ -21,30 +49,161 @@ func AuthMiddleware(userGetter services.UserGetter, sessions services.SessionFet
 			return
 		}
 
-		username, err := sessions.FetchSession(c.Copy(), sessionId)
+		userID, err := s.Sessions.FetchSession(c.Copy(), sessionId)
 		if err != nil {
 			logger.WithField("session_id", sessionId).Errorf("sessions.FetchSession raised an error when fetching session with session_id: %v", err.Error())
-			c.Header("Set-Cookie", "session=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;")
+			common.ClearSessionCookie(c)
 			c.Next()
 			return
 		}
 
-		user, err := userGetter.GetUser(c.Copy(), username)
+		user, err := s.Users.GetUserByID(c.Copy(), userID)
 		if err == services.ErrNoUser {
-			logger.WithField("username", username).Debug("user with username does not exist")
-			c.Header("Set-Cookie", "session=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;")
+			logger.WithField("user_id", userID.Hex()).Debug("user with id does not exist")
+			common.ClearSessionCookie(c)
 			c.Next()
 			return
 		}
 		if err != nil {
-			logger.WithField("username", username).Errorf("UserGetter.GetUser() raised an error while finding user with username: %v", err.Error())
-			c.Header("Set-Cookie", "session=; expires=Thu, 01 Jan 1970 00:00:00 GMT; path=/;")
+			logger.WithField("user_id", userID.Hex()).Errorf("UserGetter.GetUserByID() raised an error while finding user with id: %v", err.Error())
+			common.ClearSessionCookie(c)
+			c.Next()
+			return
+		}
+		if err := services.CheckAccountStatus(user); err != nil {
+			logger.WithField("username", user.Username).Debugf("user with username cannot sign in: %v", err.Error())
+			if err := s.Revoker.RevokeSession(c.Copy(), sessionId); err != nil {
+				logger.WithField("session_id", sessionId).Errorf("SessionRevoker.RevokeSession() raised an error while revoking session with session_id: %v", err.Error())
+			}
+			common.ClearSessionCookie(c)
+			common.RejectInactiveAccount(c, err.(services.AccountStatusError))
+			return
+		}
+
+		impersonator, impersonated, ok := sessionImpersonator(c, logger, s, sessionId)
+		if !ok {
+			common.ClearSessionCookie(c)
+			c.Next()
+			return
+		}
+
+		ttl, err := s.Renewer.RenewSession(c.Copy(), sessionId)
+		if err == services.ErrNoSession {
+			logger.WithField("session_id", sessionId).Debug("session with session_id has expired")
+			common.ClearSessionCookie(c)
 			c.Next()
 			return
+		} else if err != nil {
+			logger.WithField("session_id", sessionId).Errorf("SessionRenewer.RenewSession() raised an error while renewing session with session_id: %v", err.Error())
+		} else {
+			common.SetSessionCookie(c, sessionId, ttl)
 		}
 
+		if impersonated {
+			c.Set("impersonator", impersonator)
+		} else {
+			touchPresence(c, logger, s.Presence, user)
+		}
 		c.Set("user", user)
+		c.Set("session_id", sessionId)
 		c.Next()
 	}
 }
 
+// sessionImpersonator returns the impersonator of the session, if it is an impersonation session. The session cannot
+// be used if its impersonation has expired, or the impersonator is no longer allowed to impersonate users.
+func sessionImpersonator(c *gin.Context, logger *logrus.Entry, s AuthServices, sessionId string) (models.User, bool, bool) {
+	impersonatorID, err := s.Impersonations.FetchImpersonator(c.Copy(), sessionId)
+	if err == services.ErrNoSession {
+		logger.WithField("session_id", sessionId).Debug("impersonation of session with session_id has expired")
+		return models.User{}, false, false
+	} else if err != nil {
+		logger.WithField("session_id", sessionId).Errorf("ImpersonationFetcher.FetchImpersonator() raised an error while fetching session with session_id: %v", err.Error())
+		return models.User{}, false, false
+	}
+	if impersonatorID.IsZero() {
+		return models.User{}, false, true
+	}
+
+	impersonator, err := s.Users.GetUserByID(c.Copy(), impersonatorID)
+	if err != nil {
+		logger.WithField("user_id", impersonatorID.Hex()).Errorf("UserGetter.GetUserByID() raised an error while finding the impersonator with id: %v", err.Error())
+		return models.User{}, false, false
+	}
+	if services.CheckAccountStatus(impersonator) != nil || !services.HasPermission(impersonator, services.PermissionImpersonate) {
+		logger.WithField("username", impersonator.Username).Debug("impersonator with username is no longer allowed to impersonate")
+		return models.User{}, false, false
+	}
+
+	return impersonator, true, true
+}
+
+// bearerAuth does not fall back to the session cookie when the token is invalid,
+// so that clients can tell that they need to refresh their tokens.
+func bearerAuth(c *gin.Context, logger *logrus.Entry, s AuthServices, accessToken string) {
+	userID, err := s.Tokens.VerifyAccessToken(c.Copy(), accessToken)
+	if err == services.ErrInvalidAccessToken {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "access token is invalid or expired"})
+		return
+	} else if err != nil {
+		logger.Errorf("AccessTokenVerifier.VerifyAccessToken() raised an error: %v", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+
+	user, err := s.Users.GetUserByID(c.Copy(), userID)
+	if err != nil {
+		logger.WithField("user_id", userID.Hex()).Errorf("UserGetter.GetUserByID() raised an error while finding user with id: %v", err.Error())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "access token is invalid or expired"})
+		return
+	}
+	if err := services.CheckAccountStatus(user); err != nil {
+		common.RejectInactiveAccount(c, err.(services.AccountStatusError))
+		return
+	}
+
+	touchPresence(c, logger, s.Presence, user)
+	c.Set("user", user)
+	c.Next()
+}
+
+func apiKeyAuth(c *gin.Context, logger *logrus.Entry, s AuthServices, key string) {
+	apiKey, err := s.APIKeys.AuthenticateAPIKey(c.Copy(), key)
+	if err == services.ErrInvalidAPIKey {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key is invalid"})
+		return
+	} else if err != nil {
+		logger.Errorf("APIKeyAuthenticator.AuthenticateAPIKey() raised an error: %v", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+
+	user, err := s.Users.GetUserByID(c.Copy(), apiKey.UserID)
+	if err != nil {
+		logger.WithField("user_id", apiKey.UserID.Hex()).Errorf("UserGetter.GetUserByID() raised an error while finding user with id: %v", err.Error())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key is invalid"})
+		return
+	}
+	if err := services.CheckAccountStatus(user); err != nil {
+		common.RejectInactiveAccount(c, err.(services.AccountStatusError))
+		return
+	}
+
+	touchPresence(c, logger, s.Presence, user)
+	c.Set("user", user)
+	c.Set("scopes", apiKey.Scopes)
+	c.Next()
+}
+
+// touchPresence does not fail the request, since presence is not essential.
+func touchPresence(c *gin.Context, logger *logrus.Entry, presence services.PresenceTracker, user models.User) {
+	if user.Profile.PresenceHidden() {
+		return
+	}
+
+	if err := presence.TouchPresence(c.Copy(), user.UserID); err != nil {
+		logger.WithField("username", user.Username).Errorf("PresenceTracker.TouchPresence() raised an error: %v", err.Error())
+	}
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ReadOnlyImpersonation rejects every request of an impersonation session, except the ones to the allowed routes,
+// such as "GET /api/messages" or the one which ends the impersonation. Routes are allowed one by one rather than by
+// their methods, since some GET requests change the account too, like the ones which link an identity.
+func ReadOnlyImpersonation(allowedRoutes ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowedRoutes))
+	for _, route := range allowedRoutes {
+		allowed[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("impersonator"); !impersonated {
+			c.Next()
+			return
+		}
+
+		if allowed[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "impersonation sessions are read-only"})
+	}
+}
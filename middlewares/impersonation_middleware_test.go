@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnlyImpersonation(t *testing.T) {
+	tests := []struct {
+		Method       string
+		Path         string
+		Impersonated bool
+		ExpectedCode int
+	}{
+		{Method: http.MethodGet, Path: "/api/messages", Impersonated: true, ExpectedCode: http.StatusOK},
+		{Method: http.MethodDelete, Path: "/api/impersonation", Impersonated: true, ExpectedCode: http.StatusOK},
+		{Method: http.MethodGet, Path: "/api/oidc/login", Impersonated: true, ExpectedCode: http.StatusForbidden},
+		{Method: http.MethodPost, Path: "/api/messages", Impersonated: true, ExpectedCode: http.StatusForbidden},
+		{Method: http.MethodGet, Path: "/api/oidc/login", ExpectedCode: http.StatusOK},
+		{Method: http.MethodPost, Path: "/api/messages", ExpectedCode: http.StatusOK},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.Use(func(c *gin.Context) {
+				if tt.Impersonated {
+					c.Set("impersonator", models.User{Username: "aliparlakci"})
+				}
+			})
+			r.Use(ReadOnlyImpersonation("GET /api/messages", "DELETE /api/impersonation"))
+			ok := func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{})
+			}
+			r.GET("/api/messages", ok)
+			r.POST("/api/messages", ok)
+			r.GET("/api/oidc/login", ok)
+			r.DELETE("/api/impersonation", ok)
+
+			request, err := http.NewRequest(tt.Method, tt.Path, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
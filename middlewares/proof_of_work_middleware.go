@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequireProofOfWork wraps the handler of a route which requires the solution of a challenge issued for the purpose,
+// in the "pow_challenge" and "pow_solution" fields, when proof of work is required. Requests for which any of the
+// exemptions holds do not need one.
+func RequireProofOfWork(handler gin.HandlerFunc, verifier services.ProofOfWorkVerifier, purpose string, exemptions ...func(c *gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !verifier.ProofOfWorkRequired() {
+			handler(c)
+			return
+		}
+		for _, exempt := range exemptions {
+			if exempt(c) {
+				handler(c)
+				return
+			}
+		}
+
+		logger := common.LoggerWithRequestId(c.Copy())
+
+		err := verifier.VerifyProofOfWork(c.Copy(), purpose, c.PostForm("pow_challenge"), c.PostForm("pow_solution"))
+		if err == services.ErrInvalidProofOfWork {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "proof of work is missing or invalid"})
+			return
+		} else if err != nil {
+			logger.Errorf("ProofOfWorkVerifier.VerifyProofOfWork() raised an error: %v", err.Error())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		handler(c)
+	}
+}
+
+// RefreshTokenGrant exempts refreshing a token on the token endpoint, since the refresh token cannot be guessed.
+// It must not be used on the other routes, which would otherwise be exempt by sending the grant type along.
+func RefreshTokenGrant(c *gin.Context) bool {
+	return c.PostForm("grant_type") == "refresh_token"
+}
@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/common"
+	"github.com/aliparlakci/armut-backend-assessment/mocks"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRequireProofOfWork(t *testing.T) {
+	tests := []struct {
+		Path         string
+		Form         map[string][]string
+		Prepare      func(verifier *mocks.MockProofOfWorkVerifier)
+		ExpectedCode int
+		ExpectedBody gin.H
+	}{
+		{
+			Path: "/api/signup",
+			Form: map[string][]string{"grant_type": {"refresh_token"}, "pow_challenge": {"challenge"}, "pow_solution": {"wrong"}},
+			Prepare: func(verifier *mocks.MockProofOfWorkVerifier) {
+				verifier.EXPECT().ProofOfWorkRequired().Return(true)
+				verifier.EXPECT().VerifyProofOfWork(gomock.Any(), services.PurposeSignup, "challenge", "wrong").Return(services.ErrInvalidProofOfWork)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "proof of work is missing or invalid"},
+		}, {
+			Path: "/api/signup",
+			Form: map[string][]string{"pow_challenge": {"challenge"}, "pow_solution": {"right"}},
+			Prepare: func(verifier *mocks.MockProofOfWorkVerifier) {
+				verifier.EXPECT().ProofOfWorkRequired().Return(true)
+				verifier.EXPECT().VerifyProofOfWork(gomock.Any(), services.PurposeSignup, "challenge", "right").Return(nil)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "handled"},
+		}, {
+			Path: "/api/signup",
+			Form: map[string][]string{},
+			Prepare: func(verifier *mocks.MockProofOfWorkVerifier) {
+				verifier.EXPECT().ProofOfWorkRequired().Return(false)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "handled"},
+		}, {
+			Path: "/api/token",
+			Form: map[string][]string{"grant_type": {"refresh_token"}},
+			Prepare: func(verifier *mocks.MockProofOfWorkVerifier) {
+				verifier.EXPECT().ProofOfWorkRequired().Return(true)
+				verifier.EXPECT().VerifyProofOfWork(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: gin.H{"result": "handled"},
+		}, {
+			Path: "/api/token",
+			Form: map[string][]string{"grant_type": {"password"}},
+			Prepare: func(verifier *mocks.MockProofOfWorkVerifier) {
+				verifier.EXPECT().ProofOfWorkRequired().Return(true)
+				verifier.EXPECT().VerifyProofOfWork(gomock.Any(), services.PurposeSignin, "", "").Return(services.ErrInvalidProofOfWork)
+			},
+			ExpectedCode: http.StatusForbidden,
+			ExpectedBody: gin.H{"error": "proof of work is missing or invalid"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockedVerifier := mocks.NewMockProofOfWorkVerifier(ctrl)
+
+			if tt.Prepare != nil {
+				tt.Prepare(mockedVerifier)
+			}
+
+			handler := func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"result": "handled"})
+			}
+
+			recorder := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(recorder)
+			r.POST("/api/signup", RequireProofOfWork(handler, mockedVerifier, services.PurposeSignup))
+			r.POST("/api/token", RequireProofOfWork(handler, mockedVerifier, services.PurposeSignin, RefreshTokenGrant))
+
+			request, err := http.NewRequest(http.MethodPost, tt.Path, strings.NewReader(url.Values(tt.Form).Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(recorder, request)
+
+			if bodyAssertion, err := common.AreBodiesEqual(tt.ExpectedBody, recorder.Result().Body); err != nil {
+				t.Fatal(err)
+			} else if !bodyAssertion {
+				t.Errorf("response bodies don't match")
+			}
+
+			if recorder.Result().StatusCode != tt.ExpectedCode {
+				t.Errorf("want %v, got %v", tt.ExpectedCode, recorder.Result().StatusCode)
+			}
+		})
+	}
+}
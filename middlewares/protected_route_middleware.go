@@ -5,12 +5,39 @@ import (
 	"net/http"
 )
 
-func Protected(handler gin.HandlerFunc) gin.HandlerFunc {
+// Protected requires the user to be authenticated. Requests authenticated with an API key must also carry
+// every scope the route requires, and routes which do not declare any scope cannot be used with API keys.
+func Protected(handler gin.HandlerFunc, scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if _, exists := c.Get("user"); !exists {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
+		if granted, exists := c.Get("scopes"); exists && !hasScopes(granted.([]string), scopes) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key does not have the required scope"})
+			return
+		}
 		handler(c)
 	}
 }
+
+func hasScopes(granted []string, required []string) bool {
+	if len(required) == 0 {
+		return false
+	}
+
+	for _, scope := range required {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/aliparlakci/armut-backend-assessment/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequireRole requires the user to have at least one of the roles. Unlike Protected, it is meant to be used on
+// route groups. Requests authenticated with an API key are always rejected.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, exists := c.Get("user")
+		if !exists {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if _, isAPIKey := c.Get("scopes"); isAPIKey {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api keys cannot be used here"})
+			return
+		}
+
+		user := u.(models.User)
+		for _, role := range roles {
+			if user.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "user does not have the required role"})
+	}
+}
+
+// RequirePermission wraps the handler of a route which requires the permission, which is granted through the roles of the user.
+func RequirePermission(handler gin.HandlerFunc, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if u, exists := c.Get("user"); !exists {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		} else if !services.HasPermission(u.(models.User), permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "user does not have the required permission"})
+			return
+		}
+		handler(c)
+	}
+}
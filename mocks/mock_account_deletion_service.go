@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: AccountDeleter)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAccountDeleter is a mock of AccountDeleter interface.
+type MockAccountDeleter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountDeleterMockRecorder
+}
+
+// MockAccountDeleterMockRecorder is the mock recorder for MockAccountDeleter.
+type MockAccountDeleterMockRecorder struct {
+	mock *MockAccountDeleter
+}
+
+// NewMockAccountDeleter creates a new mock instance.
+func NewMockAccountDeleter(ctrl *gomock.Controller) *MockAccountDeleter {
+	mock := &MockAccountDeleter{ctrl: ctrl}
+	mock.recorder = &MockAccountDeleterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountDeleter) EXPECT() *MockAccountDeleterMockRecorder {
+	return m.recorder
+}
+
+// CancelDeletion mocks base method.
+func (m *MockAccountDeleter) CancelDeletion(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockAccountDeleterMockRecorder) CancelDeletion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockAccountDeleter)(nil).CancelDeletion), arg0, arg1)
+}
+
+// ScheduleDeletion mocks base method.
+func (m *MockAccountDeleter) ScheduleDeletion(arg0 context.Context, arg1 string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockAccountDeleterMockRecorder) ScheduleDeletion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockAccountDeleter)(nil).ScheduleDeletion), arg0, arg1)
+}
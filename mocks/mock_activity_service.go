@@ -10,6 +10,7 @@ import (
 
 	models "github.com/aliparlakci/armut-backend-assessment/models"
 	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockActivityLogger is a mock of ActivityLogger interface.
@@ -35,6 +36,146 @@ func (m *MockActivityLogger) EXPECT() *MockActivityLoggerMockRecorder {
 	return m.recorder
 }
 
+// LogDeactivation mocks base method.
+func (m *MockActivityLogger) LogDeactivation(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogDeactivation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogDeactivation indicates an expected call of LogDeactivation.
+func (mr *MockActivityLoggerMockRecorder) LogDeactivation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogDeactivation", reflect.TypeOf((*MockActivityLogger)(nil).LogDeactivation), arg0, arg1, arg2)
+}
+
+// LogIdentityLink mocks base method.
+func (m *MockActivityLogger) LogIdentityLink(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogIdentityLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogIdentityLink indicates an expected call of LogIdentityLink.
+func (mr *MockActivityLoggerMockRecorder) LogIdentityLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogIdentityLink", reflect.TypeOf((*MockActivityLogger)(nil).LogIdentityLink), arg0, arg1, arg2)
+}
+
+// LogImpersonationEnd mocks base method.
+func (m *MockActivityLogger) LogImpersonationEnd(arg0 context.Context, arg1 string, arg2 primitive.ObjectID, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogImpersonationEnd", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogImpersonationEnd indicates an expected call of LogImpersonationEnd.
+func (mr *MockActivityLoggerMockRecorder) LogImpersonationEnd(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogImpersonationEnd", reflect.TypeOf((*MockActivityLogger)(nil).LogImpersonationEnd), arg0, arg1, arg2, arg3)
+}
+
+// LogImpersonationStart mocks base method.
+func (m *MockActivityLogger) LogImpersonationStart(arg0 context.Context, arg1 string, arg2 primitive.ObjectID, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogImpersonationStart", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogImpersonationStart indicates an expected call of LogImpersonationStart.
+func (mr *MockActivityLoggerMockRecorder) LogImpersonationStart(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogImpersonationStart", reflect.TypeOf((*MockActivityLogger)(nil).LogImpersonationStart), arg0, arg1, arg2, arg3)
+}
+
+// LogLockout mocks base method.
+func (m *MockActivityLogger) LogLockout(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogLockout", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogLockout indicates an expected call of LogLockout.
+func (mr *MockActivityLoggerMockRecorder) LogLockout(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogLockout", reflect.TypeOf((*MockActivityLogger)(nil).LogLockout), arg0, arg1, arg2)
+}
+
+// LogPasswordChange mocks base method.
+func (m *MockActivityLogger) LogPasswordChange(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogPasswordChange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogPasswordChange indicates an expected call of LogPasswordChange.
+func (mr *MockActivityLoggerMockRecorder) LogPasswordChange(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogPasswordChange", reflect.TypeOf((*MockActivityLogger)(nil).LogPasswordChange), arg0, arg1, arg2)
+}
+
+// LogPasswordReset mocks base method.
+func (m *MockActivityLogger) LogPasswordReset(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogPasswordReset", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogPasswordReset indicates an expected call of LogPasswordReset.
+func (mr *MockActivityLoggerMockRecorder) LogPasswordReset(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogPasswordReset", reflect.TypeOf((*MockActivityLogger)(nil).LogPasswordReset), arg0, arg1, arg2)
+}
+
+// LogPasswordResetRequest mocks base method.
+func (m *MockActivityLogger) LogPasswordResetRequest(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogPasswordResetRequest", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogPasswordResetRequest indicates an expected call of LogPasswordResetRequest.
+func (mr *MockActivityLoggerMockRecorder) LogPasswordResetRequest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogPasswordResetRequest", reflect.TypeOf((*MockActivityLogger)(nil).LogPasswordResetRequest), arg0, arg1, arg2)
+}
+
+// LogReactivation mocks base method.
+func (m *MockActivityLogger) LogReactivation(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogReactivation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogReactivation indicates an expected call of LogReactivation.
+func (mr *MockActivityLoggerMockRecorder) LogReactivation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogReactivation", reflect.TypeOf((*MockActivityLogger)(nil).LogReactivation), arg0, arg1, arg2)
+}
+
+// LogRefreshTokenReuse mocks base method.
+func (m *MockActivityLogger) LogRefreshTokenReuse(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogRefreshTokenReuse", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogRefreshTokenReuse indicates an expected call of LogRefreshTokenReuse.
+func (mr *MockActivityLoggerMockRecorder) LogRefreshTokenReuse(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogRefreshTokenReuse", reflect.TypeOf((*MockActivityLogger)(nil).LogRefreshTokenReuse), arg0, arg1, arg2)
+}
+
 // LogSignin mocks base method.
 func (m *MockActivityLogger) LogSignin(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -63,6 +204,48 @@ func (mr *MockActivityLoggerMockRecorder) LogSignout(arg0, arg1, arg2 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogSignout", reflect.TypeOf((*MockActivityLogger)(nil).LogSignout), arg0, arg1, arg2)
 }
 
+// LogTwoFactor mocks base method.
+func (m *MockActivityLogger) LogTwoFactor(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogTwoFactor indicates an expected call of LogTwoFactor.
+func (mr *MockActivityLoggerMockRecorder) LogTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogTwoFactor", reflect.TypeOf((*MockActivityLogger)(nil).LogTwoFactor), arg0, arg1, arg2)
+}
+
+// LogTwoFactorDisable mocks base method.
+func (m *MockActivityLogger) LogTwoFactorDisable(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogTwoFactorDisable", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogTwoFactorDisable indicates an expected call of LogTwoFactorDisable.
+func (mr *MockActivityLoggerMockRecorder) LogTwoFactorDisable(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogTwoFactorDisable", reflect.TypeOf((*MockActivityLogger)(nil).LogTwoFactorDisable), arg0, arg1, arg2)
+}
+
+// LogTwoFactorEnable mocks base method.
+func (m *MockActivityLogger) LogTwoFactorEnable(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogTwoFactorEnable", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogTwoFactorEnable indicates an expected call of LogTwoFactorEnable.
+func (mr *MockActivityLoggerMockRecorder) LogTwoFactorEnable(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogTwoFactorEnable", reflect.TypeOf((*MockActivityLogger)(nil).LogTwoFactorEnable), arg0, arg1, arg2)
+}
+
 // LogUnsuccesfulSignin mocks base method.
 func (m *MockActivityLogger) LogUnsuccesfulSignin(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -77,6 +260,34 @@ func (mr *MockActivityLoggerMockRecorder) LogUnsuccesfulSignin(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogUnsuccesfulSignin", reflect.TypeOf((*MockActivityLogger)(nil).LogUnsuccesfulSignin), arg0, arg1, arg2)
 }
 
+// LogUnsuccesfulTwoFactor mocks base method.
+func (m *MockActivityLogger) LogUnsuccesfulTwoFactor(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogUnsuccesfulTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogUnsuccesfulTwoFactor indicates an expected call of LogUnsuccesfulTwoFactor.
+func (mr *MockActivityLoggerMockRecorder) LogUnsuccesfulTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogUnsuccesfulTwoFactor", reflect.TypeOf((*MockActivityLogger)(nil).LogUnsuccesfulTwoFactor), arg0, arg1, arg2)
+}
+
+// LogUsernameChange mocks base method.
+func (m *MockActivityLogger) LogUsernameChange(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogUsernameChange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogUsernameChange indicates an expected call of LogUsernameChange.
+func (mr *MockActivityLoggerMockRecorder) LogUsernameChange(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogUsernameChange", reflect.TypeOf((*MockActivityLogger)(nil).LogUsernameChange), arg0, arg1, arg2)
+}
+
 // MockActivityFetcher is a mock of ActivityFetcher interface.
 type MockActivityFetcher struct {
 	ctrl     *gomock.Controller
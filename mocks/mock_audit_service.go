@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: AuditLogger,AuditFetcher)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuditLogger is a mock of AuditLogger interface.
+type MockAuditLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditLoggerMockRecorder
+}
+
+// MockAuditLoggerMockRecorder is the mock recorder for MockAuditLogger.
+type MockAuditLoggerMockRecorder struct {
+	mock *MockAuditLogger
+}
+
+// NewMockAuditLogger creates a new mock instance.
+func NewMockAuditLogger(ctrl *gomock.Controller) *MockAuditLogger {
+	mock := &MockAuditLogger{ctrl: ctrl}
+	mock.recorder = &MockAuditLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditLogger) EXPECT() *MockAuditLoggerMockRecorder {
+	return m.recorder
+}
+
+// LogAudit mocks base method.
+func (m *MockAuditLogger) LogAudit(arg0 context.Context, arg1 models.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogAudit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogAudit indicates an expected call of LogAudit.
+func (mr *MockAuditLoggerMockRecorder) LogAudit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogAudit", reflect.TypeOf((*MockAuditLogger)(nil).LogAudit), arg0, arg1)
+}
+
+// MockAuditFetcher is a mock of AuditFetcher interface.
+type MockAuditFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditFetcherMockRecorder
+}
+
+// MockAuditFetcherMockRecorder is the mock recorder for MockAuditFetcher.
+type MockAuditFetcherMockRecorder struct {
+	mock *MockAuditFetcher
+}
+
+// NewMockAuditFetcher creates a new mock instance.
+func NewMockAuditFetcher(ctrl *gomock.Controller) *MockAuditFetcher {
+	mock := &MockAuditFetcher{ctrl: ctrl}
+	mock.recorder = &MockAuditFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditFetcher) EXPECT() *MockAuditFetcherMockRecorder {
+	return m.recorder
+}
+
+// FetchAudit mocks base method.
+func (m *MockAuditFetcher) FetchAudit(arg0 context.Context, arg1, arg2 int64) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchAudit", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchAudit indicates an expected call of FetchAudit.
+func (mr *MockAuditFetcherMockRecorder) FetchAudit(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAudit", reflect.TypeOf((*MockAuditFetcher)(nil).FetchAudit), arg0, arg1, arg2)
+}
@@ -0,0 +1,89 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: VerificationTokenCreator,VerificationTokenConsumer)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockVerificationTokenCreator is a mock of VerificationTokenCreator interface.
+type MockVerificationTokenCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerificationTokenCreatorMockRecorder
+}
+
+// MockVerificationTokenCreatorMockRecorder is the mock recorder for MockVerificationTokenCreator.
+type MockVerificationTokenCreatorMockRecorder struct {
+	mock *MockVerificationTokenCreator
+}
+
+// NewMockVerificationTokenCreator creates a new mock instance.
+func NewMockVerificationTokenCreator(ctrl *gomock.Controller) *MockVerificationTokenCreator {
+	mock := &MockVerificationTokenCreator{ctrl: ctrl}
+	mock.recorder = &MockVerificationTokenCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerificationTokenCreator) EXPECT() *MockVerificationTokenCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateVerificationToken mocks base method.
+func (m *MockVerificationTokenCreator) CreateVerificationToken(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerificationToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVerificationToken indicates an expected call of CreateVerificationToken.
+func (mr *MockVerificationTokenCreatorMockRecorder) CreateVerificationToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerificationToken", reflect.TypeOf((*MockVerificationTokenCreator)(nil).CreateVerificationToken), arg0, arg1, arg2)
+}
+
+// MockVerificationTokenConsumer is a mock of VerificationTokenConsumer interface.
+type MockVerificationTokenConsumer struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerificationTokenConsumerMockRecorder
+}
+
+// MockVerificationTokenConsumerMockRecorder is the mock recorder for MockVerificationTokenConsumer.
+type MockVerificationTokenConsumerMockRecorder struct {
+	mock *MockVerificationTokenConsumer
+}
+
+// NewMockVerificationTokenConsumer creates a new mock instance.
+func NewMockVerificationTokenConsumer(ctrl *gomock.Controller) *MockVerificationTokenConsumer {
+	mock := &MockVerificationTokenConsumer{ctrl: ctrl}
+	mock.recorder = &MockVerificationTokenConsumerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerificationTokenConsumer) EXPECT() *MockVerificationTokenConsumerMockRecorder {
+	return m.recorder
+}
+
+// ConsumeVerificationToken mocks base method.
+func (m *MockVerificationTokenConsumer) ConsumeVerificationToken(arg0 context.Context, arg1 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeVerificationToken", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConsumeVerificationToken indicates an expected call of ConsumeVerificationToken.
+func (mr *MockVerificationTokenConsumerMockRecorder) ConsumeVerificationToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeVerificationToken", reflect.TypeOf((*MockVerificationTokenConsumer)(nil).ConsumeVerificationToken), arg0, arg1)
+}
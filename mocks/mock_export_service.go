@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: DataExporter)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDataExporter is a mock of DataExporter interface.
+type MockDataExporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockDataExporterMockRecorder
+}
+
+// MockDataExporterMockRecorder is the mock recorder for MockDataExporter.
+type MockDataExporterMockRecorder struct {
+	mock *MockDataExporter
+}
+
+// NewMockDataExporter creates a new mock instance.
+func NewMockDataExporter(ctrl *gomock.Controller) *MockDataExporter {
+	mock := &MockDataExporter{ctrl: ctrl}
+	mock.recorder = &MockDataExporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDataExporter) EXPECT() *MockDataExporterMockRecorder {
+	return m.recorder
+}
+
+// FetchExport mocks base method.
+func (m *MockDataExporter) FetchExport(arg0 context.Context, arg1, arg2 string) (models.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchExport", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchExport indicates an expected call of FetchExport.
+func (mr *MockDataExporterMockRecorder) FetchExport(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchExport", reflect.TypeOf((*MockDataExporter)(nil).FetchExport), arg0, arg1, arg2)
+}
+
+// OpenArchive mocks base method.
+func (m *MockDataExporter) OpenArchive(arg0 context.Context, arg1 models.Export) (io.ReadCloser, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenArchive", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenArchive indicates an expected call of OpenArchive.
+func (mr *MockDataExporterMockRecorder) OpenArchive(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenArchive", reflect.TypeOf((*MockDataExporter)(nil).OpenArchive), arg0, arg1)
+}
+
+// RequestExport mocks base method.
+func (m *MockDataExporter) RequestExport(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestExport", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestExport indicates an expected call of RequestExport.
+func (mr *MockDataExporterMockRecorder) RequestExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestExport", reflect.TypeOf((*MockDataExporter)(nil).RequestExport), arg0, arg1)
+}
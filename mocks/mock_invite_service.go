@@ -0,0 +1,214 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: InviteRedeemer,InviteCreator,InviteManager)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInviteRedeemer is a mock of InviteRedeemer interface.
+type MockInviteRedeemer struct {
+	ctrl     *gomock.Controller
+	recorder *MockInviteRedeemerMockRecorder
+}
+
+// MockInviteRedeemerMockRecorder is the mock recorder for MockInviteRedeemer.
+type MockInviteRedeemerMockRecorder struct {
+	mock *MockInviteRedeemer
+}
+
+// NewMockInviteRedeemer creates a new mock instance.
+func NewMockInviteRedeemer(ctrl *gomock.Controller) *MockInviteRedeemer {
+	mock := &MockInviteRedeemer{ctrl: ctrl}
+	mock.recorder = &MockInviteRedeemerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInviteRedeemer) EXPECT() *MockInviteRedeemerMockRecorder {
+	return m.recorder
+}
+
+// InvitesRequired mocks base method.
+func (m *MockInviteRedeemer) InvitesRequired() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvitesRequired")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// InvitesRequired indicates an expected call of InvitesRequired.
+func (mr *MockInviteRedeemerMockRecorder) InvitesRequired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvitesRequired", reflect.TypeOf((*MockInviteRedeemer)(nil).InvitesRequired))
+}
+
+// RecordInvitee mocks base method.
+func (m *MockInviteRedeemer) RecordInvitee(arg0 context.Context, arg1 string, arg2 models.Invite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordInvitee", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordInvitee indicates an expected call of RecordInvitee.
+func (mr *MockInviteRedeemerMockRecorder) RecordInvitee(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordInvitee", reflect.TypeOf((*MockInviteRedeemer)(nil).RecordInvitee), arg0, arg1, arg2)
+}
+
+// RedeemInvite mocks base method.
+func (m *MockInviteRedeemer) RedeemInvite(arg0 context.Context, arg1 string) (models.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemInvite", arg0, arg1)
+	ret0, _ := ret[0].(models.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemInvite indicates an expected call of RedeemInvite.
+func (mr *MockInviteRedeemerMockRecorder) RedeemInvite(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemInvite", reflect.TypeOf((*MockInviteRedeemer)(nil).RedeemInvite), arg0, arg1)
+}
+
+// ReleaseInvite mocks base method.
+func (m *MockInviteRedeemer) ReleaseInvite(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseInvite", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseInvite indicates an expected call of ReleaseInvite.
+func (mr *MockInviteRedeemerMockRecorder) ReleaseInvite(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseInvite", reflect.TypeOf((*MockInviteRedeemer)(nil).ReleaseInvite), arg0, arg1)
+}
+
+// MockInviteCreator is a mock of InviteCreator interface.
+type MockInviteCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockInviteCreatorMockRecorder
+}
+
+// MockInviteCreatorMockRecorder is the mock recorder for MockInviteCreator.
+type MockInviteCreatorMockRecorder struct {
+	mock *MockInviteCreator
+}
+
+// NewMockInviteCreator creates a new mock instance.
+func NewMockInviteCreator(ctrl *gomock.Controller) *MockInviteCreator {
+	mock := &MockInviteCreator{ctrl: ctrl}
+	mock.recorder = &MockInviteCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInviteCreator) EXPECT() *MockInviteCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateUserInvite mocks base method.
+func (m *MockInviteCreator) CreateUserInvite(arg0 context.Context, arg1 string) (models.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserInvite", arg0, arg1)
+	ret0, _ := ret[0].(models.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserInvite indicates an expected call of CreateUserInvite.
+func (mr *MockInviteCreatorMockRecorder) CreateUserInvite(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserInvite", reflect.TypeOf((*MockInviteCreator)(nil).CreateUserInvite), arg0, arg1)
+}
+
+// ListUserInvites mocks base method.
+func (m *MockInviteCreator) ListUserInvites(arg0 context.Context, arg1 string) (models.InviteList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserInvites", arg0, arg1)
+	ret0, _ := ret[0].(models.InviteList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserInvites indicates an expected call of ListUserInvites.
+func (mr *MockInviteCreatorMockRecorder) ListUserInvites(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserInvites", reflect.TypeOf((*MockInviteCreator)(nil).ListUserInvites), arg0, arg1)
+}
+
+// MockInviteManager is a mock of InviteManager interface.
+type MockInviteManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockInviteManagerMockRecorder
+}
+
+// MockInviteManagerMockRecorder is the mock recorder for MockInviteManager.
+type MockInviteManagerMockRecorder struct {
+	mock *MockInviteManager
+}
+
+// NewMockInviteManager creates a new mock instance.
+func NewMockInviteManager(ctrl *gomock.Controller) *MockInviteManager {
+	mock := &MockInviteManager{ctrl: ctrl}
+	mock.recorder = &MockInviteManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInviteManager) EXPECT() *MockInviteManagerMockRecorder {
+	return m.recorder
+}
+
+// CreateInvite mocks base method.
+func (m *MockInviteManager) CreateInvite(arg0 context.Context, arg1 string, arg2 int, arg3 time.Duration) (models.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockInviteManagerMockRecorder) CreateInvite(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockInviteManager)(nil).CreateInvite), arg0, arg1, arg2, arg3)
+}
+
+// ListInvites mocks base method.
+func (m *MockInviteManager) ListInvites(arg0 context.Context, arg1, arg2 int64) ([]models.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvites", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvites indicates an expected call of ListInvites.
+func (mr *MockInviteManagerMockRecorder) ListInvites(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvites", reflect.TypeOf((*MockInviteManager)(nil).ListInvites), arg0, arg1, arg2)
+}
+
+// SetInviteQuota mocks base method.
+func (m *MockInviteManager) SetInviteQuota(arg0 context.Context, arg1 string, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInviteQuota", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetInviteQuota indicates an expected call of SetInviteQuota.
+func (mr *MockInviteManagerMockRecorder) SetInviteQuota(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInviteQuota", reflect.TypeOf((*MockInviteManager)(nil).SetInviteQuota), arg0, arg1, arg2)
+}
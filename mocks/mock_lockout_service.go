@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: SigninThrottler)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSigninThrottler is a mock of SigninThrottler interface.
+type MockSigninThrottler struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigninThrottlerMockRecorder
+}
+
+// MockSigninThrottlerMockRecorder is the mock recorder for MockSigninThrottler.
+type MockSigninThrottlerMockRecorder struct {
+	mock *MockSigninThrottler
+}
+
+// NewMockSigninThrottler creates a new mock instance.
+func NewMockSigninThrottler(ctrl *gomock.Controller) *MockSigninThrottler {
+	mock := &MockSigninThrottler{ctrl: ctrl}
+	mock.recorder = &MockSigninThrottlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigninThrottler) EXPECT() *MockSigninThrottlerMockRecorder {
+	return m.recorder
+}
+
+// CheckLockout mocks base method.
+func (m *MockSigninThrottler) CheckLockout(arg0 context.Context, arg1, arg2 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckLockout", arg0, arg1, arg2)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckLockout indicates an expected call of CheckLockout.
+func (mr *MockSigninThrottlerMockRecorder) CheckLockout(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLockout", reflect.TypeOf((*MockSigninThrottler)(nil).CheckLockout), arg0, arg1, arg2)
+}
+
+// RecordFailure mocks base method.
+func (m *MockSigninThrottler) RecordFailure(arg0 context.Context, arg1, arg2 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", arg0, arg1, arg2)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockSigninThrottlerMockRecorder) RecordFailure(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockSigninThrottler)(nil).RecordFailure), arg0, arg1, arg2)
+}
+
+// ResetFailures mocks base method.
+func (m *MockSigninThrottler) ResetFailures(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetFailures", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetFailures indicates an expected call of ResetFailures.
+func (mr *MockSigninThrottlerMockRecorder) ResetFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFailures", reflect.TypeOf((*MockSigninThrottler)(nil).ResetFailures), arg0, arg1)
+}
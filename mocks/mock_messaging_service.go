@@ -139,6 +139,21 @@ func (mr *MockMessageGetterMockRecorder) CheckNewMessages(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckNewMessages", reflect.TypeOf((*MockMessageGetter)(nil).CheckNewMessages), arg0, arg1)
 }
 
+// CheckNewMessagesFrom mocks base method.
+func (m *MockMessageGetter) CheckNewMessagesFrom(arg0 context.Context, arg1, arg2 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckNewMessagesFrom", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckNewMessagesFrom indicates an expected call of CheckNewMessagesFrom.
+func (mr *MockMessageGetterMockRecorder) CheckNewMessagesFrom(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckNewMessagesFrom", reflect.TypeOf((*MockMessageGetter)(nil).CheckNewMessagesFrom), arg0, arg1, arg2)
+}
+
 // GetAllMessages mocks base method.
 func (m *MockMessageGetter) GetAllMessages(arg0 context.Context, arg1 string) ([]models.Message, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: MigrationChecker)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMigrationChecker is a mock of MigrationChecker interface.
+type MockMigrationChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigrationCheckerMockRecorder
+}
+
+// MockMigrationCheckerMockRecorder is the mock recorder for MockMigrationChecker.
+type MockMigrationCheckerMockRecorder struct {
+	mock *MockMigrationChecker
+}
+
+// NewMockMigrationChecker creates a new mock instance.
+func NewMockMigrationChecker(ctrl *gomock.Controller) *MockMigrationChecker {
+	mock := &MockMigrationChecker{ctrl: ctrl}
+	mock.recorder = &MockMigrationCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMigrationChecker) EXPECT() *MockMigrationCheckerMockRecorder {
+	return m.recorder
+}
+
+// MigrationCompleted mocks base method.
+func (m *MockMigrationChecker) MigrationCompleted(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationCompleted", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrationCompleted indicates an expected call of MigrationCompleted.
+func (mr *MockMigrationCheckerMockRecorder) MigrationCompleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationCompleted", reflect.TypeOf((*MockMigrationChecker)(nil).MigrationCompleted), arg0, arg1)
+}
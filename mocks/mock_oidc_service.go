@@ -0,0 +1,135 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: OIDCAuthenticator,Reauthenticator)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOIDCAuthenticator is a mock of OIDCAuthenticator interface.
+type MockOIDCAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCAuthenticatorMockRecorder
+}
+
+// MockOIDCAuthenticatorMockRecorder is the mock recorder for MockOIDCAuthenticator.
+type MockOIDCAuthenticatorMockRecorder struct {
+	mock *MockOIDCAuthenticator
+}
+
+// NewMockOIDCAuthenticator creates a new mock instance.
+func NewMockOIDCAuthenticator(ctrl *gomock.Controller) *MockOIDCAuthenticator {
+	mock := &MockOIDCAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockOIDCAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCAuthenticator) EXPECT() *MockOIDCAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockOIDCAuthenticator) BeginLogin(arg0 context.Context, arg1 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockOIDCAuthenticatorMockRecorder) BeginLogin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockOIDCAuthenticator)(nil).BeginLogin), arg0, arg1)
+}
+
+// BeginReauthentication mocks base method.
+func (m *MockOIDCAuthenticator) BeginReauthentication(arg0 context.Context, arg1 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginReauthentication", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BeginReauthentication indicates an expected call of BeginReauthentication.
+func (mr *MockOIDCAuthenticatorMockRecorder) BeginReauthentication(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginReauthentication", reflect.TypeOf((*MockOIDCAuthenticator)(nil).BeginReauthentication), arg0, arg1)
+}
+
+// CompleteLogin mocks base method.
+func (m *MockOIDCAuthenticator) CompleteLogin(arg0 context.Context, arg1, arg2 string) (models.OIDCLogin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLogin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.OIDCLogin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteLogin indicates an expected call of CompleteLogin.
+func (mr *MockOIDCAuthenticatorMockRecorder) CompleteLogin(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLogin", reflect.TypeOf((*MockOIDCAuthenticator)(nil).CompleteLogin), arg0, arg1, arg2)
+}
+
+// MockReauthenticator is a mock of Reauthenticator interface.
+type MockReauthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockReauthenticatorMockRecorder
+}
+
+// MockReauthenticatorMockRecorder is the mock recorder for MockReauthenticator.
+type MockReauthenticatorMockRecorder struct {
+	mock *MockReauthenticator
+}
+
+// NewMockReauthenticator creates a new mock instance.
+func NewMockReauthenticator(ctrl *gomock.Controller) *MockReauthenticator {
+	mock := &MockReauthenticator{ctrl: ctrl}
+	mock.recorder = &MockReauthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReauthenticator) EXPECT() *MockReauthenticatorMockRecorder {
+	return m.recorder
+}
+
+// ConfirmReauthentication mocks base method.
+func (m *MockReauthenticator) ConfirmReauthentication(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmReauthentication", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmReauthentication indicates an expected call of ConfirmReauthentication.
+func (mr *MockReauthenticatorMockRecorder) ConfirmReauthentication(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmReauthentication", reflect.TypeOf((*MockReauthenticator)(nil).ConfirmReauthentication), arg0, arg1)
+}
+
+// ConsumeReauthentication mocks base method.
+func (m *MockReauthenticator) ConsumeReauthentication(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeReauthentication", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeReauthentication indicates an expected call of ConsumeReauthentication.
+func (mr *MockReauthenticatorMockRecorder) ConsumeReauthentication(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeReauthentication", reflect.TypeOf((*MockReauthenticator)(nil).ConsumeReauthentication), arg0, arg1)
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: ResetTokenCreator,ResetTokenConsumer)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockResetTokenCreator is a mock of ResetTokenCreator interface.
+type MockResetTokenCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockResetTokenCreatorMockRecorder
+}
+
+// MockResetTokenCreatorMockRecorder is the mock recorder for MockResetTokenCreator.
+type MockResetTokenCreatorMockRecorder struct {
+	mock *MockResetTokenCreator
+}
+
+// NewMockResetTokenCreator creates a new mock instance.
+func NewMockResetTokenCreator(ctrl *gomock.Controller) *MockResetTokenCreator {
+	mock := &MockResetTokenCreator{ctrl: ctrl}
+	mock.recorder = &MockResetTokenCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResetTokenCreator) EXPECT() *MockResetTokenCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateResetToken mocks base method.
+func (m *MockResetTokenCreator) CreateResetToken(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateResetToken", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateResetToken indicates an expected call of CreateResetToken.
+func (mr *MockResetTokenCreatorMockRecorder) CreateResetToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResetToken", reflect.TypeOf((*MockResetTokenCreator)(nil).CreateResetToken), arg0, arg1)
+}
+
+// MockResetTokenConsumer is a mock of ResetTokenConsumer interface.
+type MockResetTokenConsumer struct {
+	ctrl     *gomock.Controller
+	recorder *MockResetTokenConsumerMockRecorder
+}
+
+// MockResetTokenConsumerMockRecorder is the mock recorder for MockResetTokenConsumer.
+type MockResetTokenConsumerMockRecorder struct {
+	mock *MockResetTokenConsumer
+}
+
+// NewMockResetTokenConsumer creates a new mock instance.
+func NewMockResetTokenConsumer(ctrl *gomock.Controller) *MockResetTokenConsumer {
+	mock := &MockResetTokenConsumer{ctrl: ctrl}
+	mock.recorder = &MockResetTokenConsumerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResetTokenConsumer) EXPECT() *MockResetTokenConsumerMockRecorder {
+	return m.recorder
+}
+
+// ConsumeResetToken mocks base method.
+func (m *MockResetTokenConsumer) ConsumeResetToken(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeResetToken", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeResetToken indicates an expected call of ConsumeResetToken.
+func (mr *MockResetTokenConsumerMockRecorder) ConsumeResetToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeResetToken", reflect.TypeOf((*MockResetTokenConsumer)(nil).ConsumeResetToken), arg0, arg1)
+}
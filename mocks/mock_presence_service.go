@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: PresenceTracker,PresenceGetter)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockPresenceTracker is a mock of PresenceTracker interface.
+type MockPresenceTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockPresenceTrackerMockRecorder
+}
+
+// MockPresenceTrackerMockRecorder is the mock recorder for MockPresenceTracker.
+type MockPresenceTrackerMockRecorder struct {
+	mock *MockPresenceTracker
+}
+
+// NewMockPresenceTracker creates a new mock instance.
+func NewMockPresenceTracker(ctrl *gomock.Controller) *MockPresenceTracker {
+	mock := &MockPresenceTracker{ctrl: ctrl}
+	mock.recorder = &MockPresenceTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPresenceTracker) EXPECT() *MockPresenceTrackerMockRecorder {
+	return m.recorder
+}
+
+// ForgetPresence mocks base method.
+func (m *MockPresenceTracker) ForgetPresence(arg0 context.Context, arg1 primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgetPresence", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgetPresence indicates an expected call of ForgetPresence.
+func (mr *MockPresenceTrackerMockRecorder) ForgetPresence(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetPresence", reflect.TypeOf((*MockPresenceTracker)(nil).ForgetPresence), arg0, arg1)
+}
+
+// TouchPresence mocks base method.
+func (m *MockPresenceTracker) TouchPresence(arg0 context.Context, arg1 primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchPresence", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchPresence indicates an expected call of TouchPresence.
+func (mr *MockPresenceTrackerMockRecorder) TouchPresence(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchPresence", reflect.TypeOf((*MockPresenceTracker)(nil).TouchPresence), arg0, arg1)
+}
+
+// MockPresenceGetter is a mock of PresenceGetter interface.
+type MockPresenceGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockPresenceGetterMockRecorder
+}
+
+// MockPresenceGetterMockRecorder is the mock recorder for MockPresenceGetter.
+type MockPresenceGetterMockRecorder struct {
+	mock *MockPresenceGetter
+}
+
+// NewMockPresenceGetter creates a new mock instance.
+func NewMockPresenceGetter(ctrl *gomock.Controller) *MockPresenceGetter {
+	mock := &MockPresenceGetter{ctrl: ctrl}
+	mock.recorder = &MockPresenceGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPresenceGetter) EXPECT() *MockPresenceGetterMockRecorder {
+	return m.recorder
+}
+
+// GetPresences mocks base method.
+func (m *MockPresenceGetter) GetPresences(arg0 context.Context, arg1 []string) ([]models.Presence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPresences", arg0, arg1)
+	ret0, _ := ret[0].([]models.Presence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPresences indicates an expected call of GetPresences.
+func (mr *MockPresenceGetterMockRecorder) GetPresences(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresences", reflect.TypeOf((*MockPresenceGetter)(nil).GetPresences), arg0, arg1)
+}
@@ -0,0 +1,181 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: ProfileGetter,ProfileUpdater,AvatarGetter,UserSearcher)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockProfileGetter is a mock of ProfileGetter interface.
+type MockProfileGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockProfileGetterMockRecorder
+}
+
+// MockProfileGetterMockRecorder is the mock recorder for MockProfileGetter.
+type MockProfileGetterMockRecorder struct {
+	mock *MockProfileGetter
+}
+
+// NewMockProfileGetter creates a new mock instance.
+func NewMockProfileGetter(ctrl *gomock.Controller) *MockProfileGetter {
+	mock := &MockProfileGetter{ctrl: ctrl}
+	mock.recorder = &MockProfileGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProfileGetter) EXPECT() *MockProfileGetterMockRecorder {
+	return m.recorder
+}
+
+// GetProfile mocks base method.
+func (m *MockProfileGetter) GetProfile(arg0 context.Context, arg1 string) (models.PublicProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", arg0, arg1)
+	ret0, _ := ret[0].(models.PublicProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockProfileGetterMockRecorder) GetProfile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockProfileGetter)(nil).GetProfile), arg0, arg1)
+}
+
+// GetProfilesByID mocks base method.
+func (m *MockProfileGetter) GetProfilesByID(arg0 context.Context, arg1 []primitive.ObjectID) (map[primitive.ObjectID]models.PublicProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfilesByID", arg0, arg1)
+	ret0, _ := ret[0].(map[primitive.ObjectID]models.PublicProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfilesByID indicates an expected call of GetProfilesByID.
+func (mr *MockProfileGetterMockRecorder) GetProfilesByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfilesByID", reflect.TypeOf((*MockProfileGetter)(nil).GetProfilesByID), arg0, arg1)
+}
+
+// MockProfileUpdater is a mock of ProfileUpdater interface.
+type MockProfileUpdater struct {
+	ctrl     *gomock.Controller
+	recorder *MockProfileUpdaterMockRecorder
+}
+
+// MockProfileUpdaterMockRecorder is the mock recorder for MockProfileUpdater.
+type MockProfileUpdaterMockRecorder struct {
+	mock *MockProfileUpdater
+}
+
+// NewMockProfileUpdater creates a new mock instance.
+func NewMockProfileUpdater(ctrl *gomock.Controller) *MockProfileUpdater {
+	mock := &MockProfileUpdater{ctrl: ctrl}
+	mock.recorder = &MockProfileUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProfileUpdater) EXPECT() *MockProfileUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdateProfile mocks base method.
+func (m *MockProfileUpdater) UpdateProfile(arg0 context.Context, arg1 string, arg2 models.ProfileForm, arg3 io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockProfileUpdaterMockRecorder) UpdateProfile(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockProfileUpdater)(nil).UpdateProfile), arg0, arg1, arg2, arg3)
+}
+
+// MockAvatarGetter is a mock of AvatarGetter interface.
+type MockAvatarGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAvatarGetterMockRecorder
+}
+
+// MockAvatarGetterMockRecorder is the mock recorder for MockAvatarGetter.
+type MockAvatarGetterMockRecorder struct {
+	mock *MockAvatarGetter
+}
+
+// NewMockAvatarGetter creates a new mock instance.
+func NewMockAvatarGetter(ctrl *gomock.Controller) *MockAvatarGetter {
+	mock := &MockAvatarGetter{ctrl: ctrl}
+	mock.recorder = &MockAvatarGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAvatarGetter) EXPECT() *MockAvatarGetterMockRecorder {
+	return m.recorder
+}
+
+// GetAvatar mocks base method.
+func (m *MockAvatarGetter) GetAvatar(arg0 context.Context, arg1 string) (models.Avatar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAvatar", arg0, arg1)
+	ret0, _ := ret[0].(models.Avatar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAvatar indicates an expected call of GetAvatar.
+func (mr *MockAvatarGetterMockRecorder) GetAvatar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAvatar", reflect.TypeOf((*MockAvatarGetter)(nil).GetAvatar), arg0, arg1)
+}
+
+// MockUserSearcher is a mock of UserSearcher interface.
+type MockUserSearcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserSearcherMockRecorder
+}
+
+// MockUserSearcherMockRecorder is the mock recorder for MockUserSearcher.
+type MockUserSearcherMockRecorder struct {
+	mock *MockUserSearcher
+}
+
+// NewMockUserSearcher creates a new mock instance.
+func NewMockUserSearcher(ctrl *gomock.Controller) *MockUserSearcher {
+	mock := &MockUserSearcher{ctrl: ctrl}
+	mock.recorder = &MockUserSearcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserSearcher) EXPECT() *MockUserSearcherMockRecorder {
+	return m.recorder
+}
+
+// SearchUsers mocks base method.
+func (m *MockUserSearcher) SearchUsers(arg0 context.Context, arg1 string, arg2, arg3 int64) ([]models.PublicProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]models.PublicProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUserSearcherMockRecorder) SearchUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUserSearcher)(nil).SearchUsers), arg0, arg1, arg2, arg3)
+}
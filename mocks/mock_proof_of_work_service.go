@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: ProofOfWorkIssuer,ProofOfWorkVerifier)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockProofOfWorkIssuer is a mock of ProofOfWorkIssuer interface.
+type MockProofOfWorkIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MockProofOfWorkIssuerMockRecorder
+}
+
+// MockProofOfWorkIssuerMockRecorder is the mock recorder for MockProofOfWorkIssuer.
+type MockProofOfWorkIssuerMockRecorder struct {
+	mock *MockProofOfWorkIssuer
+}
+
+// NewMockProofOfWorkIssuer creates a new mock instance.
+func NewMockProofOfWorkIssuer(ctrl *gomock.Controller) *MockProofOfWorkIssuer {
+	mock := &MockProofOfWorkIssuer{ctrl: ctrl}
+	mock.recorder = &MockProofOfWorkIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProofOfWorkIssuer) EXPECT() *MockProofOfWorkIssuerMockRecorder {
+	return m.recorder
+}
+
+// IssueChallenge mocks base method.
+func (m *MockProofOfWorkIssuer) IssueChallenge(arg0 context.Context, arg1 string) (models.Challenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueChallenge", arg0, arg1)
+	ret0, _ := ret[0].(models.Challenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueChallenge indicates an expected call of IssueChallenge.
+func (mr *MockProofOfWorkIssuerMockRecorder) IssueChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueChallenge", reflect.TypeOf((*MockProofOfWorkIssuer)(nil).IssueChallenge), arg0, arg1)
+}
+
+// MockProofOfWorkVerifier is a mock of ProofOfWorkVerifier interface.
+type MockProofOfWorkVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockProofOfWorkVerifierMockRecorder
+}
+
+// MockProofOfWorkVerifierMockRecorder is the mock recorder for MockProofOfWorkVerifier.
+type MockProofOfWorkVerifierMockRecorder struct {
+	mock *MockProofOfWorkVerifier
+}
+
+// NewMockProofOfWorkVerifier creates a new mock instance.
+func NewMockProofOfWorkVerifier(ctrl *gomock.Controller) *MockProofOfWorkVerifier {
+	mock := &MockProofOfWorkVerifier{ctrl: ctrl}
+	mock.recorder = &MockProofOfWorkVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProofOfWorkVerifier) EXPECT() *MockProofOfWorkVerifierMockRecorder {
+	return m.recorder
+}
+
+// ProofOfWorkRequired mocks base method.
+func (m *MockProofOfWorkVerifier) ProofOfWorkRequired() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProofOfWorkRequired")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ProofOfWorkRequired indicates an expected call of ProofOfWorkRequired.
+func (mr *MockProofOfWorkVerifierMockRecorder) ProofOfWorkRequired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProofOfWorkRequired", reflect.TypeOf((*MockProofOfWorkVerifier)(nil).ProofOfWorkRequired))
+}
+
+// VerifyProofOfWork mocks base method.
+func (m *MockProofOfWorkVerifier) VerifyProofOfWork(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyProofOfWork", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyProofOfWork indicates an expected call of VerifyProofOfWork.
+func (mr *MockProofOfWorkVerifierMockRecorder) VerifyProofOfWork(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyProofOfWork", reflect.TypeOf((*MockProofOfWorkVerifier)(nil).VerifyProofOfWork), arg0, arg1, arg2, arg3)
+}
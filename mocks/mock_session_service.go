@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: SessionFetcher,SessionCreator,SessionRevoker)
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: SessionFetcher,SessionCreator,SessionRevoker,SessionRenewer,SessionLister,ImpersonationFetcher,ImpersonationManager,ImpersonationExpirer)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -7,8 +7,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	models "github.com/aliparlakci/armut-backend-assessment/models"
 	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockSessionFetcher is a mock of SessionFetcher interface.
@@ -35,10 +38,10 @@ func (m *MockSessionFetcher) EXPECT() *MockSessionFetcherMockRecorder {
 }
 
 // FetchSession mocks base method.
-func (m *MockSessionFetcher) FetchSession(arg0 context.Context, arg1 string) (string, error) {
+func (m *MockSessionFetcher) FetchSession(arg0 context.Context, arg1 string) (primitive.ObjectID, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FetchSession", arg0, arg1)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(primitive.ObjectID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -73,18 +76,19 @@ func (m *MockSessionCreator) EXPECT() *MockSessionCreatorMockRecorder {
 }
 
 // CreateSession mocks base method.
-func (m *MockSessionCreator) CreateSession(arg0 context.Context, arg1 string) (string, error) {
+func (m *MockSessionCreator) CreateSession(arg0 context.Context, arg1, arg2, arg3 string) (string, time.Duration, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // CreateSession indicates an expected call of CreateSession.
-func (mr *MockSessionCreatorMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockSessionCreatorMockRecorder) CreateSession(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionCreator)(nil).CreateSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionCreator)(nil).CreateSession), arg0, arg1, arg2, arg3)
 }
 
 // MockSessionRevoker is a mock of SessionRevoker interface.
@@ -110,6 +114,20 @@ func (m *MockSessionRevoker) EXPECT() *MockSessionRevokerMockRecorder {
 	return m.recorder
 }
 
+// RevokeOtherSessions mocks base method.
+func (m *MockSessionRevoker) RevokeOtherSessions(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockSessionRevokerMockRecorder) RevokeOtherSessions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockSessionRevoker)(nil).RevokeOtherSessions), arg0, arg1, arg2)
+}
+
 // RevokeSession mocks base method.
 func (m *MockSessionRevoker) RevokeSession(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -123,3 +141,223 @@ func (mr *MockSessionRevokerMockRecorder) RevokeSession(arg0, arg1 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionRevoker)(nil).RevokeSession), arg0, arg1)
 }
+
+// RevokeUserSession mocks base method.
+func (m *MockSessionRevoker) RevokeUserSession(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSession indicates an expected call of RevokeUserSession.
+func (mr *MockSessionRevokerMockRecorder) RevokeUserSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSession", reflect.TypeOf((*MockSessionRevoker)(nil).RevokeUserSession), arg0, arg1, arg2)
+}
+
+// MockSessionRenewer is a mock of SessionRenewer interface.
+type MockSessionRenewer struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRenewerMockRecorder
+}
+
+// MockSessionRenewerMockRecorder is the mock recorder for MockSessionRenewer.
+type MockSessionRenewerMockRecorder struct {
+	mock *MockSessionRenewer
+}
+
+// NewMockSessionRenewer creates a new mock instance.
+func NewMockSessionRenewer(ctrl *gomock.Controller) *MockSessionRenewer {
+	mock := &MockSessionRenewer{ctrl: ctrl}
+	mock.recorder = &MockSessionRenewerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRenewer) EXPECT() *MockSessionRenewerMockRecorder {
+	return m.recorder
+}
+
+// RenewSession mocks base method.
+func (m *MockSessionRenewer) RenewSession(arg0 context.Context, arg1 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewSession", arg0, arg1)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewSession indicates an expected call of RenewSession.
+func (mr *MockSessionRenewerMockRecorder) RenewSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewSession", reflect.TypeOf((*MockSessionRenewer)(nil).RenewSession), arg0, arg1)
+}
+
+// MockSessionLister is a mock of SessionLister interface.
+type MockSessionLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionListerMockRecorder
+}
+
+// MockSessionListerMockRecorder is the mock recorder for MockSessionLister.
+type MockSessionListerMockRecorder struct {
+	mock *MockSessionLister
+}
+
+// NewMockSessionLister creates a new mock instance.
+func NewMockSessionLister(ctrl *gomock.Controller) *MockSessionLister {
+	mock := &MockSessionLister{ctrl: ctrl}
+	mock.recorder = &MockSessionListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionLister) EXPECT() *MockSessionListerMockRecorder {
+	return m.recorder
+}
+
+// ListSessions mocks base method.
+func (m *MockSessionLister) ListSessions(arg0 context.Context, arg1 string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", arg0, arg1)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockSessionListerMockRecorder) ListSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockSessionLister)(nil).ListSessions), arg0, arg1)
+}
+
+// MockImpersonationFetcher is a mock of ImpersonationFetcher interface.
+type MockImpersonationFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationFetcherMockRecorder
+}
+
+// MockImpersonationFetcherMockRecorder is the mock recorder for MockImpersonationFetcher.
+type MockImpersonationFetcherMockRecorder struct {
+	mock *MockImpersonationFetcher
+}
+
+// NewMockImpersonationFetcher creates a new mock instance.
+func NewMockImpersonationFetcher(ctrl *gomock.Controller) *MockImpersonationFetcher {
+	mock := &MockImpersonationFetcher{ctrl: ctrl}
+	mock.recorder = &MockImpersonationFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonationFetcher) EXPECT() *MockImpersonationFetcherMockRecorder {
+	return m.recorder
+}
+
+// FetchImpersonator mocks base method.
+func (m *MockImpersonationFetcher) FetchImpersonator(arg0 context.Context, arg1 string) (primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchImpersonator", arg0, arg1)
+	ret0, _ := ret[0].(primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchImpersonator indicates an expected call of FetchImpersonator.
+func (mr *MockImpersonationFetcherMockRecorder) FetchImpersonator(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchImpersonator", reflect.TypeOf((*MockImpersonationFetcher)(nil).FetchImpersonator), arg0, arg1)
+}
+
+// MockImpersonationManager is a mock of ImpersonationManager interface.
+type MockImpersonationManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationManagerMockRecorder
+}
+
+// MockImpersonationManagerMockRecorder is the mock recorder for MockImpersonationManager.
+type MockImpersonationManagerMockRecorder struct {
+	mock *MockImpersonationManager
+}
+
+// NewMockImpersonationManager creates a new mock instance.
+func NewMockImpersonationManager(ctrl *gomock.Controller) *MockImpersonationManager {
+	mock := &MockImpersonationManager{ctrl: ctrl}
+	mock.recorder = &MockImpersonationManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonationManager) EXPECT() *MockImpersonationManagerMockRecorder {
+	return m.recorder
+}
+
+// EndImpersonation mocks base method.
+func (m *MockImpersonationManager) EndImpersonation(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndImpersonation", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndImpersonation indicates an expected call of EndImpersonation.
+func (mr *MockImpersonationManagerMockRecorder) EndImpersonation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndImpersonation", reflect.TypeOf((*MockImpersonationManager)(nil).EndImpersonation), arg0, arg1)
+}
+
+// StartImpersonation mocks base method.
+func (m *MockImpersonationManager) StartImpersonation(arg0 context.Context, arg1 string, arg2 primitive.ObjectID, arg3, arg4, arg5 string) (string, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImpersonation", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartImpersonation indicates an expected call of StartImpersonation.
+func (mr *MockImpersonationManagerMockRecorder) StartImpersonation(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImpersonation", reflect.TypeOf((*MockImpersonationManager)(nil).StartImpersonation), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// MockImpersonationExpirer is a mock of ImpersonationExpirer interface.
+type MockImpersonationExpirer struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationExpirerMockRecorder
+}
+
+// MockImpersonationExpirerMockRecorder is the mock recorder for MockImpersonationExpirer.
+type MockImpersonationExpirerMockRecorder struct {
+	mock *MockImpersonationExpirer
+}
+
+// NewMockImpersonationExpirer creates a new mock instance.
+func NewMockImpersonationExpirer(ctrl *gomock.Controller) *MockImpersonationExpirer {
+	mock := &MockImpersonationExpirer{ctrl: ctrl}
+	mock.recorder = &MockImpersonationExpirerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonationExpirer) EXPECT() *MockImpersonationExpirerMockRecorder {
+	return m.recorder
+}
+
+// ExpireImpersonations mocks base method.
+func (m *MockImpersonationExpirer) ExpireImpersonations(arg0 context.Context) ([]models.Impersonation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireImpersonations", arg0)
+	ret0, _ := ret[0].([]models.Impersonation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireImpersonations indicates an expected call of ExpireImpersonations.
+func (mr *MockImpersonationExpirerMockRecorder) ExpireImpersonations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireImpersonations", reflect.TypeOf((*MockImpersonationExpirer)(nil).ExpireImpersonations), arg0)
+}
@@ -0,0 +1,143 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: TokenIssuer,AccessTokenVerifier,TokenRevoker)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockTokenIssuer is a mock of TokenIssuer interface.
+type MockTokenIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenIssuerMockRecorder
+}
+
+// MockTokenIssuerMockRecorder is the mock recorder for MockTokenIssuer.
+type MockTokenIssuerMockRecorder struct {
+	mock *MockTokenIssuer
+}
+
+// NewMockTokenIssuer creates a new mock instance.
+func NewMockTokenIssuer(ctrl *gomock.Controller) *MockTokenIssuer {
+	mock := &MockTokenIssuer{ctrl: ctrl}
+	mock.recorder = &MockTokenIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenIssuer) EXPECT() *MockTokenIssuerMockRecorder {
+	return m.recorder
+}
+
+// IssueTokens mocks base method.
+func (m *MockTokenIssuer) IssueTokens(arg0 context.Context, arg1 string) (models.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", arg0, arg1)
+	ret0, _ := ret[0].(models.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockTokenIssuerMockRecorder) IssueTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockTokenIssuer)(nil).IssueTokens), arg0, arg1)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockTokenIssuer) RefreshTokens(arg0 context.Context, arg1 string) (models.TokenPair, primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", arg0, arg1)
+	ret0, _ := ret[0].(models.TokenPair)
+	ret1, _ := ret[1].(primitive.ObjectID)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockTokenIssuerMockRecorder) RefreshTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockTokenIssuer)(nil).RefreshTokens), arg0, arg1)
+}
+
+// MockAccessTokenVerifier is a mock of AccessTokenVerifier interface.
+type MockAccessTokenVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessTokenVerifierMockRecorder
+}
+
+// MockAccessTokenVerifierMockRecorder is the mock recorder for MockAccessTokenVerifier.
+type MockAccessTokenVerifierMockRecorder struct {
+	mock *MockAccessTokenVerifier
+}
+
+// NewMockAccessTokenVerifier creates a new mock instance.
+func NewMockAccessTokenVerifier(ctrl *gomock.Controller) *MockAccessTokenVerifier {
+	mock := &MockAccessTokenVerifier{ctrl: ctrl}
+	mock.recorder = &MockAccessTokenVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessTokenVerifier) EXPECT() *MockAccessTokenVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyAccessToken mocks base method.
+func (m *MockAccessTokenVerifier) VerifyAccessToken(arg0 context.Context, arg1 string) (primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAccessToken indicates an expected call of VerifyAccessToken.
+func (mr *MockAccessTokenVerifierMockRecorder) VerifyAccessToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAccessToken", reflect.TypeOf((*MockAccessTokenVerifier)(nil).VerifyAccessToken), arg0, arg1)
+}
+
+// MockTokenRevoker is a mock of TokenRevoker interface.
+type MockTokenRevoker struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRevokerMockRecorder
+}
+
+// MockTokenRevokerMockRecorder is the mock recorder for MockTokenRevoker.
+type MockTokenRevokerMockRecorder struct {
+	mock *MockTokenRevoker
+}
+
+// NewMockTokenRevoker creates a new mock instance.
+func NewMockTokenRevoker(ctrl *gomock.Controller) *MockTokenRevoker {
+	mock := &MockTokenRevoker{ctrl: ctrl}
+	mock.recorder = &MockTokenRevokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRevoker) EXPECT() *MockTokenRevokerMockRecorder {
+	return m.recorder
+}
+
+// RevokeUserTokens mocks base method.
+func (m *MockTokenRevoker) RevokeUserTokens(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserTokens indicates an expected call of RevokeUserTokens.
+func (mr *MockTokenRevokerMockRecorder) RevokeUserTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserTokens", reflect.TypeOf((*MockTokenRevoker)(nil).RevokeUserTokens), arg0, arg1)
+}
@@ -0,0 +1,194 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: TwoFactorEnroller,TwoFactorVerifier,PendingSigninCreator,PendingSigninCompleter)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliparlakci/armut-backend-assessment/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTwoFactorEnroller is a mock of TwoFactorEnroller interface.
+type MockTwoFactorEnroller struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorEnrollerMockRecorder
+}
+
+// MockTwoFactorEnrollerMockRecorder is the mock recorder for MockTwoFactorEnroller.
+type MockTwoFactorEnrollerMockRecorder struct {
+	mock *MockTwoFactorEnroller
+}
+
+// NewMockTwoFactorEnroller creates a new mock instance.
+func NewMockTwoFactorEnroller(ctrl *gomock.Controller) *MockTwoFactorEnroller {
+	mock := &MockTwoFactorEnroller{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorEnrollerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorEnroller) EXPECT() *MockTwoFactorEnrollerMockRecorder {
+	return m.recorder
+}
+
+// BeginEnrollment mocks base method.
+func (m *MockTwoFactorEnroller) BeginEnrollment(arg0 context.Context, arg1 string) (models.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginEnrollment", arg0, arg1)
+	ret0, _ := ret[0].(models.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginEnrollment indicates an expected call of BeginEnrollment.
+func (mr *MockTwoFactorEnrollerMockRecorder) BeginEnrollment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginEnrollment", reflect.TypeOf((*MockTwoFactorEnroller)(nil).BeginEnrollment), arg0, arg1)
+}
+
+// ConfirmEnrollment mocks base method.
+func (m *MockTwoFactorEnroller) ConfirmEnrollment(arg0 context.Context, arg1, arg2 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEnrollment", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmEnrollment indicates an expected call of ConfirmEnrollment.
+func (mr *MockTwoFactorEnrollerMockRecorder) ConfirmEnrollment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEnrollment", reflect.TypeOf((*MockTwoFactorEnroller)(nil).ConfirmEnrollment), arg0, arg1, arg2)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockTwoFactorEnroller) DisableTwoFactor(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockTwoFactorEnrollerMockRecorder) DisableTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockTwoFactorEnroller)(nil).DisableTwoFactor), arg0, arg1, arg2)
+}
+
+// MockTwoFactorVerifier is a mock of TwoFactorVerifier interface.
+type MockTwoFactorVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorVerifierMockRecorder
+}
+
+// MockTwoFactorVerifierMockRecorder is the mock recorder for MockTwoFactorVerifier.
+type MockTwoFactorVerifierMockRecorder struct {
+	mock *MockTwoFactorVerifier
+}
+
+// NewMockTwoFactorVerifier creates a new mock instance.
+func NewMockTwoFactorVerifier(ctrl *gomock.Controller) *MockTwoFactorVerifier {
+	mock := &MockTwoFactorVerifier{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorVerifier) EXPECT() *MockTwoFactorVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyTwoFactor mocks base method.
+func (m *MockTwoFactorVerifier) VerifyTwoFactor(arg0 context.Context, arg1, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyTwoFactor indicates an expected call of VerifyTwoFactor.
+func (mr *MockTwoFactorVerifierMockRecorder) VerifyTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTwoFactor", reflect.TypeOf((*MockTwoFactorVerifier)(nil).VerifyTwoFactor), arg0, arg1, arg2)
+}
+
+// MockPendingSigninCreator is a mock of PendingSigninCreator interface.
+type MockPendingSigninCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockPendingSigninCreatorMockRecorder
+}
+
+// MockPendingSigninCreatorMockRecorder is the mock recorder for MockPendingSigninCreator.
+type MockPendingSigninCreatorMockRecorder struct {
+	mock *MockPendingSigninCreator
+}
+
+// NewMockPendingSigninCreator creates a new mock instance.
+func NewMockPendingSigninCreator(ctrl *gomock.Controller) *MockPendingSigninCreator {
+	mock := &MockPendingSigninCreator{ctrl: ctrl}
+	mock.recorder = &MockPendingSigninCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPendingSigninCreator) EXPECT() *MockPendingSigninCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreatePendingSignin mocks base method.
+func (m *MockPendingSigninCreator) CreatePendingSignin(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePendingSignin", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePendingSignin indicates an expected call of CreatePendingSignin.
+func (mr *MockPendingSigninCreatorMockRecorder) CreatePendingSignin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingSignin", reflect.TypeOf((*MockPendingSigninCreator)(nil).CreatePendingSignin), arg0, arg1)
+}
+
+// MockPendingSigninCompleter is a mock of PendingSigninCompleter interface.
+type MockPendingSigninCompleter struct {
+	ctrl     *gomock.Controller
+	recorder *MockPendingSigninCompleterMockRecorder
+}
+
+// MockPendingSigninCompleterMockRecorder is the mock recorder for MockPendingSigninCompleter.
+type MockPendingSigninCompleterMockRecorder struct {
+	mock *MockPendingSigninCompleter
+}
+
+// NewMockPendingSigninCompleter creates a new mock instance.
+func NewMockPendingSigninCompleter(ctrl *gomock.Controller) *MockPendingSigninCompleter {
+	mock := &MockPendingSigninCompleter{ctrl: ctrl}
+	mock.recorder = &MockPendingSigninCompleterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPendingSigninCompleter) EXPECT() *MockPendingSigninCompleterMockRecorder {
+	return m.recorder
+}
+
+// CompletePendingSignin mocks base method.
+func (m *MockPendingSigninCompleter) CompletePendingSignin(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompletePendingSignin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompletePendingSignin indicates an expected call of CompletePendingSignin.
+func (mr *MockPendingSigninCompleterMockRecorder) CompletePendingSignin(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletePendingSignin", reflect.TypeOf((*MockPendingSigninCompleter)(nil).CompletePendingSignin), arg0, arg1, arg2)
+}
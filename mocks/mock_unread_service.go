@@ -0,0 +1,136 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: UnreadCounter)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUnreadCounter is a mock of UnreadCounter interface.
+type MockUnreadCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnreadCounterMockRecorder
+}
+
+// MockUnreadCounterMockRecorder is the mock recorder for MockUnreadCounter.
+type MockUnreadCounterMockRecorder struct {
+	mock *MockUnreadCounter
+}
+
+// NewMockUnreadCounter creates a new mock instance.
+func NewMockUnreadCounter(ctrl *gomock.Controller) *MockUnreadCounter {
+	mock := &MockUnreadCounter{ctrl: ctrl}
+	mock.recorder = &MockUnreadCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnreadCounter) EXPECT() *MockUnreadCounterMockRecorder {
+	return m.recorder
+}
+
+// CountedReceivers mocks base method.
+func (m *MockUnreadCounter) CountedReceivers(arg0 context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountedReceivers", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountedReceivers indicates an expected call of CountedReceivers.
+func (mr *MockUnreadCounterMockRecorder) CountedReceivers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountedReceivers", reflect.TypeOf((*MockUnreadCounter)(nil).CountedReceivers), arg0)
+}
+
+// DecrementUnread mocks base method.
+func (m *MockUnreadCounter) DecrementUnread(arg0 context.Context, arg1, arg2 string, arg3 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementUnread", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementUnread indicates an expected call of DecrementUnread.
+func (mr *MockUnreadCounterMockRecorder) DecrementUnread(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementUnread", reflect.TypeOf((*MockUnreadCounter)(nil).DecrementUnread), arg0, arg1, arg2, arg3)
+}
+
+// DeleteUnread mocks base method.
+func (m *MockUnreadCounter) DeleteUnread(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnread", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnread indicates an expected call of DeleteUnread.
+func (mr *MockUnreadCounterMockRecorder) DeleteUnread(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnread", reflect.TypeOf((*MockUnreadCounter)(nil).DeleteUnread), arg0, arg1)
+}
+
+// IncrementUnread mocks base method.
+func (m *MockUnreadCounter) IncrementUnread(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementUnread", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementUnread indicates an expected call of IncrementUnread.
+func (mr *MockUnreadCounterMockRecorder) IncrementUnread(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementUnread", reflect.TypeOf((*MockUnreadCounter)(nil).IncrementUnread), arg0, arg1, arg2)
+}
+
+// SetUnread mocks base method.
+func (m *MockUnreadCounter) SetUnread(arg0 context.Context, arg1 string, arg2 map[string]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUnread", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUnread indicates an expected call of SetUnread.
+func (mr *MockUnreadCounterMockRecorder) SetUnread(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUnread", reflect.TypeOf((*MockUnreadCounter)(nil).SetUnread), arg0, arg1, arg2)
+}
+
+// UnreadCount mocks base method.
+func (m *MockUnreadCounter) UnreadCount(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadCount", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnreadCount indicates an expected call of UnreadCount.
+func (mr *MockUnreadCounterMockRecorder) UnreadCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadCount", reflect.TypeOf((*MockUnreadCounter)(nil).UnreadCount), arg0, arg1)
+}
+
+// UnreadCountFrom mocks base method.
+func (m *MockUnreadCounter) UnreadCountFrom(arg0 context.Context, arg1, arg2 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadCountFrom", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnreadCountFrom indicates an expected call of UnreadCountFrom.
+func (mr *MockUnreadCounterMockRecorder) UnreadCountFrom(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadCountFrom", reflect.TypeOf((*MockUnreadCounter)(nil).UnreadCountFrom), arg0, arg1, arg2)
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: UserGetter,UserCreator)
+// Source: github.com/aliparlakci/armut-backend-assessment/services (interfaces: UserGetter,UserCreator,UserUpdater,UserLister,UserStatusUpdater,AccountDeactivator,UserRenamer,SettingsUpdater,IdentityLinker)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -10,6 +10,7 @@ import (
 
 	models "github.com/aliparlakci/armut-backend-assessment/models"
 	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockUserGetter is a mock of UserGetter interface.
@@ -50,6 +51,21 @@ func (mr *MockUserGetterMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserGetter)(nil).GetUser), arg0, arg1)
 }
 
+// GetUserByID mocks base method.
+func (m *MockUserGetter) GetUserByID(arg0 context.Context, arg1 primitive.ObjectID) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0, arg1)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockUserGetterMockRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserGetter)(nil).GetUserByID), arg0, arg1)
+}
+
 // UserExists mocks base method.
 func (m *MockUserGetter) UserExists(arg0 context.Context, arg1 string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -89,16 +105,335 @@ func (m *MockUserCreator) EXPECT() *MockUserCreatorMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockUserCreator) CreateUser(arg0 context.Context, arg1, arg2 string) (string, error) {
+func (m *MockUserCreator) CreateUser(arg0 context.Context, arg1, arg2, arg3 string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserCreatorMockRecorder) CreateUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockUserCreatorMockRecorder) CreateUser(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserCreator)(nil).CreateUser), arg0, arg1, arg2, arg3)
+}
+
+// MockUserUpdater is a mock of UserUpdater interface.
+type MockUserUpdater struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserUpdaterMockRecorder
+}
+
+// MockUserUpdaterMockRecorder is the mock recorder for MockUserUpdater.
+type MockUserUpdaterMockRecorder struct {
+	mock *MockUserUpdater
+}
+
+// NewMockUserUpdater creates a new mock instance.
+func NewMockUserUpdater(ctrl *gomock.Controller) *MockUserUpdater {
+	mock := &MockUserUpdater{ctrl: ctrl}
+	mock.recorder = &MockUserUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserUpdater) EXPECT() *MockUserUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserUpdater) UpdatePassword(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserUpdaterMockRecorder) UpdatePassword(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserUpdater)(nil).UpdatePassword), arg0, arg1, arg2)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUserUpdater) VerifyEmail(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserUpdaterMockRecorder) VerifyEmail(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserUpdater)(nil).VerifyEmail), arg0, arg1, arg2)
+}
+
+// MockUserLister is a mock of UserLister interface.
+type MockUserLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserListerMockRecorder
+}
+
+// MockUserListerMockRecorder is the mock recorder for MockUserLister.
+type MockUserListerMockRecorder struct {
+	mock *MockUserLister
+}
+
+// NewMockUserLister creates a new mock instance.
+func NewMockUserLister(ctrl *gomock.Controller) *MockUserLister {
+	mock := &MockUserLister{ctrl: ctrl}
+	mock.recorder = &MockUserListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserLister) EXPECT() *MockUserListerMockRecorder {
+	return m.recorder
+}
+
+// ListUsers mocks base method.
+func (m *MockUserLister) ListUsers(arg0 context.Context, arg1, arg2 int64) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserListerMockRecorder) ListUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserLister)(nil).ListUsers), arg0, arg1, arg2)
+}
+
+// MockUserStatusUpdater is a mock of UserStatusUpdater interface.
+type MockUserStatusUpdater struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStatusUpdaterMockRecorder
+}
+
+// MockUserStatusUpdaterMockRecorder is the mock recorder for MockUserStatusUpdater.
+type MockUserStatusUpdaterMockRecorder struct {
+	mock *MockUserStatusUpdater
+}
+
+// NewMockUserStatusUpdater creates a new mock instance.
+func NewMockUserStatusUpdater(ctrl *gomock.Controller) *MockUserStatusUpdater {
+	mock := &MockUserStatusUpdater{ctrl: ctrl}
+	mock.recorder = &MockUserStatusUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStatusUpdater) EXPECT() *MockUserStatusUpdaterMockRecorder {
+	return m.recorder
+}
+
+// SetStatus mocks base method.
+func (m *MockUserStatusUpdater) SetStatus(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockUserStatusUpdaterMockRecorder) SetStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockUserStatusUpdater)(nil).SetStatus), arg0, arg1, arg2, arg3)
+}
+
+// MockAccountDeactivator is a mock of AccountDeactivator interface.
+type MockAccountDeactivator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountDeactivatorMockRecorder
+}
+
+// MockAccountDeactivatorMockRecorder is the mock recorder for MockAccountDeactivator.
+type MockAccountDeactivatorMockRecorder struct {
+	mock *MockAccountDeactivator
+}
+
+// NewMockAccountDeactivator creates a new mock instance.
+func NewMockAccountDeactivator(ctrl *gomock.Controller) *MockAccountDeactivator {
+	mock := &MockAccountDeactivator{ctrl: ctrl}
+	mock.recorder = &MockAccountDeactivatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountDeactivator) EXPECT() *MockAccountDeactivatorMockRecorder {
+	return m.recorder
+}
+
+// Deactivate mocks base method.
+func (m *MockAccountDeactivator) Deactivate(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deactivate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Deactivate indicates an expected call of Deactivate.
+func (mr *MockAccountDeactivatorMockRecorder) Deactivate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deactivate", reflect.TypeOf((*MockAccountDeactivator)(nil).Deactivate), arg0, arg1, arg2)
+}
+
+// Reactivate mocks base method.
+func (m *MockAccountDeactivator) Reactivate(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reactivate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reactivate indicates an expected call of Reactivate.
+func (mr *MockAccountDeactivatorMockRecorder) Reactivate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reactivate", reflect.TypeOf((*MockAccountDeactivator)(nil).Reactivate), arg0, arg1)
+}
+
+// MockUserRenamer is a mock of UserRenamer interface.
+type MockUserRenamer struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserRenamerMockRecorder
+}
+
+// MockUserRenamerMockRecorder is the mock recorder for MockUserRenamer.
+type MockUserRenamerMockRecorder struct {
+	mock *MockUserRenamer
+}
+
+// NewMockUserRenamer creates a new mock instance.
+func NewMockUserRenamer(ctrl *gomock.Controller) *MockUserRenamer {
+	mock := &MockUserRenamer{ctrl: ctrl}
+	mock.recorder = &MockUserRenamerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserRenamer) EXPECT() *MockUserRenamerMockRecorder {
+	return m.recorder
+}
+
+// ChangeUsername mocks base method.
+func (m *MockUserRenamer) ChangeUsername(arg0 context.Context, arg1 primitive.ObjectID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUsername", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeUsername indicates an expected call of ChangeUsername.
+func (mr *MockUserRenamerMockRecorder) ChangeUsername(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUsername", reflect.TypeOf((*MockUserRenamer)(nil).ChangeUsername), arg0, arg1, arg2)
+}
+
+// MockSettingsUpdater is a mock of SettingsUpdater interface.
+type MockSettingsUpdater struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettingsUpdaterMockRecorder
+}
+
+// MockSettingsUpdaterMockRecorder is the mock recorder for MockSettingsUpdater.
+type MockSettingsUpdaterMockRecorder struct {
+	mock *MockSettingsUpdater
+}
+
+// NewMockSettingsUpdater creates a new mock instance.
+func NewMockSettingsUpdater(ctrl *gomock.Controller) *MockSettingsUpdater {
+	mock := &MockSettingsUpdater{ctrl: ctrl}
+	mock.recorder = &MockSettingsUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettingsUpdater) EXPECT() *MockSettingsUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdateSettings mocks base method.
+func (m *MockSettingsUpdater) UpdateSettings(arg0 context.Context, arg1 string, arg2 models.SettingsForm) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockSettingsUpdaterMockRecorder) UpdateSettings(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockSettingsUpdater)(nil).UpdateSettings), arg0, arg1, arg2)
+}
+
+// MockIdentityLinker is a mock of IdentityLinker interface.
+type MockIdentityLinker struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityLinkerMockRecorder
+}
+
+// MockIdentityLinkerMockRecorder is the mock recorder for MockIdentityLinker.
+type MockIdentityLinkerMockRecorder struct {
+	mock *MockIdentityLinker
+}
+
+// NewMockIdentityLinker creates a new mock instance.
+func NewMockIdentityLinker(ctrl *gomock.Controller) *MockIdentityLinker {
+	mock := &MockIdentityLinker{ctrl: ctrl}
+	mock.recorder = &MockIdentityLinkerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityLinker) EXPECT() *MockIdentityLinkerMockRecorder {
+	return m.recorder
+}
+
+// GetUserByIdentity mocks base method.
+func (m *MockIdentityLinker) GetUserByIdentity(arg0 context.Context, arg1, arg2 string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdentity", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByIdentity indicates an expected call of GetUserByIdentity.
+func (mr *MockIdentityLinkerMockRecorder) GetUserByIdentity(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdentity", reflect.TypeOf((*MockIdentityLinker)(nil).GetUserByIdentity), arg0, arg1, arg2)
+}
+
+// LinkIdentity mocks base method.
+func (m *MockIdentityLinker) LinkIdentity(arg0 context.Context, arg1 string, arg2 models.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockIdentityLinkerMockRecorder) LinkIdentity(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockIdentityLinker)(nil).LinkIdentity), arg0, arg1, arg2)
+}
+
+// ProvisionUser mocks base method.
+func (m *MockIdentityLinker) ProvisionUser(arg0 context.Context, arg1 string, arg2 models.Identity) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvisionUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProvisionUser indicates an expected call of ProvisionUser.
+func (mr *MockIdentityLinkerMockRecorder) ProvisionUser(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserCreator)(nil).CreateUser), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionUser", reflect.TypeOf((*MockIdentityLinker)(nil).ProvisionUser), arg0, arg1, arg2)
 }
@@ -5,10 +5,16 @@ import (
 	"time"
 )
 
+// Activity references its user by their id. Username is filled in with the current username when activity is
+// fetched, and is only stored for the failed sign-ins of users who do not exist.
 type Activity struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Event    string             `bson:"event" json:"event"`
-	Username string             `bson:"username" json:"username"`
+	UserID   primitive.ObjectID `bson:"user_id,omitempty" json:"-"`
+	Username string             `bson:"username,omitempty" json:"username"`
 	IP       string             `bson:"ip" json:"ip"`
 	When     time.Time          `bson:"when" json:"when"`
+	// ImpersonatorID is the admin who has started or ended an impersonation, in the activity of both the admin
+	// and the user they have impersonated.
+	ImpersonatorID *primitive.ObjectID `bson:"impersonator_id,omitempty" json:"impersonator_id,omitempty"`
 }
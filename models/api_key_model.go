@@ -0,0 +1,22 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type APIKey struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	Name      string             `bson:"name" json:"name"`
+	Prefix    string             `bson:"prefix" json:"prefix"`
+	Hash      string             `bson:"hash" json:"-"`
+	Scopes    []string           `bson:"scopes" json:"scopes"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	LastUsed  time.Time          `bson:"last_used,omitempty" json:"last_used"`
+}
+
+type NewAPIKey struct {
+	Name   string   `form:"name" binding:"required"`
+	Scopes []string `form:"scopes" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// AuditEntry records an action an admin has taken.
+type AuditEntry struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Actor  string             `bson:"actor" json:"actor"`
+	Action string             `bson:"action" json:"action"`
+	Target string             `bson:"target,omitempty" json:"target,omitempty"`
+	Status int                `bson:"status" json:"status"`
+	IP     string             `bson:"ip" json:"ip"`
+	When   time.Time          `bson:"when" json:"when"`
+	// OnBehalfOf is the user the actor has been impersonating.
+	OnBehalfOf string `bson:"on_behalf_of,omitempty" json:"on_behalf_of,omitempty"`
+}
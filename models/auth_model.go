@@ -3,4 +3,61 @@ package models
 type AuthForm struct {
 	Username string `form:"username" binding:"required"`
 	Password string	`form:"password" binding:"required"`
+	// Email is only used, and required, on signup
+	Email string `form:"email" binding:"omitempty,email"`
+	// InviteCode is only used on signup, and is required when signups are invite only
+	InviteCode string `form:"invite_code"`
+}
+
+type PasswordChangeForm struct {
+	CurrentPassword string `form:"current_password" binding:"required"`
+	NewPassword     string `form:"new_password" binding:"required"`
+}
+
+type PasswordResetRequestForm struct {
+	Username string `form:"username" binding:"required"`
+}
+
+type PasswordResetForm struct {
+	Token       string `form:"token" binding:"required"`
+	NewPassword string `form:"new_password" binding:"required"`
+}
+
+type TwoFactorForm struct {
+	Code string `form:"code" binding:"required"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TokenForm is an OAuth 2.0 style token request, with either the "password" or the "refresh_token" grant type.
+type TokenForm struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Username     string `form:"username"`
+	Password     string `form:"password"`
+	Code         string `form:"code"`
+	RefreshToken string `form:"refresh_token"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// OIDCLogin is the outcome of a login at an OpenID Connect identity provider.
+type OIDCLogin struct {
+	Identity          Identity
+	PreferredUsername string
+	// LinkTo is the user who has started the login to link the identity to their account, if any.
+	LinkTo string
+	// Reauthenticate is the id of the user who has started the login to confirm their identity, if any.
+	Reauthenticate string
+}
+
+type EmailVerificationForm struct {
+	Token string `form:"token" binding:"required"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Challenge is solved by finding a solution for which the SHA-256 hash of "<challenge>:<solution>"
+// starts with Difficulty zero bits.
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+)
+
+// Export is an archive of the personal data of a user. It is referenced by the hash of its download token,
+// and is removed once ExpiresAt has passed. ArchiveID references the archive in GridFS once the export is ready.
+type Export struct {
+	ID          string             `bson:"_id" json:"-"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"-"`
+	Status      string             `bson:"status" json:"status"`
+	RequestedAt time.Time          `bson:"requested_at" json:"requested_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+	ArchiveID   primitive.ObjectID `bson:"archive_id,omitempty" json:"-"`
+}
+
+// ExportProfile is what the user has told about themselves, as included in their data export.
+type ExportProfile struct {
+	ID               string           `json:"id"`
+	Username         string           `json:"username"`
+	UsernameHistory  []UsernameChange `json:"username_history"`
+	Email            string           `json:"email"`
+	EmailVerified    bool             `json:"email_verified"`
+	DisplayName      string           `json:"display_name"`
+	Bio              string           `json:"bio"`
+	Private          bool             `json:"private"`
+	HidePresence     bool             `json:"hide_presence"`
+	WhoCanMessage    string           `json:"who_can_message"`
+	SendReadReceipts bool             `json:"send_read_receipts"`
+	Roles            []string         `json:"roles"`
+	Status           string           `json:"status"`
+	Invite           string           `json:"invite,omitempty"`
+	TwoFactorEnabled bool             `json:"two_factor_enabled"`
+	Identities       []string         `json:"identities"`
+}
+
+func (u User) ExportProfile() ExportProfile {
+	history := u.UsernameHistory
+	if history == nil {
+		history = []UsernameChange{}
+	}
+	roles := u.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	issuers := make([]string, len(u.Identities))
+	for i, identity := range u.Identities {
+		issuers[i] = identity.Issuer
+	}
+
+	return ExportProfile{
+		ID:               u.UserID.Hex(),
+		Username:         u.Username,
+		UsernameHistory:  history,
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		DisplayName:      u.Profile.DisplayName,
+		Bio:              u.Profile.Bio,
+		Private:          u.Profile.Private,
+		HidePresence:     u.Profile.HidePresence,
+		WhoCanMessage:    u.Settings.MessagesFrom(),
+		SendReadReceipts: !u.Settings.HideReadReceipts,
+		Roles:            roles,
+		Status:           u.AccountStatus(),
+		Invite:           u.Invite,
+		TwoFactorEnabled: u.TwoFactorEnabled(),
+		Identities:       issuers,
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Invite lets up to MaxUses users sign up with its code until it expires.
+type Invite struct {
+	Code      string             `bson:"_id" json:"code"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"-"`
+	MaxUses   int                `bson:"max_uses" json:"max_uses"`
+	Uses      int                `bson:"uses" json:"uses"`
+	ExpiresAt *time.Time         `bson:"expires_at,omitempty" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// InviteList is what users can see of the invites they have created.
+type InviteList struct {
+	// Remaining is the number of invites the user can still create.
+	Remaining int      `json:"remaining"`
+	Invites   []Invite `json:"invites"`
+}
+
+type NewInvite struct {
+	MaxUses int `form:"max_uses" binding:"omitempty,min=1"`
+	// ExpiresIn is a duration such as "72h". Invites without it do not expire.
+	ExpiresIn string `form:"expires_in"`
+}
+
+type InviteQuotaForm struct {
+	Quota *int `form:"quota" binding:"required,min=0"`
+}
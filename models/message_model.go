@@ -5,13 +5,21 @@ import (
 	"time"
 )
 
+// Message references its users by their ids. To and From are filled in with their current usernames when messages
+// are fetched, and are only stored for the messages of users who no longer exist.
 type Message struct {
 	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	To     string             `bson:"to" json:"to"`
-	From   string             `bson:"from" json:"from"`
+	ToID   primitive.ObjectID `bson:"to_id,omitempty" json:"-"`
+	FromID primitive.ObjectID `bson:"from_id,omitempty" json:"-"`
+	To     string             `bson:"to,omitempty" json:"to"`
+	From   string             `bson:"from,omitempty" json:"from"`
 	Body   string             `bson:"body" json:"body"`
 	SendAt time.Time          `bson:"send_at" json:"send_at"`
 	IsRead bool               `bson:"is_read" json:"is_read"`
+	// ReadAt is not set for the messages which have been read before it was recorded.
+	ReadAt *time.Time `bson:"read_at,omitempty" json:"read_at,omitempty"`
+
+	Sender *SenderInfo `bson:"-" json:"sender,omitempty"`
 }
 
 type NewMessage struct {
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+type Presence struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	// LastSeen is nil if the user has not been seen for a long time, or hides their presence.
+	LastSeen *time.Time `json:"last_seen"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// Profile is the part of User which the user presents to others. Private and HidePresence are changed along with
+// the other privacy settings, in SettingsForm.
+type Profile struct {
+	DisplayName string `bson:"display_name,omitempty"`
+	Bio         string `bson:"bio,omitempty"`
+	// AvatarVersion changes with every avatar upload, so that the avatar urls can be cached forever.
+	AvatarVersion string `bson:"avatar_version,omitempty"`
+	// Private users are left out of the user directory, and their presence is hidden.
+	// They can still be found by their exact username.
+	Private bool `bson:"private,omitempty"`
+	// HidePresence users always appear offline, without a last seen time.
+	HidePresence bool `bson:"hide_presence,omitempty"`
+}
+
+// PresenceHidden reports whether the presence of the user must not be recorded, so that they appear offline.
+func (p Profile) PresenceHidden() bool {
+	return p.HidePresence || p.Private
+}
+
+// Avatar is the thumbnail of a user, with the version of the avatar and whether the profile of the user is private.
+type Avatar struct {
+	Image   []byte
+	Version string
+	Private bool
+}
+
+type PublicProfile struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// SenderInfo is included in messages, so that clients do not need to look up the profile of every sender.
+type SenderInfo struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// ProfileForm only updates the fields which are sent. The avatar is uploaded as the "avatar" file,
+// unless RemoveAvatar is set.
+type ProfileForm struct {
+	DisplayName  *string `form:"display_name"`
+	Bio          *string `form:"bio"`
+	RemoveAvatar bool    `form:"remove_avatar"`
+}
+
+func (p PublicProfile) SenderInfo() SenderInfo {
+	return SenderInfo{Username: p.Username, DisplayName: p.DisplayName, AvatarURL: p.AvatarURL}
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type Session struct {
+	ID           string    `json:"id"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastSeen     time.Time `json:"last_seen"`
+	Current      bool      `json:"current"`
+	Impersonated bool      `json:"impersonated,omitempty"`
+}
+
+// Impersonation is an impersonation session which has ended by timing out, rather than by the impersonator.
+type Impersonation struct {
+	UserID         primitive.ObjectID
+	ImpersonatorID primitive.ObjectID
+	EndedAt        time.Time
+}
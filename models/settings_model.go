@@ -0,0 +1,31 @@
+package models
+
+const (
+	MessagesFromEveryone = "everyone"
+	// MessagesFromContacts only accepts messages from the users who have been sent a message by the user before.
+	MessagesFromContacts = "contacts"
+	MessagesFromNobody   = "nobody"
+)
+
+// Settings are the privacy settings of the user. Their zero value is the default,
+// which accepts messages from everyone and sends read receipts.
+type Settings struct {
+	WhoCanMessage string `bson:"who_can_message,omitempty"`
+	// HideReadReceipts makes the messages the user has read appear unread to their senders.
+	HideReadReceipts bool `bson:"hide_read_receipts,omitempty"`
+}
+
+// SettingsForm only updates the fields which are sent. Private and HidePresence are kept on the profile.
+type SettingsForm struct {
+	WhoCanMessage    *string `form:"who_can_message"`
+	SendReadReceipts *bool   `form:"send_read_receipts"`
+	Private          *bool   `form:"private"`
+	HidePresence     *bool   `form:"hide_presence"`
+}
+
+func (s Settings) MessagesFrom() string {
+	if s.WhoCanMessage == "" {
+		return MessagesFromEveryone
+	}
+	return s.WhoCanMessage
+}
@@ -1,9 +1,148 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
 
+// Users who are not active cannot sign in, and their existing sessions and tokens are not accepted.
+// Deactivated users cannot receive messages either.
+const (
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusDeactivated = "deactivated"
+)
+
+// User is referenced by its UserID everywhere else, so that the username can change.
 type User struct {
 	UserID   primitive.ObjectID `bson:"_id,omitempty"`
 	Username string             `bson:"username"`
 	Password string             `bson:"password,omitempty" `
+
+	// Usernames are every username the user has had, including the current one. They are unique across users,
+	// so that nobody can take the username of someone else, even after it has been changed.
+	Usernames       []string         `bson:"usernames,omitempty"`
+	UsernameHistory []UsernameChange `bson:"username_history,omitempty"`
+
+	// Email is only trusted once EmailVerified is set. Users who have signed up before email addresses
+	// were required, or through single sign-on, do not have one.
+	Email         string `bson:"email,omitempty"`
+	EmailVerified bool   `bson:"email_verified,omitempty"`
+
+	Profile  Profile  `bson:"profile,omitempty"`
+	Settings Settings `bson:"settings,omitempty"`
+	// SearchGrams are the bigrams of the username and the display name, which the user directory is searched by.
+	SearchGrams []string `bson:"search_bigrams,omitempty"`
+
+	// Invite is the code the user has signed up with, which InvitedBy has created.
+	Invite    string             `bson:"invite,omitempty"`
+	InvitedBy primitive.ObjectID `bson:"invited_by,omitempty"`
+	// InviteQuota is the number of invites the user can create. Users without one have the default quota.
+	InviteQuota    *int `bson:"invite_quota,omitempty"`
+	InvitesCreated int  `bson:"invites_created,omitempty"`
+
+	Roles []string `bson:"roles,omitempty"`
+	// Status is empty for the users whose status has never been changed, who are active. StatusReason is shown to the user.
+	Status       string `bson:"status,omitempty"`
+	StatusReason string `bson:"status_reason,omitempty"`
+	// SelfDeactivated is set for the users who have deactivated their own accounts, who can activate them again.
+	SelfDeactivated bool `bson:"self_deactivated,omitempty"`
+	// DeletionScheduledAt is when the account of the user, who has asked for it to be deleted, is going to be deleted.
+	// Until then, the account is deactivated.
+	DeletionScheduledAt *time.Time `bson:"deletion_scheduled_at,omitempty"`
+
+	// TOTPSecret is only set once the user has verified an authenticator app with PendingTOTPSecret.
+	TOTPSecret        string   `bson:"totp_secret,omitempty"`
+	PendingTOTPSecret string   `bson:"pending_totp_secret,omitempty"`
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty"`
+
+	// Identities are the accounts of the user at external identity providers, which the user can sign in with.
+	// Users provisioned through single sign-on do not have a password.
+	Identities []Identity `bson:"identities,omitempty"`
+}
+
+// UserSummary is what admins can see of a user.
+type UserSummary struct {
+	ID            string   `json:"id"`
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles"`
+	Status        string   `json:"status"`
+	StatusReason  string   `json:"status_reason,omitempty"`
+	Invite        string   `json:"invite,omitempty"`
+}
+
+type StatusForm struct {
+	Status string `form:"status" binding:"required"`
+	Reason string `form:"reason" binding:"max=500"`
+}
+
+// AccountDeletionForm does not require the password, like DeactivationForm.
+type AccountDeletionForm struct {
+	Password string `form:"password"`
+}
+
+// DeactivationForm does not require the password, since the users without one confirm their identity otherwise.
+type DeactivationForm struct {
+	Password string `form:"password"`
+	Reason   string `form:"reason" binding:"max=500"`
+}
+
+type UsernameChange struct {
+	Username  string    `bson:"username" json:"username"`
+	ChangedAt time.Time `bson:"changed_at" json:"changed_at"`
+}
+
+type UsernameForm struct {
+	Username string `form:"username" binding:"required"`
+}
+
+type Identity struct {
+	Issuer  string `bson:"issuer"`
+	Subject string `bson:"subject"`
+	Email   string `bson:"email,omitempty"`
+}
+
+func (u User) TwoFactorEnabled() bool {
+	return u.TOTPSecret != ""
+}
+
+// EmailUnverified reports whether the user has to verify their email address before they can use every feature.
+func (u User) EmailUnverified() bool {
+	return u.Email != "" && !u.EmailVerified
+}
+
+func (u User) AccountStatus() string {
+	if u.Status != "" {
+		return u.Status
+	}
+	return StatusActive
+}
+
+func (u User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (u User) Summary() UserSummary {
+	roles := u.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	return UserSummary{
+		ID:            u.UserID.Hex(),
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Roles:         roles,
+		Status:        u.AccountStatus(),
+		StatusReason:  u.StatusReason,
+		Invite:        u.Invite,
+	}
 }
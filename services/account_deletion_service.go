@@ -0,0 +1,200 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_account_deletion_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services AccountDeleter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"time"
+)
+
+// MessagePolicy decides what happens to the messages of the deleted users. Anonymized messages are kept for the
+// users they have been exchanged with, but reference DeletedUsername instead of the deleted user.
+const (
+	DeleteMessages    = "delete"
+	AnonymizeMessages = "anonymize"
+)
+
+// DeletedUsername cannot be taken by anyone, since it is not a valid username.
+const DeletedUsername = "[deleted]"
+
+// AccountDeletionService deletes the accounts of the users who ask for it once GracePeriod has passed. Until then,
+// their accounts are deactivated, and they can cancel the deletion. Deleted users lose their profiles, activity,
+// sessions, API keys, avatars and data exports, and their messages are handled according to MessagePolicy.
+type AccountDeletionService struct {
+	Users         *mongo.Collection
+	Messages      *mongo.Collection
+	Activity      *mongo.Collection
+	APIKeys       *mongo.Collection
+	Avatars       *mongo.Collection
+	Exports       *mongo.Collection
+	Archives      *gridfs.Bucket
+	Sessions      *SessionService
+	GracePeriod   time.Duration
+	MessagePolicy string
+}
+
+type AccountDeleter interface {
+	ScheduleDeletion(c context.Context, username string) (time.Time, error)
+	CancelDeletion(c context.Context, username string) error
+}
+
+// ScheduleDeletion deactivates the account of the user and returns when it is going to be deleted.
+func (a *AccountDeletionService) ScheduleDeletion(c context.Context, username string) (time.Time, error) {
+	deleteAt := time.Now().Add(a.GracePeriod).Truncate(time.Second)
+
+	result, err := a.Users.UpdateOne(c,
+		bson.M{"username": username},
+		bson.M{"$set": bson.M{
+			"status":                models.StatusDeactivated,
+			"status_reason":         "account is scheduled for deletion",
+			"deletion_scheduled_at": deleteAt,
+		}},
+	)
+	if err != nil {
+		return deleteAt, fmt.Errorf("mongo driver raised an error while scheduling the deletion of the user: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return deleteAt, ErrNoUser
+	}
+
+	return deleteAt, nil
+}
+
+// CancelDeletion activates the account of the user again, unless the grace period has passed,
+// or the user has been suspended in the meantime.
+func (a *AccountDeletionService) CancelDeletion(c context.Context, username string) error {
+	result, err := a.Users.UpdateOne(c,
+		bson.M{
+			"username":              username,
+			"status":                models.StatusDeactivated,
+			"deletion_scheduled_at": bson.M{"$gt": time.Now()},
+		},
+		bson.M{
+			"$set":   bson.M{"status": models.StatusActive},
+			"$unset": bson.M{"status_reason": "", "deletion_scheduled_at": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while cancelling the deletion of the user: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoDeletionScheduled
+	}
+
+	return nil
+}
+
+// DeleteScheduledAccounts deletes the accounts whose grace period has passed. Each step can be run again,
+// so an interrupted deletion is completed on the next run. An account which cannot be deleted does not keep
+// the others from being deleted, and is tried again on the next run.
+func (a *AccountDeletionService) DeleteScheduledAccounts(c context.Context) error {
+	cursor, err := a.Users.Find(c, bson.M{"deletion_scheduled_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the accounts to delete: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	failed := 0
+	for cursor.Next(c) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			logrus.Errorf("cannot decode user: %v", err.Error())
+			failed++
+			continue
+		}
+
+		if err := a.deleteAccount(c, user); err != nil {
+			logrus.WithField("user_id", user.UserID.Hex()).Errorf("cannot delete the account of the user with id: %v", err.Error())
+			failed++
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("cannot delete %d of the accounts scheduled for deletion", failed)
+	}
+	return nil
+}
+
+// deleteAccount removes the user last, so that the account can be found again if any of the steps fails.
+func (a *AccountDeletionService) deleteAccount(c context.Context, user models.User) error {
+	if err := a.deleteMessages(c, user); err != nil {
+		return err
+	}
+
+	if _, err := a.Activity.DeleteMany(c, bson.M{"$or": bson.A{
+		bson.M{"user_id": user.UserID},
+		bson.M{"username": user.Username, "user_id": bson.M{"$exists": false}},
+	}}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while deleting the activity of the user: %v", err.Error())
+	}
+	if _, err := a.APIKeys.DeleteMany(c, bson.M{"user_id": user.UserID}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while deleting the api keys of the user: %v", err.Error())
+	}
+	if _, err := a.Avatars.DeleteOne(c, bson.M{"_id": user.UserID}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while deleting the avatar of the user: %v", err.Error())
+	}
+	if _, err := a.Exports.DeleteMany(c, bson.M{"user_id": user.UserID}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while deleting the data exports of the user: %v", err.Error())
+	}
+	if err := deleteArchives(c, a.Archives, bson.M{"metadata.user_id": user.UserID}); err != nil {
+		return err
+	}
+	if err := a.Sessions.RevokeOtherSessions(c, user.Username, ""); err != nil && err != ErrNoUser {
+		return err
+	}
+
+	if _, err := a.Users.DeleteOne(c, bson.M{"_id": user.UserID}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while deleting the user: %v", err.Error())
+	}
+
+	return nil
+}
+
+// deleteMessages either deletes every message the user has sent or received, or makes them reference DeletedUsername.
+func (a *AccountDeletionService) deleteMessages(c context.Context, user models.User) error {
+	if a.MessagePolicy == DeleteMessages {
+		if _, err := a.Messages.DeleteMany(c, deletedMessagesFilter(user)); err != nil {
+			return fmt.Errorf("mongo driver raised an error while deleting the messages of the user: %v", err.Error())
+		}
+		return nil
+	}
+
+	for _, field := range []string{"from", "to"} {
+		filter, update := anonymizedMessages(field, user)
+		if _, err := a.Messages.UpdateMany(c, filter, update); err != nil {
+			return fmt.Errorf("mongo driver raised an error while anonymizing the messages of the user: %v", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// deletedMessagesFilter matches the messages the user has sent or received, including the ones stored
+// before the messages referenced the users by their ids.
+func deletedMessagesFilter(user models.User) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"from_id": user.UserID},
+		bson.M{"to_id": user.UserID},
+		legacyMessageFilter("from", user.Username),
+		legacyMessageFilter("to", user.Username),
+	}}
+}
+
+// anonymizedMessages returns the filter and the update which make the field of the messages of the user,
+// either "from" or "to", reference DeletedUsername.
+func anonymizedMessages(field string, user models.User) (bson.M, bson.M) {
+	filter := bson.M{"$or": bson.A{bson.M{field + "_id": user.UserID}, legacyMessageFilter(field, user.Username)}}
+	update := bson.M{"$set": bson.M{field: DeletedUsername}, "$unset": bson.M{field + "_id": ""}}
+	return filter, update
+}
+
+var ErrNoDeletionScheduled error = fmt.Errorf("account is not scheduled for deletion")
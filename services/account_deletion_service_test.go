@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+)
+
+func TestDeletedMessagesFilter(t *testing.T) {
+	user := models.User{UserID: primitive.NewObjectID(), Username: "johndoe"}
+	other := primitive.NewObjectID()
+
+	tests := []struct {
+		Message  bson.M
+		Expected bool
+	}{
+		{Message: bson.M{"from_id": user.UserID, "from": "johndoe", "to_id": other, "to": "janedoe"}, Expected: true},
+		{Message: bson.M{"from_id": other, "from": "janedoe", "to_id": user.UserID, "to": "johndoe"}, Expected: true},
+		{Message: bson.M{"from": "johndoe", "to": "janedoe"}, Expected: true},
+		{Message: bson.M{"from": "janedoe", "to": "johndoe"}, Expected: true},
+		{Message: bson.M{"from_id": other, "from": "johndoe", "to_id": other, "to": "janedoe"}, Expected: false},
+		{Message: bson.M{"from": "janedoe", "to": "richardroe"}, Expected: false},
+	}
+
+	filter := deletedMessagesFilter(user)
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			if got := matches(tt.Message, filter); got != tt.Expected {
+				t.Errorf("want %v, got %v for %v", tt.Expected, got, tt.Message)
+			}
+		})
+	}
+}
+
+func TestAnonymizedMessages(t *testing.T) {
+	user := models.User{UserID: primitive.NewObjectID(), Username: "johndoe"}
+	other := primitive.NewObjectID()
+
+	tests := []struct {
+		Message  bson.M
+		Expected bson.M
+	}{
+		{
+			Message:  bson.M{"from_id": user.UserID, "from": "johndoe", "to_id": other, "to": "janedoe"},
+			Expected: bson.M{"from": DeletedUsername, "to_id": other, "to": "janedoe"},
+		}, {
+			Message:  bson.M{"from_id": other, "from": "janedoe", "to_id": user.UserID, "to": "johndoe"},
+			Expected: bson.M{"from_id": other, "from": "janedoe", "to": DeletedUsername},
+		}, {
+			Message:  bson.M{"from": "johndoe", "to": "janedoe"},
+			Expected: bson.M{"from": DeletedUsername, "to": "janedoe"},
+		}, {
+			Message:  bson.M{"from": "janedoe", "to": "johndoe"},
+			Expected: bson.M{"from": "janedoe", "to": DeletedUsername},
+		}, {
+			Message:  bson.M{"from_id": other, "from": "johndoe", "to_id": other, "to": "janedoe"},
+			Expected: bson.M{"from_id": other, "from": "johndoe", "to_id": other, "to": "janedoe"},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			message := tt.Message
+			for _, field := range []string{"from", "to"} {
+				filter, update := anonymizedMessages(field, user)
+				if matches(message, filter) {
+					message = apply(message, update)
+				}
+			}
+			if !reflect.DeepEqual(message, tt.Expected) {
+				t.Errorf("want %v, got %v", tt.Expected, message)
+			}
+		})
+	}
+}
@@ -6,20 +6,46 @@ import (
 	"context"
 	"fmt"
 	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
 
+// ActivityService references the users by their ids, but is called with their usernames.
+// Impersonations are only needed by LogExpiredImpersonations.
 type ActivityService struct {
 	*mongo.Collection
+	Users          UserIDResolver
+	Impersonations ImpersonationExpirer
 }
 
 type ActivityLogger interface {
 	LogSignin(c context.Context, username, ip string) error
 	LogSignout(c context.Context, username, ip string) error
 	LogUnsuccesfulSignin(c context.Context, username, ip string) error
+	LogPasswordChange(c context.Context, username, ip string) error
+	LogPasswordResetRequest(c context.Context, username, ip string) error
+	LogPasswordReset(c context.Context, username, ip string) error
+	LogTwoFactor(c context.Context, username, ip string) error
+	LogUnsuccesfulTwoFactor(c context.Context, username, ip string) error
+	LogTwoFactorEnable(c context.Context, username, ip string) error
+	LogTwoFactorDisable(c context.Context, username, ip string) error
+	LogLockout(c context.Context, username, ip string) error
+	LogRefreshTokenReuse(c context.Context, username, ip string) error
+	LogIdentityLink(c context.Context, username, ip string) error
+	LogUsernameChange(c context.Context, username, ip string) error
+	LogImpersonationStart(c context.Context, username string, impersonatorID primitive.ObjectID, ip string) error
+	LogImpersonationEnd(c context.Context, username string, impersonatorID primitive.ObjectID, ip string) error
+	LogDeactivation(c context.Context, username, ip string) error
+	LogReactivation(c context.Context, username, ip string) error
+}
+
+// FailureCounter tells how many signins and two-factor verifications have failed recently, across every user.
+type FailureCounter interface {
+	CountFailures(c context.Context, since time.Time) (int64, error)
 }
 
 type ActivityFetcher interface {
@@ -38,26 +64,149 @@ func (a *ActivityService) LogUnsuccesfulSignin(c context.Context, username, ip s
 	return a.log(c, username, ip, "fail_signin")
 }
 
-func (a *ActivityService) log(c context.Context, username, ip string, event string) error {
-	_, err := a.InsertOne(c, models.Activity{
-		Username: username,
-		Event:    event,
-		When:     time.Now(),
-		IP:       ip,
+func (a *ActivityService) LogPasswordChange(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "password_change")
+}
+
+func (a *ActivityService) LogPasswordResetRequest(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "password_reset_request")
+}
+
+func (a *ActivityService) LogPasswordReset(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "password_reset")
+}
+
+func (a *ActivityService) LogTwoFactor(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "2fa")
+}
+
+func (a *ActivityService) LogUnsuccesfulTwoFactor(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "fail_2fa")
+}
+
+func (a *ActivityService) LogTwoFactorEnable(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "2fa_enable")
+}
+
+func (a *ActivityService) LogTwoFactorDisable(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "2fa_disable")
+}
+
+func (a *ActivityService) LogLockout(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "locked_out")
+}
+
+func (a *ActivityService) LogRefreshTokenReuse(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "refresh_token_reuse")
+}
+
+func (a *ActivityService) LogIdentityLink(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "identity_link")
+}
+
+func (a *ActivityService) LogUsernameChange(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "username_change")
+}
+
+func (a *ActivityService) LogImpersonationStart(c context.Context, username string, impersonatorID primitive.ObjectID, ip string) error {
+	return a.insert(c, username, models.Activity{Event: "impersonation_start", IP: ip, When: time.Now(), ImpersonatorID: &impersonatorID})
+}
+
+func (a *ActivityService) LogImpersonationEnd(c context.Context, username string, impersonatorID primitive.ObjectID, ip string) error {
+	return a.insert(c, username, models.Activity{Event: "impersonation_end", IP: ip, When: time.Now(), ImpersonatorID: &impersonatorID})
+}
+
+// LogExpiredImpersonations logs the end of the impersonations which have timed out, in the activity of both
+// the impersonator and the user, as of when they have timed out. An impersonation which cannot be logged
+// does not keep the others from being logged.
+func (a *ActivityService) LogExpiredImpersonations(c context.Context) error {
+	impersonations, err := a.Impersonations.ExpireImpersonations(c)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, impersonation := range impersonations {
+		impersonatorID := impersonation.ImpersonatorID
+		for _, userID := range []primitive.ObjectID{impersonation.ImpersonatorID, impersonation.UserID} {
+			if _, err := a.InsertOne(c, models.Activity{
+				Event:          "impersonation_end",
+				UserID:         userID,
+				When:           impersonation.EndedAt,
+				ImpersonatorID: &impersonatorID,
+			}); err != nil {
+				logrus.WithField("user_id", userID.Hex()).Errorf("cannot log the end of the impersonation of the user with id: %v", err.Error())
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("cannot log the end of %d of the expired impersonations", failed)
+	}
+	return nil
+}
+
+func (a *ActivityService) LogDeactivation(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "deactivation")
+}
+
+func (a *ActivityService) LogReactivation(c context.Context, username, ip string) error {
+	return a.log(c, username, ip, "reactivation")
+}
+
+func (a *ActivityService) CountFailures(c context.Context, since time.Time) (int64, error) {
+	count, err := a.Collection.CountDocuments(c, bson.M{
+		"event": bson.M{"$in": bson.A{"fail_signin", "fail_2fa"}},
+		"when":  bson.M{"$gte": since},
 	})
 	if err != nil {
-		return fmt.Errorf("mongo driver raised an error while logging an %v event: %v", event, err.Error())
+		return 0, fmt.Errorf("mongo driver raised an error while counting the failures: %v", err.Error())
+	}
+
+	return count, nil
+}
+
+func (a *ActivityService) log(c context.Context, username, ip string, event string) error {
+	return a.insert(c, username, models.Activity{
+		Event: event,
+		When:  time.Now(),
+		IP:    ip,
+	})
+}
+
+// insert keeps the username instead of the id if the user does not exist, as for failed sign-ins.
+func (a *ActivityService) insert(c context.Context, username string, activity models.Activity) error {
+	if userID, err := a.Users.ResolveUserID(c, username); err == ErrNoUser {
+		activity.Username = username
+	} else if err != nil {
+		return err
+	} else {
+		activity.UserID = userID
+	}
+
+	if _, err := a.InsertOne(c, activity); err != nil {
+		return fmt.Errorf("mongo driver raised an error while logging an %v event: %v", activity.Event, err.Error())
 	}
 
 	return nil
 }
 
+// Fetch returns the activity of the user, along with that of the user who has not existed by the username.
 func (a *ActivityService) Fetch(c context.Context, username string) ([]models.Activity, error) {
 	results := make([]models.Activity, 0)
 
+	// Activity which has not been migrated yet, or whose user did not exist, references the user by the username
+	filter := bson.M{"username": username, "user_id": bson.M{"$exists": false}}
+	if userID, err := a.Users.ResolveUserID(c, username); err == nil {
+		filter = bson.M{"$or": bson.A{bson.M{"user_id": userID}, filter}}
+	} else if err != ErrNoUser {
+		return nil, err
+	}
+
 	cursor, err := a.Collection.Find(
 		c,
-		bson.M{"username": username},
+		filter,
 		options.Find().SetSort(bson.D{{"when", -1}}))
 	if err == mongo.ErrNoDocuments {
 		return results, nil
@@ -70,6 +219,7 @@ func (a *ActivityService) Fetch(c context.Context, username string) ([]models.Ac
 		if err := cursor.Decode(&activity); err != nil {
 			return nil, fmt.Errorf("cannot decode the activity: %v", err.Error())
 		}
+		activity.Username = username
 
 		results = append(results, activity)
 	}
@@ -0,0 +1,178 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_api_key_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services APIKeyManager,APIKeyAuthenticator
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+// Scopes limit what an API key can be used for. Routes declare the scope they require with middlewares.Protected.
+const (
+	ScopeMessagesRead = "messages:read"
+	ScopeMessagesSend = "messages:send"
+	ScopeActivityRead = "activity:read"
+)
+
+var Scopes = []string{ScopeMessagesRead, ScopeMessagesSend, ScopeActivityRead}
+
+// APIKeyPrefix marks API keys, so that they can be told apart from access tokens in the Authorization header.
+const APIKeyPrefix = "armut_"
+
+// APIKeyService manages the long-lived API keys of the users. Only the hashes of the keys are stored.
+// Keys reference their users by their ids.
+type APIKeyService struct {
+	Collection *mongo.Collection
+	Users      UserIDResolver
+}
+
+type APIKeyManager interface {
+	CreateAPIKey(c context.Context, username, name string, scopes []string) (string, models.APIKey, error)
+	ListAPIKeys(c context.Context, username string) ([]models.APIKey, error)
+	RevokeAPIKey(c context.Context, username, id string) error
+}
+
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(c context.Context, key string) (models.APIKey, error)
+}
+
+func (a *APIKeyService) EnsureIndexes(c context.Context) error {
+	_, err := a.Collection.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.M{"hash": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"user_id": 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while creating the api key indexes: %v", err.Error())
+	}
+
+	return nil
+}
+
+// CreateAPIKey returns the key itself along with its record. The key cannot be retrieved afterwards.
+func (a *APIKeyService) CreateAPIKey(c context.Context, username, name string, scopes []string) (string, models.APIKey, error) {
+	for _, scope := range scopes {
+		if !isScope(scope) {
+			return "", models.APIKey{}, ErrUnknownScope
+		}
+	}
+
+	userID, err := a.Users.ResolveUserID(c, username)
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+	key := APIKeyPrefix + token
+
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:len(APIKeyPrefix)+6],
+		Hash:      hashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := a.Collection.InsertOne(c, apiKey)
+	if err != nil {
+		return "", models.APIKey{}, fmt.Errorf("mongo driver raised an error while inserting a new api key: %v", err.Error())
+	}
+	apiKey.ID = result.InsertedID.(primitive.ObjectID)
+
+	return key, apiKey, nil
+}
+
+func (a *APIKeyService) ListAPIKeys(c context.Context, username string) ([]models.APIKey, error) {
+	results := make([]models.APIKey, 0)
+
+	userID, err := a.Users.ResolveUserID(c, username)
+	if err != nil {
+		return results, err
+	}
+
+	cursor, err := a.Collection.Find(c, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return results, fmt.Errorf("mongo driver raised an error while fetching api keys: %v", err.Error())
+	}
+
+	for cursor.Next(c) {
+		var apiKey models.APIKey
+		if err := cursor.Decode(&apiKey); err != nil {
+			return results, fmt.Errorf("cannot decode the api key: %v", err.Error())
+		}
+
+		results = append(results, apiKey)
+	}
+
+	return results, nil
+}
+
+func (a *APIKeyService) RevokeAPIKey(c context.Context, username, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNoAPIKey
+	}
+
+	userID, err := a.Users.ResolveUserID(c, username)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.Collection.DeleteOne(c, bson.M{"_id": objID, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while revoking the api key: %v", err.Error())
+	}
+	if result.DeletedCount == 0 {
+		return ErrNoAPIKey
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the record of the key and marks it as used.
+func (a *APIKeyService) AuthenticateAPIKey(c context.Context, key string) (models.APIKey, error) {
+	var apiKey models.APIKey
+
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return apiKey, ErrInvalidAPIKey
+	}
+
+	result := a.Collection.FindOneAndUpdate(c,
+		bson.M{"hash": hashToken(key)},
+		bson.M{"$set": bson.M{"last_used": time.Now()}},
+	)
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return apiKey, ErrInvalidAPIKey
+	} else if err != nil {
+		return apiKey, fmt.Errorf("mongo driver raised an error while fetching the api key: %v", err.Error())
+	}
+
+	if err := result.Decode(&apiKey); err != nil {
+		return apiKey, fmt.Errorf("cannot decode the api key: %v", err.Error())
+	}
+
+	return apiKey, nil
+}
+
+func isScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+var ErrUnknownScope error = fmt.Errorf("scope is unknown")
+var ErrNoAPIKey error = fmt.Errorf("api key does not exist")
+var ErrInvalidAPIKey error = fmt.Errorf("api key is invalid")
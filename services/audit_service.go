@@ -0,0 +1,53 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_audit_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services AuditLogger,AuditFetcher
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditService keeps the audit log of the actions admins take. Unlike activities, audit entries are not visible to the users they concern.
+type AuditService struct {
+	*mongo.Collection
+}
+
+type AuditLogger interface {
+	LogAudit(c context.Context, entry models.AuditEntry) error
+}
+
+type AuditFetcher interface {
+	FetchAudit(c context.Context, skip, limit int64) ([]models.AuditEntry, error)
+}
+
+func (a *AuditService) LogAudit(c context.Context, entry models.AuditEntry) error {
+	if _, err := a.InsertOne(c, entry); err != nil {
+		return fmt.Errorf("mongo driver raised an error while logging an audit entry: %v", err.Error())
+	}
+
+	return nil
+}
+
+func (a *AuditService) FetchAudit(c context.Context, skip, limit int64) ([]models.AuditEntry, error) {
+	results := make([]models.AuditEntry, 0)
+
+	cursor, err := a.Collection.Find(c, bson.M{}, options.Find().SetSort(bson.M{"when": -1}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while fetching the audit log: %v", err.Error())
+	}
+
+	for cursor.Next(c) {
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("cannot decode the audit entry: %v", err.Error())
+		}
+
+		results = append(results, entry)
+	}
+
+	return results, nil
+}
@@ -8,11 +8,11 @@ import (
 	"github.com/aliparlakci/armut-backend-assessment/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
 	*mongo.Collection
+	Hashing HashingPolicy
 }
 
 type Authenticator interface {
@@ -23,6 +23,9 @@ type PasswordHasher interface {
 	HashPassword(password string) (string, error)
 }
 
+// Authenticate upgrades the stored hash of the user to the current HashingPolicy
+// if the password is correct and the hash is outdated. It returns an AccountStatusError
+// if the password is correct but the user is not active.
 func (a *AuthService) Authenticate(c context.Context, username, password string) (bool, error) {
 	result := a.Collection.FindOne(c, bson.M{"username": username})
 
@@ -37,15 +40,47 @@ func (a *AuthService) Authenticate(c context.Context, username, password string)
 		return false, fmt.Errorf("cannot decode user: %v", err.Error())
 	}
 
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-	return err == nil, nil
+	// Users provisioned through single sign-on do not have a password to sign in with.
+	if user.Password == "" {
+		return false, nil
+	}
+
+	success, err := a.Hashing.Verify(user.Password, password)
+	if err != nil || !success {
+		return false, err
+	}
+
+	// The status is only revealed to those who know the password
+	if err := CheckAccountStatus(user); err != nil {
+		return false, err
+	}
+
+	if a.Hashing.NeedsRehash(user.Password) {
+		// A failed upgrade must not fail the signin, it is retried on the next one.
+		_ = a.rehash(c, user, password)
+	}
+
+	return true, nil
 }
 
 func (a AuthService) HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	return a.Hashing.Hash(password)
+}
+
+// rehash only replaces the hash it has verified, so that it cannot overwrite a password changed in the meantime.
+func (a *AuthService) rehash(c context.Context, user models.User, password string) error {
+	hash, err := a.Hashing.Hash(password)
 	if err != nil {
-		return "", fmt.Errorf("cannot hash the password: %v", err.Error())
+		return err
 	}
 
-	return string(bytes), nil
-}
\ No newline at end of file
+	_, err = a.Collection.UpdateOne(c,
+		bson.M{"_id": user.UserID, "password": user.Password},
+		bson.M{"$set": bson.M{"password": hash}},
+	)
+	if err != nil {
+		return fmt.Errorf("mongodb driver raised an error while upgrading the password hash: %v", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	// AvatarSize is the width and the height of the avatar thumbnails.
+	AvatarSize = 128
+	// MaxAvatarUpload is the largest avatar upload in bytes.
+	MaxAvatarUpload = 5 << 20
+	// maxAvatarPixels guards against images which are small to upload but huge to decode.
+	maxAvatarPixels = 25_000_000
+)
+
+// makeThumbnail crops the center square of a PNG, JPEG or GIF image and scales it to AvatarSize,
+// returning it encoded as PNG.
+func makeThumbnail(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxAvatarUpload+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the avatar: %v", err.Error())
+	}
+	if len(data) > MaxAvatarUpload {
+		return nil, ErrAvatarTooLarge
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+	if config.Width*config.Height > maxAvatarPixels {
+		return nil, ErrAvatarTooLarge
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, scale(centerSquare(source.Bounds()), source, AvatarSize)); err != nil {
+		return nil, fmt.Errorf("cannot encode the avatar: %v", err.Error())
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func centerSquare(bounds image.Rectangle) image.Rectangle {
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// scale resizes the square area of the source to size by averaging the source pixels each target pixel covers,
+// or by picking the nearest one when the source is smaller than the target.
+func scale(area image.Rectangle, source image.Image, size int) *image.NRGBA {
+	target := image.NewNRGBA(image.Rect(0, 0, size, size))
+	side := area.Dx()
+
+	for ty := 0; ty < size; ty++ {
+		y0 := area.Min.Y + ty*side/size
+		y1 := area.Min.Y + (ty+1)*side/size
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for tx := 0; tx < size; tx++ {
+			x0 := area.Min.X + tx*side/size
+			x1 := area.Min.X + (tx+1)*side/size
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := source.At(x, y).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+
+			// The sums are of alpha-premultiplied colors
+			target.Set(tx, ty, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	return target
+}
+
+var ErrInvalidAvatar error = fmt.Errorf("avatar must be a PNG, JPEG or GIF image")
+var ErrAvatarTooLarge error = fmt.Errorf("avatar is too large")
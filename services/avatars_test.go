@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestMakeThumbnail(t *testing.T) {
+	// A wide image whose center square is green and whose sides, which are cropped, are red
+	wide := image.NewNRGBA(image.Rect(0, 0, 600, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 600; x++ {
+			if x >= 200 && x < 400 {
+				wide.Set(x, y, color.NRGBA{G: 255, A: 255})
+			} else {
+				wide.Set(x, y, color.NRGBA{R: 255, A: 255})
+			}
+		}
+	}
+	var widePNG bytes.Buffer
+	png.Encode(&widePNG, wide)
+
+	small := image.NewNRGBA(image.Rect(0, 0, 16, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 16; x++ {
+			small.Set(x, y, color.NRGBA{B: 255, A: 255})
+		}
+	}
+	var smallJPEG bytes.Buffer
+	jpeg.Encode(&smallJPEG, small, &jpeg.Options{Quality: 100})
+
+	tests := []struct {
+		Image         []byte
+		ExpectedColor color.NRGBA
+		ExpectedError error
+	}{
+		{Image: widePNG.Bytes(), ExpectedColor: color.NRGBA{G: 255, A: 255}, ExpectedError: nil},
+		{Image: smallJPEG.Bytes(), ExpectedColor: color.NRGBA{B: 254, A: 255}, ExpectedError: nil},
+		{Image: []byte("definitely not an image"), ExpectedError: ErrInvalidAvatar},
+		{Image: make([]byte, MaxAvatarUpload+1), ExpectedError: ErrAvatarTooLarge},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			result, err := makeThumbnail(bytes.NewReader(tt.Image))
+
+			if err != tt.ExpectedError {
+				t.Fatalf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			thumbnail, err := png.Decode(bytes.NewReader(result))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bounds := thumbnail.Bounds(); bounds.Dx() != AvatarSize || bounds.Dy() != AvatarSize {
+				t.Errorf("want %vx%v, got %vx%v", AvatarSize, AvatarSize, bounds.Dx(), bounds.Dy())
+			}
+			for _, point := range []image.Point{{0, 0}, {AvatarSize / 2, AvatarSize / 2}, {AvatarSize - 1, AvatarSize - 1}} {
+				if actual := color.NRGBAModel.Convert(thumbnail.At(point.X, point.Y)).(color.NRGBA); !closeColors(actual, tt.ExpectedColor) {
+					t.Errorf("at %v: want %v, got %v", point, tt.ExpectedColor, actual)
+				}
+			}
+		})
+	}
+}
+
+// closeColors tolerates the loss of JPEG compression
+func closeColors(a, b color.NRGBA) bool {
+	near := func(x, y uint8) bool { return x-y < 8 || y-x < 8 }
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
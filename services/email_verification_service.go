@@ -0,0 +1,68 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_email_verification_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services VerificationTokenCreator,VerificationTokenConsumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// EmailVerificationService issues single-use tokens which prove that a user owns an email address and expire after TokenTTL.
+// Only the hashes of the tokens are stored, in "email_verification:<hash>", along with the user and the email address.
+type EmailVerificationService struct {
+	Store    *redis.Client
+	TokenTTL time.Duration
+}
+
+type VerificationTokenCreator interface {
+	CreateVerificationToken(c context.Context, username, email string) (string, error)
+}
+
+type VerificationTokenConsumer interface {
+	ConsumeVerificationToken(c context.Context, token string) (string, string, error)
+}
+
+func (e *EmailVerificationService) CreateVerificationToken(c context.Context, username, email string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := verificationTokenKey(token)
+	pipe := e.Store.TxPipeline()
+	pipe.HSet(c, key, "username", username, "email", email)
+	pipe.Expire(c, key, e.TokenTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return "", fmt.Errorf("cannot store the verification token: %v", err.Error())
+	}
+
+	return token, nil
+}
+
+// ConsumeVerificationToken invalidates the token and returns the username and the email address it was issued for.
+// Returns ErrInvalidVerificationToken if the token does not exist, has expired or is already used.
+func (e *EmailVerificationService) ConsumeVerificationToken(c context.Context, token string) (string, string, error) {
+	key := verificationTokenKey(token)
+
+	pipe := e.Store.TxPipeline()
+	get := pipe.HGetAll(c, key)
+	pipe.Del(c, key)
+	if _, err := pipe.Exec(c); err != nil && err != redis.Nil {
+		return "", "", fmt.Errorf("cannot consume the verification token: %v", err.Error())
+	}
+
+	fields := get.Val()
+	if len(fields) == 0 {
+		return "", "", ErrInvalidVerificationToken
+	}
+
+	return fields["username"], fields["email"], nil
+}
+
+func verificationTokenKey(token string) string {
+	return "email_verification:" + hashToken(token)
+}
+
+var ErrInvalidVerificationToken error = fmt.Errorf("verification token is invalid or expired")
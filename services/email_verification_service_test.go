@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redismock/v8"
+	"testing"
+	"time"
+)
+
+func TestConsumeVerificationToken(t *testing.T) {
+	key := "email_verification:" + hashToken("sometoken")
+
+	tests := []struct {
+		Token         string
+		Prepare       func(client *redismock.ClientMock)
+		Expected      [2]string
+		ExpectedError error
+	}{
+		{
+			Token: "sometoken",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectTxPipeline()
+				(*client).ExpectHGetAll(key).SetVal(map[string]string{"username": "aliparlakci", "email": "ali@example.com"})
+				(*client).ExpectDel(key).SetVal(1)
+				(*client).ExpectTxPipelineExec()
+			},
+			Expected:      [2]string{"aliparlakci", "ali@example.com"},
+			ExpectedError: nil,
+		}, {
+			Token: "sometoken",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectTxPipeline()
+				(*client).ExpectHGetAll(key).SetVal(map[string]string{})
+				(*client).ExpectDel(key).SetVal(0)
+				(*client).ExpectTxPipelineExec()
+			},
+			Expected:      [2]string{"", ""},
+			ExpectedError: ErrInvalidVerificationToken,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := EmailVerificationService{Store: db, TokenTTL: time.Hour}
+			username, email, err := service.ConsumeVerificationToken(context.Background(), tt.Token)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result := [2]string{username, email}; result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_export_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services DataExporter
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"strconv"
+	"time"
+)
+
+// exportBuildTimeout bounds how long an export can stay pending, so that a user is not kept from requesting
+// another one when the build has been interrupted.
+const exportBuildTimeout = 10 * time.Minute
+
+// ExportService builds archives of the personal data of users in the background, and notifies them once the archives
+// can be downloaded. Exports are kept in Exports, referenced by the hashes of their download tokens, and their archives
+// are streamed into Archives. Both are removed once LinkTTL has passed.
+type ExportService struct {
+	Exports  *mongo.Collection
+	Archives *gridfs.Bucket
+	Users    UserGetter
+	Messages MessageGetter
+	Activity ActivityFetcher
+	Sessions SessionLister
+	Notifier Notifier
+	LinkTTL  time.Duration
+}
+
+type DataExporter interface {
+	RequestExport(c context.Context, username string) (string, error)
+	FetchExport(c context.Context, username, token string) (models.Export, error)
+	OpenArchive(c context.Context, export models.Export) (io.ReadCloser, int64, error)
+}
+
+// EnsureIndexes lets a user have a single pending export at a time, and lets mongo remove the expired exports.
+func (e *ExportService) EnsureIndexes(c context.Context) error {
+	_, err := e.Exports.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{
+			Keys: bson.M{"user_id": 1},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": models.ExportPending}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while creating the export indexes: %v", err.Error())
+	}
+
+	return nil
+}
+
+// RequestExport returns the token the export can be downloaded with once it is ready.
+// Returns ErrExportInProgress if the previous export of the user has not been built yet.
+func (e *ExportService) RequestExport(c context.Context, username string) (string, error) {
+	user, err := e.Users.GetUser(c, username)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().Truncate(time.Second)
+	export := models.Export{
+		ID:          hashToken(token),
+		UserID:      user.UserID,
+		Status:      models.ExportPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(exportBuildTimeout),
+	}
+	if _, err := e.Exports.InsertOne(c, export); mongo.IsDuplicateKeyError(err) {
+		return "", ErrExportInProgress
+	} else if err != nil {
+		return "", fmt.Errorf("mongo driver raised an error while creating the export: %v", err.Error())
+	}
+
+	go e.build(user, export.ID, token)
+
+	return token, nil
+}
+
+// FetchExport returns the export of the user with the token, which does not have its archive until it is ready.
+func (e *ExportService) FetchExport(c context.Context, username, token string) (models.Export, error) {
+	var export models.Export
+
+	user, err := e.Users.GetUser(c, username)
+	if err != nil {
+		return export, err
+	}
+
+	result := e.Exports.FindOne(c, bson.M{
+		"_id":        hashToken(token),
+		"user_id":    user.UserID,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return export, ErrNoExport
+	} else if err != nil {
+		return export, fmt.Errorf("mongo driver raised an error while fetching the export: %v", err.Error())
+	}
+
+	if err := result.Decode(&export); err != nil {
+		return export, fmt.Errorf("cannot decode the export: %v", err.Error())
+	}
+
+	return export, nil
+}
+
+// OpenArchive returns the archive of a ready export, along with its size.
+// Returns ErrNoExport if the archive has been removed since the export was fetched.
+func (e *ExportService) OpenArchive(c context.Context, export models.Export) (io.ReadCloser, int64, error) {
+	stream, err := e.Archives.OpenDownloadStream(export.ArchiveID)
+	if err == gridfs.ErrFileNotFound {
+		return nil, 0, ErrNoExport
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("mongo driver raised an error while opening the export archive: %v", err.Error())
+	}
+	if deadline, ok := c.Deadline(); ok {
+		stream.SetReadDeadline(deadline)
+	}
+
+	return stream, stream.GetFile().Length, nil
+}
+
+// RemoveExpiredArchives removes the archives of the exports which mongo has removed once they have expired.
+func (e *ExportService) RemoveExpiredArchives(c context.Context) error {
+	return deleteArchives(c, e.Archives, bson.M{"metadata.expires_at": bson.M{"$lte": time.Now()}})
+}
+
+// build does not share a context with the request, which has ended by the time the export is built.
+// Exports which cannot be built are removed, so that the user can request another one.
+func (e *ExportService) build(user models.User, id, token string) {
+	c, cancel := context.WithTimeout(context.Background(), exportBuildTimeout)
+	defer cancel()
+
+	logger := logrus.WithField("username", user.Username)
+
+	expiresAt, err := e.complete(c, user, id)
+	if err != nil {
+		logger.Errorf("cannot build the data export: %v", err.Error())
+
+		if _, err := e.Exports.DeleteOne(c, bson.M{"_id": id}); err != nil {
+			logger.Errorf("mongo driver raised an error while removing the failed export: %v", err.Error())
+		}
+		message := "Your data export could not be prepared. Please request another one."
+		if err := e.Notifier.Notify(c, user.Username, "Data export failed", message); err != nil {
+			logger.Errorf("Notifier.Notify() raised an error while sending the export failure: %v", err.Error())
+		}
+		return
+	}
+
+	message := fmt.Sprintf("Your data export is ready. Download it from /api/me/export/%v before %v.", token, expiresAt.UTC().Format(time.RFC1123))
+	if err := e.Notifier.Notify(c, user.Username, "Data export", message); err != nil {
+		logger.Errorf("Notifier.Notify() raised an error while sending the export link: %v", err.Error())
+	}
+}
+
+// complete stores the archive of the export and returns when its download link expires.
+func (e *ExportService) complete(c context.Context, user models.User, id string) (time.Time, error) {
+	expiresAt := time.Now().Add(e.LinkTTL).Truncate(time.Second)
+
+	messages, err := e.Messages.GetAllMessages(c, user.Username)
+	if err != nil {
+		return expiresAt, err
+	}
+	activities, err := e.Activity.Fetch(c, user.Username)
+	if err != nil {
+		return expiresAt, err
+	}
+	sessions, err := e.Sessions.ListSessions(c, user.Username)
+	if err != nil {
+		return expiresAt, err
+	}
+
+	archive, err := e.Archives.OpenUploadStream(id+".zip", options.GridFSUpload().SetMetadata(bson.M{
+		"user_id":    user.UserID,
+		"expires_at": expiresAt,
+	}))
+	if err != nil {
+		return expiresAt, fmt.Errorf("mongo driver raised an error while storing the export: %v", err.Error())
+	}
+	if deadline, ok := c.Deadline(); ok {
+		archive.SetWriteDeadline(deadline)
+	}
+	if err := writeExportArchive(archive, time.Now(), user.ExportProfile(), messages, activities, sessions); err != nil {
+		archive.Abort()
+		return expiresAt, err
+	}
+	if err := archive.Close(); err != nil {
+		return expiresAt, fmt.Errorf("mongo driver raised an error while storing the export: %v", err.Error())
+	}
+
+	result, err := e.Exports.UpdateOne(c,
+		bson.M{"_id": id, "status": models.ExportPending},
+		bson.M{"$set": bson.M{
+			"status":     models.ExportReady,
+			"archive_id": archive.FileID,
+			"expires_at": expiresAt,
+		}},
+	)
+	if err != nil {
+		err = fmt.Errorf("mongo driver raised an error while storing the export: %v", err.Error())
+	} else if result.MatchedCount == 0 {
+		err = ErrNoExport
+	}
+	if err != nil {
+		if err := e.Archives.Delete(archive.FileID); err != nil {
+			logrus.WithField("username", user.Username).Errorf("mongo driver raised an error while removing the archive of the failed export: %v", err.Error())
+		}
+		return expiresAt, err
+	}
+
+	return expiresAt, nil
+}
+
+// deleteArchives removes the export archives which match the filter on their files documents.
+func deleteArchives(c context.Context, archives *gridfs.Bucket, filter bson.M) error {
+	cursor, err := archives.Find(filter, options.GridFSFind().SetBatchSize(100))
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while finding the export archives: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		var file struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return fmt.Errorf("cannot decode the export archive: %v", err.Error())
+		}
+		if err := archives.Delete(file.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return fmt.Errorf("mongo driver raised an error while removing the export archive: %v", err.Error())
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("mongo driver raised an error while finding the export archives: %v", err.Error())
+	}
+
+	return nil
+}
+
+// writeExportArchive writes a zip archive with every record in export.json, and the messages, the activity and
+// the sessions in CSV files as well, for spreadsheets. Sessions which an admin has started by impersonating the user
+// are labeled as such.
+func writeExportArchive(w io.Writer, exportedAt time.Time, profile models.ExportProfile, messages []models.Message, activities []models.Activity, sessions []models.Session) error {
+	archive := zip.NewWriter(w)
+
+	document, err := json.MarshalIndent(map[string]interface{}{
+		"exported_at": exportedAt,
+		"profile":     profile,
+		"messages":    messages,
+		"activity":    activities,
+		"sessions":    sessions,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode the export: %v", err.Error())
+	}
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return fmt.Errorf("cannot write the export: %v", err.Error())
+	}
+	if _, err := file.Write(document); err != nil {
+		return fmt.Errorf("cannot write the export: %v", err.Error())
+	}
+
+	messageRows := [][]string{{"id", "from", "to", "body", "send_at", "is_read", "read_at"}}
+	for _, message := range messages {
+		readAt := ""
+		if message.ReadAt != nil {
+			readAt = message.ReadAt.UTC().Format(time.RFC3339)
+		}
+		messageRows = append(messageRows, []string{
+			message.ID.Hex(), message.From, message.To, message.Body,
+			message.SendAt.UTC().Format(time.RFC3339), strconv.FormatBool(message.IsRead), readAt,
+		})
+	}
+
+	activityRows := [][]string{{"id", "event", "ip", "when"}}
+	for _, activity := range activities {
+		activityRows = append(activityRows, []string{
+			activity.ID.Hex(), activity.Event, activity.IP, activity.When.UTC().Format(time.RFC3339),
+		})
+	}
+
+	sessionRows := [][]string{{"id", "ip", "user_agent", "created_at", "last_seen", "impersonated"}}
+	for _, session := range sessions {
+		sessionRows = append(sessionRows, []string{
+			session.ID, session.IP, session.UserAgent,
+			session.CreatedAt.UTC().Format(time.RFC3339), session.LastSeen.UTC().Format(time.RFC3339),
+			strconv.FormatBool(session.Impersonated),
+		})
+	}
+
+	for _, table := range []struct {
+		name string
+		rows [][]string
+	}{
+		{"messages.csv", messageRows},
+		{"activity.csv", activityRows},
+		{"sessions.csv", sessionRows},
+	} {
+		file, err := archive.Create(table.name)
+		if err != nil {
+			return fmt.Errorf("cannot write the export: %v", err.Error())
+		}
+		if err := csv.NewWriter(file).WriteAll(table.rows); err != nil {
+			return fmt.Errorf("cannot write the export: %v", err.Error())
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("cannot write the export: %v", err.Error())
+	}
+
+	return nil
+}
+
+var ErrExportInProgress error = fmt.Errorf("previous export has not been completed yet")
+var ErrNoExport error = fmt.Errorf("export does not exist or has expired")
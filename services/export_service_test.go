@@ -0,0 +1,112 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteExportArchive(t *testing.T) {
+	when := time.Date(2021, 11, 20, 12, 0, 0, 0, time.UTC)
+	messageID := primitive.NewObjectID()
+	activityID := primitive.NewObjectID()
+
+	user := models.User{Username: "johndoe", Profile: models.Profile{DisplayName: "John Doe"}}
+	messages := []models.Message{
+		{ID: messageID, From: "janedoe", To: "johndoe", Body: "hello, \"john\"\nhow are you?", SendAt: when, IsRead: true, ReadAt: &when},
+	}
+	activities := []models.Activity{{ID: activityID, Event: "signin", Username: "johndoe", IP: "127.0.0.1", When: when}}
+	sessions := []models.Session{
+		{ID: "0123456789abcdef", IP: "127.0.0.1", UserAgent: "curl/7.79.1", CreatedAt: when, LastSeen: when},
+		{ID: "fedcba9876543210", IP: "10.0.0.1", UserAgent: "curl/7.79.1", CreatedAt: when, LastSeen: when, Impersonated: true},
+	}
+
+	var archive bytes.Buffer
+	if err := writeExportArchive(&archive, when, user.ExportProfile(), messages, activities, sessions); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	var document struct {
+		Profile  models.ExportProfile `json:"profile"`
+		Messages []models.Message     `json:"messages"`
+		Activity []models.Activity    `json:"activity"`
+		Sessions []models.Session     `json:"sessions"`
+	}
+	file, err := files["export.json"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewDecoder(file).Decode(&document); err != nil {
+		t.Fatal(err)
+	}
+	if document.Profile.Username != "johndoe" || document.Profile.DisplayName != "John Doe" {
+		t.Errorf("want the profile of johndoe, got %v", document.Profile)
+	}
+	if len(document.Messages) != 1 || document.Messages[0].Body != messages[0].Body {
+		t.Errorf("want the messages, got %v", document.Messages)
+	}
+	if len(document.Activity) != 1 || len(document.Sessions) != 2 || !document.Sessions[1].Impersonated {
+		t.Errorf("want the activity and the sessions, got %v and %v", document.Activity, document.Sessions)
+	}
+
+	tests := []struct {
+		Name         string
+		ExpectedRows [][]string
+	}{
+		{
+			Name: "messages.csv",
+			ExpectedRows: [][]string{
+				{"id", "from", "to", "body", "send_at", "is_read", "read_at"},
+				{messageID.Hex(), "janedoe", "johndoe", "hello, \"john\"\nhow are you?", "2021-11-20T12:00:00Z", "true", "2021-11-20T12:00:00Z"},
+			},
+		}, {
+			Name: "activity.csv",
+			ExpectedRows: [][]string{
+				{"id", "event", "ip", "when"},
+				{activityID.Hex(), "signin", "127.0.0.1", "2021-11-20T12:00:00Z"},
+			},
+		}, {
+			Name: "sessions.csv",
+			ExpectedRows: [][]string{
+				{"id", "ip", "user_agent", "created_at", "last_seen", "impersonated"},
+				{"0123456789abcdef", "127.0.0.1", "curl/7.79.1", "2021-11-20T12:00:00Z", "2021-11-20T12:00:00Z", "false"},
+				{"fedcba9876543210", "10.0.0.1", "curl/7.79.1", "2021-11-20T12:00:00Z", "2021-11-20T12:00:00Z", "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			if files[tt.Name] == nil {
+				t.Fatalf("archive does not have %v", tt.Name)
+			}
+			file, err := files[tt.Name].Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := csv.NewReader(file).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.ExpectedRows) {
+				t.Errorf("want %v, got %v", tt.ExpectedRows, rows)
+			}
+		})
+	}
+}
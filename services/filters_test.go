@@ -0,0 +1,110 @@
+package services
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
+)
+
+// matches evaluates the subset of the query language the filters of the services use against a document:
+// equality, $or, $exists, $ne, $in and $nin, on dotted paths and on arrays.
+func matches(document, filter bson.M) bool {
+	for key, condition := range filter {
+		if key == "$or" {
+			matched := false
+			for _, alternative := range condition.(bson.A) {
+				matched = matched || matches(document, alternative.(bson.M))
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+
+		value, exists := lookup(document, key)
+		operators, ok := condition.(bson.M)
+		if !ok {
+			operators = bson.M{"$eq": condition}
+		}
+		for operator, operand := range operators {
+			var matched bool
+			switch operator {
+			case "$eq":
+				matched = exists && equals(value, operand)
+			case "$exists":
+				matched = exists == operand.(bool)
+			case "$ne":
+				matched = !exists || !equals(value, operand)
+			case "$in":
+				matched = exists && containsAny(value, operand)
+			case "$nin":
+				matched = !exists || !containsAny(value, operand)
+			default:
+				panic("unsupported operator " + operator)
+			}
+			if !matched {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func lookup(document bson.M, path string) (interface{}, bool) {
+	var value interface{} = document
+	for _, key := range strings.Split(path, ".") {
+		embedded, ok := value.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = embedded[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// equals matches an array if any of its elements is equal to the operand, like the query language does.
+func equals(value, operand interface{}) bool {
+	if elements, ok := value.(bson.A); ok {
+		for _, element := range elements {
+			if element == operand {
+				return true
+			}
+		}
+		return false
+	}
+	return value == operand
+}
+
+func containsAny(value, operands interface{}) bool {
+	var list []interface{}
+	switch o := operands.(type) {
+	case bson.A:
+		list = o
+	case []string:
+		for _, s := range o {
+			list = append(list, s)
+		}
+	}
+	for _, operand := range list {
+		if equals(value, operand) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply applies the $set and $unset operators of the update to a copy of the document.
+func apply(document, update bson.M) bson.M {
+	updated := bson.M{}
+	for key, value := range document {
+		updated[key] = value
+	}
+	for key, value := range update["$set"].(bson.M) {
+		updated[key] = value
+	}
+	for key := range update["$unset"].(bson.M) {
+		delete(updated, key)
+	}
+	return updated
+}
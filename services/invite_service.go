@@ -0,0 +1,262 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_invite_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services InviteRedeemer,InviteCreator,InviteManager
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// InviteService manages the invite codes users sign up with. Signing up without an invite is only possible
+// when Required is not set. Admins can create invites of any number of uses, while users can create up to their
+// quota of single use invites, which expire after UserInviteTTL.
+type InviteService struct {
+	Invites       *mongo.Collection
+	Users         *mongo.Collection
+	Required      bool
+	DefaultQuota  int
+	UserInviteTTL time.Duration
+}
+
+type InviteRedeemer interface {
+	InvitesRequired() bool
+	RedeemInvite(c context.Context, code string) (models.Invite, error)
+	ReleaseInvite(c context.Context, code string) error
+	RecordInvitee(c context.Context, username string, invite models.Invite) error
+}
+
+type InviteCreator interface {
+	CreateUserInvite(c context.Context, username string) (models.Invite, error)
+	ListUserInvites(c context.Context, username string) (models.InviteList, error)
+}
+
+type InviteManager interface {
+	CreateInvite(c context.Context, username string, maxUses int, ttl time.Duration) (models.Invite, error)
+	ListInvites(c context.Context, skip, limit int64) ([]models.Invite, error)
+	SetInviteQuota(c context.Context, username string, quota int) error
+}
+
+func (i *InviteService) EnsureIndexes(c context.Context) error {
+	if _, err := i.Invites.Indexes().CreateOne(c, mongo.IndexModel{Keys: bson.M{"created_by": 1}}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while creating the invite indexes: %v", err.Error())
+	}
+
+	return nil
+}
+
+func (i *InviteService) InvitesRequired() bool {
+	return i.Required
+}
+
+// RedeemInvite uses up one use of the invite, which ReleaseInvite gives back if the signup fails afterwards.
+func (i *InviteService) RedeemInvite(c context.Context, code string) (models.Invite, error) {
+	var invite models.Invite
+
+	result := i.Invites.FindOneAndUpdate(c,
+		bson.M{
+			"_id":   code,
+			"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}},
+			"$or": bson.A{
+				bson.M{"expires_at": bson.M{"$exists": false}},
+				bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+			},
+		},
+		bson.M{"$inc": bson.M{"uses": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return invite, ErrInvalidInvite
+	} else if err != nil {
+		return invite, fmt.Errorf("mongo driver raised an error while redeeming the invite: %v", err.Error())
+	}
+	if err := result.Decode(&invite); err != nil {
+		return invite, fmt.Errorf("cannot decode invite: %v", err.Error())
+	}
+
+	return invite, nil
+}
+
+func (i *InviteService) ReleaseInvite(c context.Context, code string) error {
+	if _, err := i.Invites.UpdateOne(c,
+		bson.M{"_id": code, "uses": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"uses": -1}},
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while releasing the invite: %v", err.Error())
+	}
+
+	return nil
+}
+
+// RecordInvitee records the invite the user has signed up with, and who has created it.
+func (i *InviteService) RecordInvitee(c context.Context, username string, invite models.Invite) error {
+	result, err := i.Users.UpdateOne(c,
+		bson.M{"username": username},
+		bson.M{"$set": bson.M{"invite": invite.Code, "invited_by": invite.CreatedBy}},
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while recording the invite of the user: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// CreateInvite creates an invite without counting it against the quota of the user, for admins.
+// Invites with a zero ttl do not expire. maxUses must be positive.
+func (i *InviteService) CreateInvite(c context.Context, username string, maxUses int, ttl time.Duration) (models.Invite, error) {
+	var user models.User
+	if err := i.Users.FindOne(c, bson.M{"username": username}, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&user); err == mongo.ErrNoDocuments {
+		return models.Invite{}, ErrNoUser
+	} else if err != nil {
+		return models.Invite{}, fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+
+	return i.insertInvite(c, user.UserID, maxUses, ttl)
+}
+
+// CreateUserInvite creates a single use invite if the user has not used up their quota.
+func (i *InviteService) CreateUserInvite(c context.Context, username string) (models.Invite, error) {
+	user, err := i.quotaUser(c, username)
+	if err != nil {
+		return models.Invite{}, err
+	}
+	quota := i.quota(user)
+	if quota <= 0 {
+		return models.Invite{}, ErrInviteQuotaExceeded
+	}
+
+	// The quota is claimed before the invite is created, so that concurrent requests cannot exceed it
+	result, err := i.Users.UpdateOne(c,
+		bson.M{"_id": user.UserID, "$or": bson.A{
+			bson.M{"invites_created": bson.M{"$lt": quota}},
+			bson.M{"invites_created": bson.M{"$exists": false}},
+		}},
+		bson.M{"$inc": bson.M{"invites_created": 1}},
+	)
+	if err != nil {
+		return models.Invite{}, fmt.Errorf("mongo driver raised an error while claiming the invite quota: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return models.Invite{}, ErrInviteQuotaExceeded
+	}
+
+	invite, err := i.insertInvite(c, user.UserID, 1, i.UserInviteTTL)
+	if err != nil {
+		// Give the quota back, since no invite has been created
+		_, _ = i.Users.UpdateOne(c, bson.M{"_id": user.UserID}, bson.M{"$inc": bson.M{"invites_created": -1}})
+		return models.Invite{}, err
+	}
+
+	return invite, nil
+}
+
+// ListUserInvites returns the invites the user has created, most recent first.
+func (i *InviteService) ListUserInvites(c context.Context, username string) (models.InviteList, error) {
+	list := models.InviteList{Invites: make([]models.Invite, 0)}
+
+	user, err := i.quotaUser(c, username)
+	if err != nil {
+		return list, err
+	}
+	if list.Remaining = i.quota(user) - user.InvitesCreated; list.Remaining < 0 {
+		list.Remaining = 0
+	}
+
+	list.Invites, err = i.findInvites(c, bson.M{"created_by": user.UserID}, options.Find())
+	return list, err
+}
+
+// ListInvites returns every invite, most recent first.
+func (i *InviteService) ListInvites(c context.Context, skip, limit int64) ([]models.Invite, error) {
+	return i.findInvites(c, bson.M{}, options.Find().SetSkip(skip).SetLimit(limit))
+}
+
+func (i *InviteService) SetInviteQuota(c context.Context, username string, quota int) error {
+	result, err := i.Users.UpdateOne(c, bson.M{"username": username}, bson.M{"$set": bson.M{"invite_quota": quota}})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while setting the invite quota: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+func (i *InviteService) insertInvite(c context.Context, createdBy primitive.ObjectID, maxUses int, ttl time.Duration) (models.Invite, error) {
+	code, err := randomToken(9)
+	if err != nil {
+		return models.Invite{}, err
+	}
+
+	invite := models.Invite{
+		Code:      code,
+		CreatedBy: createdBy,
+		MaxUses:   maxUses,
+		CreatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := invite.CreatedAt.Add(ttl)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if _, err := i.Invites.InsertOne(c, invite); err != nil {
+		return models.Invite{}, fmt.Errorf("mongo driver raised an error while inserting a new invite: %v", err.Error())
+	}
+
+	return invite, nil
+}
+
+func (i *InviteService) findInvites(c context.Context, filter bson.M, opts *options.FindOptions) ([]models.Invite, error) {
+	results := make([]models.Invite, 0)
+
+	cursor, err := i.Invites.Find(c, filter, opts.SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return results, fmt.Errorf("mongo driver raised an error while fetching invites: %v", err.Error())
+	}
+
+	for cursor.Next(c) {
+		var invite models.Invite
+		if err := cursor.Decode(&invite); err != nil {
+			return results, fmt.Errorf("cannot decode invite: %v", err.Error())
+		}
+
+		results = append(results, invite)
+	}
+
+	return results, nil
+}
+
+func (i *InviteService) quotaUser(c context.Context, username string) (models.User, error) {
+	var user models.User
+
+	err := i.Users.FindOne(c,
+		bson.M{"username": username},
+		options.FindOne().SetProjection(bson.M{"invite_quota": 1, "invites_created": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return user, ErrNoUser
+	} else if err != nil {
+		return user, fmt.Errorf("mongo driver raised an error while fetching the invite quota: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+func (i *InviteService) quota(user models.User) int {
+	if user.InviteQuota != nil {
+		return *user.InviteQuota
+	}
+	return i.DefaultQuota
+}
+
+var ErrInvalidInvite error = fmt.Errorf("invite code is invalid or expired")
+var ErrInviteQuotaExceeded error = fmt.Errorf("invite quota is used up")
@@ -0,0 +1,130 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_lockout_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services SigninThrottler
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// LockoutService counts failed signins in a sliding window of Window, both per username and per ip.
+// Once either count reaches its threshold, signins for it are locked out for BaseLockout, which doubles
+// with every further failure up to MaxLockout.
+//
+// Failures are kept in the "signin_failures:<user|ip>:<value>" sorted sets, scored by their time,
+// and lockouts in the "lockout:<user|ip>:<value>" keys, which expire when the lockout ends.
+type LockoutService struct {
+	Store         *redis.Client
+	Window        time.Duration
+	UserThreshold int
+	IPThreshold   int
+	BaseLockout   time.Duration
+	MaxLockout    time.Duration
+}
+
+type SigninThrottler interface {
+	CheckLockout(c context.Context, username, ip string) (time.Duration, error)
+	RecordFailure(c context.Context, username, ip string) (time.Duration, error)
+	ResetFailures(c context.Context, username string) error
+}
+
+// CheckLockout returns how long signins for the username or from the ip are locked out for, if they are.
+func (l *LockoutService) CheckLockout(c context.Context, username, ip string) (time.Duration, error) {
+	pipe := l.Store.Pipeline()
+	user := pipe.PTTL(c, lockoutKey("user", username))
+	address := pipe.PTTL(c, lockoutKey("ip", ip))
+	if _, err := pipe.Exec(c); err != nil {
+		return 0, fmt.Errorf("cannot check the lockouts: %v", err.Error())
+	}
+
+	return maxDuration(user.Val(), address.Val()), nil
+}
+
+// RecordFailure returns the duration of the lockout if the failure has caused one.
+func (l *LockoutService) RecordFailure(c context.Context, username, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	pipe := l.Store.TxPipeline()
+	user := l.recordFailure(c, pipe, failuresKey("user", username), now)
+	address := l.recordFailure(c, pipe, failuresKey("ip", ip), now)
+	if _, err := pipe.Exec(c); err != nil {
+		return 0, fmt.Errorf("cannot record the failed signin: %v", err.Error())
+	}
+
+	userLockout := l.lockoutFor(int(user.Val()), l.UserThreshold)
+	addressLockout := l.lockoutFor(int(address.Val()), l.IPThreshold)
+	if userLockout == 0 && addressLockout == 0 {
+		return 0, nil
+	}
+
+	pipe = l.Store.TxPipeline()
+	if userLockout > 0 {
+		pipe.Set(c, lockoutKey("user", username), 1, userLockout)
+	}
+	if addressLockout > 0 {
+		pipe.Set(c, lockoutKey("ip", ip), 1, addressLockout)
+	}
+	if _, err := pipe.Exec(c); err != nil {
+		return 0, fmt.Errorf("cannot lock out the signins: %v", err.Error())
+	}
+
+	return maxDuration(userLockout, addressLockout), nil
+}
+
+// ResetFailures forgets the failures of the username after a successful signin.
+// Failures of the ip are kept, so that an attacker cannot reset them by signing in to their own account.
+func (l *LockoutService) ResetFailures(c context.Context, username string) error {
+	if err := l.Store.Del(c, failuresKey("user", username)).Err(); err != nil {
+		return fmt.Errorf("cannot reset the failed signins: %v", err.Error())
+	}
+
+	return nil
+}
+
+// recordFailure adds the failure to the window and returns the number of failures in it.
+func (l *LockoutService) recordFailure(c context.Context, pipe redis.Pipeliner, key string, now time.Time) *redis.IntCmd {
+	pipe.ZRemRangeByScore(c, key, "-inf", strconv.FormatInt(now.Add(-l.Window).UnixNano(), 10))
+	pipe.ZAdd(c, key, &redis.Z{Score: float64(now.UnixNano()), Member: now.UnixNano()})
+	count := pipe.ZCard(c, key)
+	pipe.Expire(c, key, l.Window)
+
+	return count
+}
+
+func (l *LockoutService) lockoutFor(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	lockout := l.BaseLockout
+	for i := threshold; i < failures && lockout < l.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.MaxLockout {
+		lockout = l.MaxLockout
+	}
+
+	return lockout
+}
+
+func failuresKey(kind, value string) string {
+	return "signin_failures:" + kind + ":" + value
+}
+
+func lockoutKey(kind, value string) string {
+	return "lockout:" + kind + ":" + value
+}
+
+// maxDuration also treats the negative durations redis returns for missing keys as zero.
+func maxDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		a = b
+	}
+	if a < 0 {
+		return 0
+	}
+	return a
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redismock/v8"
+	"testing"
+	"time"
+)
+
+func TestCheckLockout(t *testing.T) {
+	tests := []struct {
+		Prepare  func(client *redismock.ClientMock)
+		Expected time.Duration
+	}{
+		{
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectPTTL("lockout:user:aliparlakci").SetVal(-2 * time.Millisecond)
+				(*client).ExpectPTTL("lockout:ip:127.0.0.1").SetVal(-2 * time.Millisecond)
+			},
+			Expected: 0,
+		}, {
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectPTTL("lockout:user:aliparlakci").SetVal(30 * time.Second)
+				(*client).ExpectPTTL("lockout:ip:127.0.0.1").SetVal(-2 * time.Millisecond)
+			},
+			Expected: 30 * time.Second,
+		}, {
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectPTTL("lockout:user:aliparlakci").SetVal(30 * time.Second)
+				(*client).ExpectPTTL("lockout:ip:127.0.0.1").SetVal(time.Minute)
+			},
+			Expected: time.Minute,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := LockoutService{Store: db}
+			result, err := service.CheckLockout(context.Background(), "aliparlakci", "127.0.0.1")
+
+			if err != nil {
+				t.Errorf("want %v, got %v", nil, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestLockoutFor(t *testing.T) {
+	service := LockoutService{BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	tests := []struct {
+		Failures int
+		Expected time.Duration
+	}{
+		{Failures: 4, Expected: 0},
+		{Failures: 5, Expected: time.Minute},
+		{Failures: 6, Expected: 2 * time.Minute},
+		{Failures: 8, Expected: 8 * time.Minute},
+		{Failures: 9, Expected: 10 * time.Minute},
+		{Failures: 50, Expected: 10 * time.Minute},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			if result := service.lockoutFor(tt.Failures, 5); result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_mail_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services Mailer
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer sends emails to email addresses, unlike Notifier, which reaches users by their username.
+type Mailer interface {
+	Mail(c context.Context, to, subject, body string) error
+}
+
+// LogMailer writes emails to the application log. It is meant for local development.
+type LogMailer struct{}
+
+func (l LogMailer) Mail(c context.Context, to, subject, body string) error {
+	logrus.WithFields(logrus.Fields{"to": to, "subject": subject}).Info(body)
+	return nil
+}
+
+// SMTPMailer sends plain text emails from From through the SMTP server at Addr, authenticating with Auth if it is set.
+type SMTPMailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func (s SMTPMailer) Mail(c context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("email headers cannot contain line breaks")
+	}
+
+	message := "From: " + s.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	if err := smtp.SendMail(s.Addr, s.Auth, s.From, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("cannot send the email: %v", err.Error())
+	}
+
+	return nil
+}
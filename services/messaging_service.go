@@ -16,6 +16,8 @@ import (
 type MessagingService struct {
 	*mongo.Collection
 	*UserService
+	Counters UnreadCounter
+	Profiles ProfileGetter
 }
 
 type MessageSender interface {
@@ -26,6 +28,7 @@ type MessageGetter interface {
 	GetAllMessages(c context.Context, username string) ([]models.Message, error)
 	GetNewMessages(c context.Context, username string) ([]models.Message, error)
 	CheckNewMessages(c context.Context, username string) (int, error)
+	CheckNewMessagesFrom(c context.Context, receiver, sender string) (int, error)
 }
 
 type MessageReader interface {
@@ -36,12 +39,18 @@ type MessageReader interface {
 func (m *MessagingService) GetAllMessages(c context.Context, username string) ([]models.Message, error) {
 	results := make([]models.Message, 0)
 
-	cursor, err := m.Collection.Find(c, bson.D{
-		{"$or",
-			bson.A{
-				bson.D{{"from", username}},
-				bson.D{{"to", username}},
-			}},
+	userID, err := m.ResolveUserID(c, username)
+	if err != nil {
+		return results, err
+	}
+
+	cursor, err := m.Collection.Find(c, bson.M{
+		"$or": bson.A{
+			bson.M{"from_id": userID},
+			bson.M{"to_id": userID},
+			legacyMessageFilter("from", username),
+			legacyMessageFilter("to", username),
+		},
 	}, options.Find().SetSort(bson.D{{"send_at", -1}}))
 	if err != nil {
 		return results, fmt.Errorf("mongo driver raised an error while fetching new messages: %v", err.Error())
@@ -56,13 +65,25 @@ func (m *MessagingService) GetAllMessages(c context.Context, username string) ([
 		results = append(results, message)
 	}
 
-	return results, nil
+	if err := m.attachUsers(c, results); err != nil {
+		return results, err
+	}
+
+	return results, m.hideReadReceipts(c, userID, username, results)
 }
 
 func (m *MessagingService) GetNewMessages(c context.Context, username string) ([]models.Message, error) {
 	results := make([]models.Message, 0)
 
-	cursor, err := m.Collection.Find(c, bson.M{"to": username, "is_read": false})
+	userID, err := m.ResolveUserID(c, username)
+	if err != nil {
+		return results, err
+	}
+
+	cursor, err := m.Collection.Find(c, bson.M{
+		"is_read": false,
+		"$or":     bson.A{bson.M{"to_id": userID}, legacyMessageFilter("to", username)},
+	})
 	if err != nil {
 		return results, fmt.Errorf("mongo driver raised an error while fetching new messages: %v", err.Error())
 	}
@@ -76,70 +97,383 @@ func (m *MessagingService) GetNewMessages(c context.Context, username string) ([
 		results = append(results, message)
 	}
 
-	return results, nil
+	return results, m.attachUsers(c, results)
+}
+
+// legacyMessageFilter matches the messages which still reference their users by their usernames,
+// because they have not been migrated yet, or their users did not exist when they were.
+func legacyMessageFilter(field, username string) bson.M {
+	return bson.M{field: username, field + "_id": bson.M{"$exists": false}}
+}
+
+// attachUsers fills in the current usernames of the users of the messages, and includes the profiles of the senders,
+// fetching them at once.
+func (m *MessagingService) attachUsers(c context.Context, messages []models.Message) error {
+	ids := make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	for _, message := range messages {
+		for _, id := range []primitive.ObjectID{message.FromID, message.ToID} {
+			if !id.IsZero() && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	profiles, err := m.Profiles.GetProfilesByID(c, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		if profile, exists := profiles[messages[i].ToID]; exists {
+			messages[i].To = profile.Username
+		}
+
+		// Messages of users who no longer exist only have their username
+		sender := models.SenderInfo{Username: messages[i].From, DisplayName: messages[i].From}
+		if profile, exists := profiles[messages[i].FromID]; exists {
+			messages[i].From = profile.Username
+			sender = profile.SenderInfo()
+		}
+		messages[i].Sender = &sender
+	}
+
+	return nil
+}
+
+// hideReadReceipts makes the messages the user has sent to the users who do not send read receipts appear unread.
+func (m *MessagingService) hideReadReceipts(c context.Context, userID primitive.ObjectID, username string, messages []models.Message) error {
+	receiverIDs := make([]primitive.ObjectID, 0)
+	receivers := make([]string, 0)
+	for _, message := range messages {
+		if !message.IsRead || (message.FromID != userID && (!message.FromID.IsZero() || message.From != username)) {
+			continue
+		}
+		if message.ToID.IsZero() {
+			receivers = append(receivers, message.To)
+		} else {
+			receiverIDs = append(receiverIDs, message.ToID)
+		}
+	}
+	if len(receiverIDs) == 0 && len(receivers) == 0 {
+		return nil
+	}
+
+	cursor, err := m.UserService.Collection.Find(c,
+		bson.M{
+			"$or":                         bson.A{bson.M{"_id": bson.M{"$in": receiverIDs}}, bson.M{"username": bson.M{"$in": receivers}}},
+			"settings.hide_read_receipts": true,
+		},
+		options.Find().SetProjection(bson.M{"username": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the settings of the receivers: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	hiddenIDs := make(map[primitive.ObjectID]bool)
+	hidden := make(map[string]bool)
+	for cursor.Next(c) {
+		var receiver models.User
+		if err := cursor.Decode(&receiver); err != nil {
+			return fmt.Errorf("cannot decode user: %v", err.Error())
+		}
+		hiddenIDs[receiver.UserID] = true
+		hidden[receiver.Username] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for i, message := range messages {
+		if message.ToID == userID || (message.ToID.IsZero() && message.To == username) {
+			continue
+		}
+		if hiddenIDs[message.ToID] || (message.ToID.IsZero() && hidden[message.To]) {
+			messages[i].IsRead = false
+			messages[i].ReadAt = nil
+		}
+	}
+
+	return nil
 }
 
 func (m *MessagingService) CheckNewMessages(c context.Context, username string) (int, error) {
-	result, err := m.Collection.CountDocuments(c, bson.M{"to": username, "is_read": false})
+	userID, err := m.ResolveUserID(c, username)
 	if err != nil {
 		return 0, err
 	}
 
-	return int(result), nil
+	receiver := userID.Hex()
+	count, err := m.Counters.UnreadCount(c, receiver)
+	if err == ErrNoCounter {
+		conversations, err := m.seedUnreadCounters(c, userID)
+		if err != nil {
+			return 0, err
+		}
+
+		total := 0
+		for _, n := range conversations {
+			total += int(n)
+		}
+		return total, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *MessagingService) CheckNewMessagesFrom(c context.Context, receiverUsername, senderUsername string) (int, error) {
+	receiverID, err := m.ResolveUserID(c, receiverUsername)
+	if err != nil {
+		return 0, err
+	}
+	senderID, err := m.ResolveUserID(c, senderUsername)
+	if err == ErrNoUser {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	receiver, sender := receiverID.Hex(), senderID.Hex()
+	count, err := m.Counters.UnreadCountFrom(c, receiver, sender)
+	if err == ErrNoCounter {
+		conversations, err := m.seedUnreadCounters(c, receiverID)
+		if err != nil {
+			return 0, err
+		}
+		return int(conversations[sender]), nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ReconcileUnreadCounters rebuilds every unread counter from mongodb,
+// which is the source of truth, to repair counters that have drifted.
+// Counters are keyed by the hex ids of the users.
+func (m *MessagingService) ReconcileUnreadCounters(c context.Context) error {
+	counts, err := m.countUnread(c, bson.M{"is_read": false, "to_id": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+
+	receivers, err := m.Counters.CountedReceivers(c)
+	if err != nil {
+		return err
+	}
+	for _, receiver := range receivers {
+		if _, exists := counts[receiver]; !exists {
+			counts[receiver] = map[string]int64{}
+		}
+	}
+
+	for receiver, conversations := range counts {
+		if err := m.Counters.SetUnread(c, receiver, conversations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MessagingService) seedUnreadCounters(c context.Context, receiverID primitive.ObjectID) (map[string]int64, error) {
+	counts, err := m.countUnread(c, bson.M{"to_id": receiverID, "is_read": false})
+	if err != nil {
+		return nil, err
+	}
+
+	receiver := receiverID.Hex()
+	conversations, exists := counts[receiver]
+	if !exists {
+		conversations = map[string]int64{}
+	}
+
+	if err := m.Counters.SetUnread(c, receiver, conversations); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// countUnread returns the number of messages matching the filter, grouped by the hex ids of the receivers and the senders.
+// Messages which do not reference their users by their ids are left out.
+func (m *MessagingService) countUnread(c context.Context, filter bson.M) (map[string]map[string]int64, error) {
+	cursor, err := m.Collection.Aggregate(c, []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{
+			"_id":   bson.M{"to": "$to_id", "from": "$from_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while counting unread messages: %v", err.Error())
+	}
+
+	counts := make(map[string]map[string]int64)
+	for cursor.Next(c) {
+		var group struct {
+			ID struct {
+				To   primitive.ObjectID `bson:"to"`
+				From primitive.ObjectID `bson:"from"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, fmt.Errorf("cannot decode the unread message count: %v", err.Error())
+		}
+		if group.ID.To.IsZero() || group.ID.From.IsZero() {
+			continue
+		}
+
+		receiver, sender := group.ID.To.Hex(), group.ID.From.Hex()
+		if _, exists := counts[receiver]; !exists {
+			counts[receiver] = make(map[string]int64)
+		}
+		counts[receiver][sender] = group.Count
+	}
+
+	return counts, nil
 }
 
 func (m *MessagingService) SendMessage(c context.Context, body, sender, receiver string) (string, error) {
-	senderExists, err := m.UserExists(c, sender)
+	senderID, err := m.ResolveUserID(c, sender)
 	if err != nil {
 		return "", err
 	}
-	receiverExists, err := m.UserExists(c, receiver)
+	receiverID, err := m.ResolveUserID(c, receiver)
 	if err != nil {
 		return "", err
 	}
+	receiverUser, err := m.GetUserByID(c, receiverID)
+	if err != nil {
+		return "", err
+	}
+	if receiverUser.AccountStatus() == models.StatusDeactivated {
+		return "", ErrRecipientDeactivated
+	}
 
-	if !senderExists || !receiverExists {
-		return "", ErrNoUser
+	if err := m.checkMessagingAllowed(c, senderID, sender, receiverUser); err != nil {
+		return "", err
 	}
 
 	if result, err := m.Collection.InsertOne(c, models.Message{
-		From:   sender,
-		To:     receiver,
+		FromID: senderID,
+		ToID:   receiverID,
 		Body:   body,
 		IsRead: false,
 		SendAt: time.Now(),
 	}); err != nil {
 		return "", fmt.Errorf("mongo driver raised an error while inserting a new message: %v", err.Error())
 	} else {
+		// The message is already sent at this point, a counter that could not be updated
+		// is fixed by the next reconciliation.
+		_ = m.Counters.IncrementUnread(c, receiverID.Hex(), senderID.Hex())
 		return result.InsertedID.(primitive.ObjectID).String(), nil
 	}
 }
 
+// checkMessagingAllowed returns ErrMessagingNotAllowed if the settings of the receiver do not accept messages
+// from the sender. Users can always message themselves.
+func (m *MessagingService) checkMessagingAllowed(c context.Context, senderID primitive.ObjectID, sender string, receiver models.User) error {
+	if senderID == receiver.UserID {
+		return nil
+	}
+
+	switch receiver.Settings.MessagesFrom() {
+	case models.MessagesFromNobody:
+		return ErrMessagingNotAllowed
+	case models.MessagesFromContacts:
+		count, err := m.Collection.CountDocuments(c,
+			bson.M{"$and": bson.A{
+				bson.M{"$or": bson.A{bson.M{"from_id": receiver.UserID}, legacyMessageFilter("from", receiver.Username)}},
+				bson.M{"$or": bson.A{bson.M{"to_id": senderID}, legacyMessageFilter("to", sender)}},
+			}},
+			options.Count().SetLimit(1),
+		)
+		if err != nil {
+			return fmt.Errorf("mongo driver raised an error while looking for the messages of the receiver: %v", err.Error())
+		}
+		if count == 0 {
+			return ErrMessagingNotAllowed
+		}
+	}
+
+	return nil
+}
+
 func (m *MessagingService) ReadMessage(c context.Context, id, receiver string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("cannot convert id to ObjectID: %v", err.Error())
 	}
 
-	_, err = m.Collection.UpdateMany(
+	receiverID, err := m.ResolveUserID(c, receiver)
+	if err != nil {
+		return err
+	}
+
+	result := m.Collection.FindOneAndUpdate(
 		c,
-		bson.M{"_id": objID, "to": receiver},
-		bson.D{
-			{"$set", bson.D{{"is_read", true}}},
+		bson.M{
+			"_id":     objID,
+			"is_read": false,
+			"$or":     bson.A{bson.M{"to_id": receiverID}, legacyMessageFilter("to", receiver)},
 		},
+		bson.M{"$set": bson.M{"is_read": true, "read_at": time.Now()}},
 	)
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var message models.Message
+	if err := result.Decode(&message); err != nil {
+		return fmt.Errorf("cannot decode the read message: %v", err.Error())
+	}
+	if message.FromID.IsZero() {
+		return nil
+	}
 
-	return err
+	return m.Counters.DecrementUnread(c, receiverID.Hex(), message.FromID.Hex(), 1)
 }
 
 func (m *MessagingService) ReadMessagesFromUser(c context.Context, sender, receiver string) error {
-	_, err := m.Collection.UpdateMany(
+	// Counters do not count the messages which still reference their users by their usernames
+	if _, err := m.Collection.UpdateMany(
 		c,
-		bson.M{"from": sender, "to": receiver},
-		bson.D{
-			{"$set", bson.D{{"is_read", true}}},
-		},
+		bson.M{"from": sender, "to": receiver, "from_id": bson.M{"$exists": false}, "is_read": false},
+		bson.M{"$set": bson.M{"is_read": true, "read_at": time.Now()}},
+	); err != nil {
+		return err
+	}
+
+	receiverID, err := m.ResolveUserID(c, receiver)
+	if err != nil {
+		return err
+	}
+	senderID, err := m.ResolveUserID(c, sender)
+	if err == ErrNoUser {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	result, err := m.Collection.UpdateMany(
+		c,
+		bson.M{"from_id": senderID, "to_id": receiverID, "is_read": false},
+		bson.M{"$set": bson.M{"is_read": true, "read_at": time.Now()}},
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return m.Counters.DecrementUnread(c, receiverID.Hex(), senderID.Hex(), result.ModifiedCount)
 }
+
+var ErrMessagingNotAllowed error = fmt.Errorf("receiver does not accept messages from the sender")
+var ErrRecipientDeactivated error = fmt.Errorf("receiver has deactivated their account")
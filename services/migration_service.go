@@ -0,0 +1,432 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_migration_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services MigrationChecker
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UserIDMigration moves the messages, activity, sessions, api keys and avatars,
+// which used to reference their users by their usernames, to reference them by their ids.
+const UserIDMigration = "user_ids"
+
+// CanonicalUsernameMigration stores the usernames which were signed up with before usernames were case-insensitive
+// in their canonical form, so that they can be found by it.
+const CanonicalUsernameMigration = "canonical_usernames"
+
+// MigrationService runs the data migrations while the server is serving requests.
+// Completed migrations are recorded in the Migrations collection, keyed by their names.
+type MigrationService struct {
+	Migrations *mongo.Collection
+	Users      *UserService
+	APIKeys    *mongo.Collection
+	Avatars    *mongo.Collection
+	Messages   *MessagingService
+	Activity   *mongo.Collection
+	Sessions   *SessionService
+}
+
+type MigrationChecker interface {
+	MigrationCompleted(c context.Context, name string) (bool, error)
+}
+
+func (m *MigrationService) MigrationCompleted(c context.Context, name string) (bool, error) {
+	err := m.Migrations.FindOne(c, bson.M{"_id": name}).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("mongo driver raised an error while fetching the migration: %v", err.Error())
+	}
+
+	return true, nil
+}
+
+// CanonicalizeUsernames must complete before the unique indexes of the users are built, since the index on
+// the usernames would not be unique otherwise. Usernames which more than one user would have in their canonical form
+// are reported, and the usernames are left as they are until they are resolved by hand.
+func (m *MigrationService) CanonicalizeUsernames(c context.Context) error {
+	if completed, err := m.MigrationCompleted(c, CanonicalUsernameMigration); err != nil || completed {
+		return err
+	}
+
+	cursor, err := m.Users.Collection.Find(c, bson.M{}, options.Find().SetProjection(bson.M{"username": 1, "usernames": 1, "profile": 1}))
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the usernames: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	users := make([]models.User, 0)
+	for cursor.Next(c) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return fmt.Errorf("cannot decode user: %v", err.Error())
+		}
+		users = append(users, user)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if collisions := usernameCollisions(users); len(collisions) > 0 {
+		return fmt.Errorf("usernames of different users are the same once they are case-insensitive, rename them first: %v", strings.Join(collisions, ", "))
+	}
+
+	for _, user := range users {
+		canonical := CanonicalUsername(user.Username)
+		usernames := canonicalUsernames(user)
+		if canonical == user.Username && reflect.DeepEqual(usernames, user.Usernames) {
+			continue
+		}
+
+		if _, err := m.Users.Collection.UpdateOne(c,
+			bson.M{"_id": user.UserID},
+			bson.M{"$set": bson.M{
+				"username":       canonical,
+				"usernames":      usernames,
+				"search_bigrams": SearchGrams(canonical, user.Profile.DisplayName),
+			}},
+		); err != nil {
+			return fmt.Errorf("mongo driver raised an error while canonicalizing the username: %v", err.Error())
+		}
+	}
+
+	if _, err := m.Migrations.UpdateOne(c,
+		bson.M{"_id": CanonicalUsernameMigration},
+		bson.M{"$set": bson.M{"completed_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while completing the migration: %v", err.Error())
+	}
+
+	return nil
+}
+
+// usernameCollisions describes every canonical username which more than one of the users would have.
+func usernameCollisions(users []models.User) []string {
+	owners := make(map[string][]string)
+	for _, user := range users {
+		for _, username := range canonicalUsernames(user) {
+			owners[username] = append(owners[username], user.Username)
+		}
+	}
+
+	collisions := make([]string, 0)
+	for username, users := range owners {
+		if len(users) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%q is shared by %q", username, users))
+		}
+	}
+	sort.Strings(collisions)
+
+	return collisions
+}
+
+// canonicalUsernames returns the canonical forms of every username the user has had, including the current one.
+func canonicalUsernames(user models.User) []string {
+	usernames := make([]string, 0, len(user.Usernames)+1)
+	seen := make(map[string]bool)
+	for _, username := range append([]string{user.Username}, user.Usernames...) {
+		if canonical := CanonicalUsername(username); !seen[canonical] {
+			seen[canonical] = true
+			usernames = append(usernames, canonical)
+		}
+	}
+
+	return usernames
+}
+
+// MigrateSuspensions gives the users who have been suspended before statuses were introduced the suspended status.
+// It must complete before the server starts serving requests, since they would be active otherwise.
+// It can be run again, so an interrupted migration is resumed on the next start.
+func (m *MigrationService) MigrateSuspensions(c context.Context) error {
+	if _, err := m.Users.Collection.UpdateMany(c,
+		bson.M{"suspended": true, "status": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": models.StatusSuspended}},
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while migrating the suspensions: %v", err.Error())
+	}
+
+	if _, err := m.Users.Collection.UpdateMany(c,
+		bson.M{"suspended": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"suspended": ""}},
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while removing the suspensions: %v", err.Error())
+	}
+
+	return nil
+}
+
+// PrepareUserIDs migrates the data which is only readable by the ids of the users.
+// It must complete before the server starts serving requests, but it is quick, since there is little of such data.
+// Every step can be run again, so an interrupted migration is resumed on the next start.
+func (m *MigrationService) PrepareUserIDs(c context.Context) error {
+	if _, err := m.Users.Collection.UpdateMany(c,
+		bson.M{"usernames": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"usernames": bson.A{"$username"}}}},
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while recording the usernames: %v", err.Error())
+	}
+
+	if err := m.migrateAPIKeys(c); err != nil {
+		return err
+	}
+
+	return m.migrateAvatars(c)
+}
+
+// MigrateUserIDs migrates the data which is readable by both the usernames and the ids of the users,
+// and then records that the migration has been completed. It can run in the background.
+func (m *MigrationService) MigrateUserIDs(c context.Context) error {
+	if completed, err := m.MigrationCompleted(c, UserIDMigration); err != nil || completed {
+		return err
+	}
+
+	if err := m.migrateMessages(c); err != nil {
+		return err
+	}
+	if err := m.migrateActivity(c); err != nil {
+		return err
+	}
+	if err := m.Sessions.MigrateSessions(c); err != nil {
+		return err
+	}
+
+	// Counters of the migrated messages were keyed by the usernames of the users
+	if err := m.deleteUsernameCounters(c); err != nil {
+		return err
+	}
+	if err := m.Messages.ReconcileUnreadCounters(c); err != nil {
+		return err
+	}
+
+	if _, err := m.Migrations.UpdateOne(c,
+		bson.M{"_id": UserIDMigration},
+		bson.M{"$set": bson.M{"completed_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while completing the migration: %v", err.Error())
+	}
+
+	return nil
+}
+
+// deleteUsernameCounters deletes the unread counters which are still keyed by usernames, which reconciliation
+// would keep at zero otherwise.
+func (m *MigrationService) deleteUsernameCounters(c context.Context) error {
+	receivers, err := m.Messages.Counters.CountedReceivers(c)
+	if err != nil {
+		return err
+	}
+
+	for _, receiver := range receivers {
+		if primitive.IsValidObjectID(receiver) {
+			continue
+		}
+		if err := m.Messages.Counters.DeleteUnread(c, receiver); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateAPIKeys revokes the api keys of the users who no longer exist.
+func (m *MigrationService) migrateAPIKeys(c context.Context) error {
+	cursor, err := m.APIKeys.Find(c,
+		bson.M{"user_id": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"username": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the api keys to migrate: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		var apiKey struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Username string             `bson:"username"`
+		}
+		if err := cursor.Decode(&apiKey); err != nil {
+			return fmt.Errorf("cannot decode api key: %v", err.Error())
+		}
+
+		userID, err := m.Users.ResolveUserID(c, CanonicalUsername(apiKey.Username))
+		if err == ErrNoUser {
+			_, err = m.APIKeys.DeleteOne(c, bson.M{"_id": apiKey.ID})
+		} else if err == nil {
+			_, err = m.APIKeys.UpdateOne(c,
+				bson.M{"_id": apiKey.ID},
+				bson.M{"$set": bson.M{"user_id": userID}, "$unset": bson.M{"username": ""}},
+			)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot migrate the api key: %v", err.Error())
+		}
+	}
+
+	return cursor.Err()
+}
+
+// migrateAvatars moves the avatars, which were keyed by the usernames of their users, under the ids of them.
+func (m *MigrationService) migrateAvatars(c context.Context) error {
+	cursor, err := m.Avatars.Find(c, bson.M{"_id": bson.M{"$type": "string"}})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the avatars to migrate: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		var legacy struct {
+			Username string `bson:"_id"`
+			Image    []byte `bson:"image"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			return fmt.Errorf("cannot decode avatar: %v", err.Error())
+		}
+
+		userID, err := m.Users.ResolveUserID(c, CanonicalUsername(legacy.Username))
+		if err != nil && err != ErrNoUser {
+			return err
+		}
+		if err == nil {
+			// An avatar which has been set under the id already is newer
+			if _, err := m.Avatars.UpdateOne(c,
+				bson.M{"_id": userID},
+				bson.M{"$setOnInsert": bson.M{"image": legacy.Image}},
+				options.Update().SetUpsert(true),
+			); err != nil {
+				return fmt.Errorf("mongo driver raised an error while migrating the avatar: %v", err.Error())
+			}
+		}
+
+		if _, err := m.Avatars.DeleteOne(c, bson.M{"_id": legacy.Username}); err != nil {
+			return fmt.Errorf("mongo driver raised an error while removing the avatar: %v", err.Error())
+		}
+	}
+
+	return cursor.Err()
+}
+
+// migrateMessages leaves the usernames of the users who no longer exist in their messages.
+func (m *MigrationService) migrateMessages(c context.Context) error {
+	cursor, err := m.Messages.Collection.Find(c,
+		bson.M{"$or": bson.A{
+			bson.M{"from_id": bson.M{"$exists": false}},
+			bson.M{"to_id": bson.M{"$exists": false}},
+		}},
+		options.Find().SetProjection(bson.M{"from": 1, "to": 1, "from_id": 1, "to_id": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the messages to migrate: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	resolve := m.cachedResolver()
+	for cursor.Next(c) {
+		var message models.Message
+		if err := cursor.Decode(&message); err != nil {
+			return fmt.Errorf("cannot decode the fetched message: %v", err.Error())
+		}
+
+		set, unset := bson.M{}, bson.M{}
+		for field, username := range map[string]string{"from": message.From, "to": message.To} {
+			if username == "" {
+				continue
+			}
+
+			userID, err := resolve(c, username)
+			if err == ErrNoUser {
+				continue
+			} else if err != nil {
+				return err
+			}
+			set[field+"_id"] = userID
+			unset[field] = ""
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		if _, err := m.Messages.Collection.UpdateOne(c,
+			bson.M{"_id": message.ID},
+			bson.M{"$set": set, "$unset": unset},
+		); err != nil {
+			return fmt.Errorf("mongo driver raised an error while migrating the message: %v", err.Error())
+		}
+	}
+
+	return cursor.Err()
+}
+
+// migrateActivity leaves the usernames in the activities of the users who no longer exist.
+func (m *MigrationService) migrateActivity(c context.Context) error {
+	cursor, err := m.Activity.Find(c,
+		bson.M{"user_id": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"username": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the activities to migrate: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	resolve := m.cachedResolver()
+	for cursor.Next(c) {
+		var activity struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Username string             `bson:"username"`
+		}
+		if err := cursor.Decode(&activity); err != nil {
+			return fmt.Errorf("cannot decode activity: %v", err.Error())
+		}
+
+		userID, err := resolve(c, activity.Username)
+		if err == ErrNoUser {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := m.Activity.UpdateOne(c,
+			bson.M{"_id": activity.ID},
+			bson.M{"$set": bson.M{"user_id": userID}, "$unset": bson.M{"username": ""}},
+		); err != nil {
+			return fmt.Errorf("mongo driver raised an error while migrating the activity: %v", err.Error())
+		}
+	}
+
+	return cursor.Err()
+}
+
+// cachedResolver resolves every username once, since most of the messages and activities belong to a few users.
+// The usernames are made canonical first, since the records may be older than canonical usernames.
+func (m *MigrationService) cachedResolver() func(c context.Context, username string) (primitive.ObjectID, error) {
+	resolved := make(map[string]primitive.ObjectID)
+	missing := make(map[string]bool)
+
+	return func(c context.Context, username string) (primitive.ObjectID, error) {
+		if userID, exists := resolved[username]; exists {
+			return userID, nil
+		}
+		if missing[username] {
+			return primitive.NilObjectID, ErrNoUser
+		}
+
+		userID, err := m.Users.ResolveUserID(c, CanonicalUsername(username))
+		if err == ErrNoUser {
+			missing[username] = true
+		} else if err == nil {
+			resolved[username] = userID
+		}
+		return userID, err
+	}
+}
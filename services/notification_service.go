@@ -0,0 +1,59 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_notification_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services Notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier delivers out-of-band notifications, such as password reset tokens, to users.
+type Notifier interface {
+	Notify(c context.Context, username, subject, message string) error
+}
+
+// LogNotifier writes notifications to the application log. It is meant for local development.
+type LogNotifier struct{}
+
+func (l LogNotifier) Notify(c context.Context, username, subject, message string) error {
+	logrus.WithFields(logrus.Fields{"username": username, "subject": subject}).Info(message)
+	return nil
+}
+
+// FileNotifier appends notifications to the file at Path, one JSON object per line.
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileNotifier) Notify(c context.Context, username, subject, message string) error {
+	line, err := json.Marshal(map[string]interface{}{
+		"username": username,
+		"subject":  subject,
+		"message":  message,
+		"when":     time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode the notification: %v", err.Error())
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open the notification file: %v", err.Error())
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("cannot write the notification: %v", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,414 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_oidc_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services OIDCAuthenticator,Reauthenticator
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redis/v8"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCService signs users in through an OpenID Connect identity provider with the authorization code flow and PKCE.
+//
+// The provider is discovered from "<Issuer>/.well-known/openid-configuration". Every login has a state, which is
+// kept in redis as "oidc_state:<hash>" for StateTTL along with the PKCE verifier and the nonce of the login,
+// and can only be completed once. Users who have confirmed their identity at the provider are kept as
+// "oidc_reauth:<user id>" for ReauthTTL.
+type OIDCService struct {
+	Store        *redis.Client
+	Client       *http.Client
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	StateTTL     time.Duration
+	ReauthTTL    time.Duration
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type OIDCAuthenticator interface {
+	BeginLogin(c context.Context, linkTo string) (string, string, error)
+	BeginReauthentication(c context.Context, userID string) (string, string, error)
+	CompleteLogin(c context.Context, state, code string) (models.OIDCLogin, error)
+}
+
+// Reauthenticator lets the users without a password confirm their identity at the identity provider instead,
+// before the actions which need the password of the others.
+type Reauthenticator interface {
+	ConfirmReauthentication(c context.Context, userID string) error
+	ConsumeReauthentication(c context.Context, userID string) (bool, error)
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	Issuer            string          `json:"iss"`
+	Subject           string          `json:"sub"`
+	Audience          json.RawMessage `json:"aud"`
+	ExpiresAt         int64           `json:"exp"`
+	Nonce             string          `json:"nonce"`
+	Email             string          `json:"email"`
+	PreferredUsername string          `json:"preferred_username"`
+}
+
+const oidcScopes = "openid profile email"
+
+// BeginLogin returns the url of the provider to redirect the user to, along with the state of the login.
+// If linkTo is set, the identity is linked to that user once the login is completed.
+func (o *OIDCService) BeginLogin(c context.Context, linkTo string) (string, string, error) {
+	return o.beginLogin(c, linkTo, "")
+}
+
+// BeginReauthentication is BeginLogin for the user with the id to confirm their identity, rather than to sign in.
+func (o *OIDCService) BeginReauthentication(c context.Context, userID string) (string, string, error) {
+	return o.beginLogin(c, "", userID)
+}
+
+func (o *OIDCService) beginLogin(c context.Context, linkTo, reauthenticate string) (string, string, error) {
+	discovery, err := o.discover(c)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	key := oidcStateKey(state)
+	pipe := o.Store.TxPipeline()
+	pipe.HSet(c, key, "verifier", verifier, "nonce", nonce, "link_to", linkTo, "reauthenticate", reauthenticate)
+	pipe.Expire(c, key, o.StateTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return "", "", fmt.Errorf("cannot store the login state: %v", err.Error())
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", o.ClientID)
+	query.Set("redirect_uri", o.RedirectURL)
+	query.Set("scope", oidcScopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), state, nil
+}
+
+// CompleteLogin exchanges the code the provider has redirected the user back with for the identity of the user.
+func (o *OIDCService) CompleteLogin(c context.Context, state, code string) (models.OIDCLogin, error) {
+	key := oidcStateKey(state)
+
+	// Fetching and deleting the state in a transaction makes sure that it can only be used once
+	pipe := o.Store.TxPipeline()
+	stored := pipe.HGetAll(c, key)
+	pipe.Del(c, key)
+	if _, err := pipe.Exec(c); err != nil {
+		return models.OIDCLogin{}, fmt.Errorf("cannot fetch the login state: %v", err.Error())
+	}
+
+	fields := stored.Val()
+	if len(fields) == 0 {
+		return models.OIDCLogin{}, ErrInvalidOIDCState
+	}
+
+	discovery, err := o.discover(c)
+	if err != nil {
+		return models.OIDCLogin{}, err
+	}
+
+	idToken, err := o.exchange(c, discovery, code, fields["verifier"])
+	if err != nil {
+		return models.OIDCLogin{}, err
+	}
+
+	claims, err := o.verifyIDToken(c, discovery, idToken, fields["nonce"])
+	if err != nil {
+		return models.OIDCLogin{}, err
+	}
+
+	return models.OIDCLogin{
+		Identity:          models.Identity{Issuer: claims.Issuer, Subject: claims.Subject, Email: claims.Email},
+		PreferredUsername: claims.PreferredUsername,
+		LinkTo:            fields["link_to"],
+		Reauthenticate:    fields["reauthenticate"],
+	}, nil
+}
+
+// ConfirmReauthentication records that the user has confirmed their identity at the provider, until
+// ConsumeReauthentication is called or ReauthTTL passes.
+func (o *OIDCService) ConfirmReauthentication(c context.Context, userID string) error {
+	if err := o.Store.Set(c, oidcReauthKey(userID), 1, o.ReauthTTL).Err(); err != nil {
+		return fmt.Errorf("cannot store the reauthentication: %v", err.Error())
+	}
+	return nil
+}
+
+// ConsumeReauthentication reports whether the user has recently confirmed their identity, which can be used only once.
+func (o *OIDCService) ConsumeReauthentication(c context.Context, userID string) (bool, error) {
+	deleted, err := o.Store.Del(c, oidcReauthKey(userID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("cannot fetch the reauthentication: %v", err.Error())
+	}
+	return deleted > 0, nil
+}
+
+// exchange returns the ID token the provider issues for the code.
+func (o *OIDCService) exchange(c context.Context, discovery *oidcDiscovery, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", o.RedirectURL)
+	form.Set("client_id", o.ClientID)
+	form.Set("code_verifier", verifier)
+
+	request, err := http.NewRequestWithContext(c, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("cannot create the token request: %v", err.Error())
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if o.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+	}
+
+	response, err := o.client().Do(request)
+	if err != nil {
+		return "", fmt.Errorf("cannot reach the token endpoint: %v", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", ErrOIDCCodeRejected
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("cannot decode the token response: %v", err.Error())
+	}
+	if tokens.IDToken == "" {
+		return "", ErrInvalidIDToken
+	}
+
+	return tokens.IDToken, nil
+}
+
+// verifyIDToken only accepts ID tokens signed with RS256, which every provider is required to support.
+func (o *OIDCService) verifyIDToken(c context.Context, discovery *oidcDiscovery, idToken, nonce string) (idTokenClaims, error) {
+	var claims idTokenClaims
+
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return claims, ErrInvalidIDToken
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Algorithm != "RS256" {
+		return claims, ErrInvalidIDToken
+	}
+
+	key, err := o.signingKey(c, discovery, header.KeyID)
+	if err != nil {
+		return claims, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrInvalidIDToken
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return claims, ErrInvalidIDToken
+	}
+
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return claims, ErrInvalidIDToken
+	}
+	if claims.Issuer != discovery.Issuer || claims.Subject == "" || !o.isAudience(claims.Audience) {
+		return claims, ErrInvalidIDToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt || claims.Nonce != nonce {
+		return claims, ErrInvalidIDToken
+	}
+
+	return claims, nil
+}
+
+// isAudience accepts both of the forms of the "aud" claim, a string and an array of strings.
+func (o *OIDCService) isAudience(audience json.RawMessage) bool {
+	var single string
+	if err := json.Unmarshal(audience, &single); err == nil {
+		return single == o.ClientID
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(audience, &multiple); err != nil {
+		return false
+	}
+	for _, a := range multiple {
+		if a == o.ClientID {
+			return true
+		}
+	}
+	return false
+}
+
+// discover does not hold the lock while fetching, so that a slow provider does not hold up the logins which
+// find the discovery document cached. Concurrent logins may fetch it more than once, which is harmless.
+func (o *OIDCService) discover(c context.Context) (*oidcDiscovery, error) {
+	o.mu.Lock()
+	cached := o.discovery
+	o.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := o.getJSON(c, strings.TrimSuffix(o.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("cannot discover the identity provider: %v", err.Error())
+	}
+	if discovery.Issuer != o.Issuer {
+		return nil, fmt.Errorf("identity provider reports a different issuer: %v", discovery.Issuer)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.discovery = &discovery
+	return o.discovery, nil
+}
+
+// signingKey refetches the keys of the provider if the key is unknown, since providers rotate their keys.
+// Like discover, it only holds the lock to read and replace the cached keys.
+func (o *OIDCService) signingKey(c context.Context, discovery *oidcDiscovery, keyId string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	key, exists := o.keys[keyId]
+	o.mu.Unlock()
+	if exists {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := o.getJSON(c, discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("cannot fetch the keys of the identity provider: %v", err.Error())
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	o.mu.Lock()
+	o.keys = keys
+	o.mu.Unlock()
+
+	if key, exists := keys[keyId]; exists {
+		return key, nil
+	}
+	return nil, ErrInvalidIDToken
+}
+
+func (o *OIDCService) getJSON(c context.Context, endpoint string, v interface{}) error {
+	request, err := http.NewRequestWithContext(c, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := o.client().Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %v", response.Status)
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+func (o *OIDCService) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return http.DefaultClient
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	bytes, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+func oidcStateKey(state string) string {
+	return "oidc_state:" + hashToken(state)
+}
+
+func oidcReauthKey(userID string) string {
+	return "oidc_reauth:" + userID
+}
+
+var ErrInvalidOIDCState error = fmt.Errorf("login state is invalid or expired")
+var ErrOIDCCodeRejected error = fmt.Errorf("identity provider has rejected the authorization code")
+var ErrInvalidIDToken error = fmt.Errorf("id token is invalid")
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redismock/v8"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// mockIdP is a minimal OpenID Connect provider, which issues the ID token made by IDToken for the code "somecode".
+type mockIdP struct {
+	*httptest.Server
+	Key      *rsa.PrivateKey
+	Verifier string
+	IDToken  func(issuer string) string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{Key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "somekey",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("code") != "somecode" || r.PostForm.Get("code_verifier") != idp.Verifier {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.IDToken(idp.URL), "token_type": "Bearer"})
+	})
+	idp.Server = httptest.NewServer(mux)
+
+	return idp
+}
+
+func (m *mockIdP) sign(key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "somekey"})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestBeginLogin(t *testing.T) {
+	idp := newMockIdP(t)
+	defer idp.Close()
+
+	db, mock := redismock.NewClientMock()
+	mock.Regexp().ExpectTxPipeline()
+	mock.Regexp().ExpectHSet(`oidc_state:[0-9a-f]{64}`, "verifier", `.+`, "nonce", `.+`, "link_to", "", "reauthenticate", "").SetVal(4)
+	mock.Regexp().ExpectExpire(`oidc_state:[0-9a-f]{64}`, 10*time.Minute).SetVal(true)
+	mock.ExpectTxPipelineExec()
+
+	service := OIDCService{Store: db, Issuer: idp.URL, ClientID: "armut", RedirectURL: "http://localhost/api/oidc/callback", StateTTL: 10 * time.Minute}
+	authURL, state, err := service.BeginLogin(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Scheme+"://"+parsed.Host+parsed.Path != idp.URL+"/authorize" {
+		t.Errorf("want %v, got %v", idp.URL+"/authorize", authURL)
+	}
+
+	query := parsed.Query()
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             "armut",
+		"redirect_uri":          "http://localhost/api/oidc/callback",
+		"state":                 state,
+		"code_challenge_method": "S256",
+	}
+	for param, value := range expected {
+		if query.Get(param) != value {
+			t.Errorf("%v: want %v, got %v", param, value, query.Get(param))
+		}
+	}
+	if query.Get("code_challenge") == "" || query.Get("nonce") == "" {
+		t.Errorf("want the code challenge and the nonce, got %v", authURL)
+	}
+}
+
+func TestCompleteLogin(t *testing.T) {
+	idp := newMockIdP(t)
+	defer idp.Close()
+	idp.Verifier = "someverifier"
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(issuer string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                issuer,
+			"sub":                "1234",
+			"aud":                "armut",
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              "somenonce",
+			"email":              "john@example.com",
+			"preferred_username": "johndoe",
+		}
+	}
+
+	tests := []struct {
+		Code          string
+		IDToken       func(issuer string) string
+		Expected      models.OIDCLogin
+		ExpectedError error
+	}{
+		{
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				return idp.sign(idp.Key, claims(issuer))
+			},
+			Expected: models.OIDCLogin{
+				Identity:          models.Identity{Issuer: idp.URL, Subject: "1234", Email: "john@example.com"},
+				PreferredUsername: "johndoe",
+				LinkTo:            "aliparlakci",
+			},
+			ExpectedError: nil,
+		}, {
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				c := claims(issuer)
+				c["aud"] = []string{"someoneelse", "armut"}
+				return idp.sign(idp.Key, c)
+			},
+			Expected: models.OIDCLogin{
+				Identity:          models.Identity{Issuer: idp.URL, Subject: "1234", Email: "john@example.com"},
+				PreferredUsername: "johndoe",
+				LinkTo:            "aliparlakci",
+			},
+			ExpectedError: nil,
+		}, {
+			Code: "wrongcode",
+			IDToken: func(issuer string) string {
+				return idp.sign(idp.Key, claims(issuer))
+			},
+			ExpectedError: ErrOIDCCodeRejected,
+		}, {
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				return idp.sign(otherKey, claims(issuer))
+			},
+			ExpectedError: ErrInvalidIDToken,
+		}, {
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				c := claims(issuer)
+				c["nonce"] = "othernonce"
+				return idp.sign(idp.Key, c)
+			},
+			ExpectedError: ErrInvalidIDToken,
+		}, {
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				c := claims(issuer)
+				c["aud"] = "someoneelse"
+				return idp.sign(idp.Key, c)
+			},
+			ExpectedError: ErrInvalidIDToken,
+		}, {
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				c := claims(issuer)
+				c["exp"] = time.Now().Add(-time.Minute).Unix()
+				return idp.sign(idp.Key, c)
+			},
+			ExpectedError: ErrInvalidIDToken,
+		}, {
+			Code: "somecode",
+			IDToken: func(issuer string) string {
+				return idp.sign(idp.Key, claims("https://attacker.example.com"))
+			},
+			ExpectedError: ErrInvalidIDToken,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			idp.IDToken = tt.IDToken
+
+			key := oidcStateKey("somestate")
+			db, mock := redismock.NewClientMock()
+			mock.ExpectTxPipeline()
+			mock.ExpectHGetAll(key).SetVal(map[string]string{"verifier": "someverifier", "nonce": "somenonce", "link_to": "aliparlakci"})
+			mock.ExpectDel(key).SetVal(1)
+			mock.ExpectTxPipelineExec()
+
+			service := OIDCService{Store: db, Issuer: idp.URL, ClientID: "armut", RedirectURL: "http://localhost/api/oidc/callback"}
+			result, err := service.CompleteLogin(context.Background(), "somestate", tt.Code)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestCompleteLoginWithUsedState(t *testing.T) {
+	key := oidcStateKey("somestate")
+	db, mock := redismock.NewClientMock()
+	mock.ExpectTxPipeline()
+	mock.ExpectHGetAll(key).SetVal(map[string]string{})
+	mock.ExpectDel(key).SetVal(0)
+	mock.ExpectTxPipelineExec()
+
+	service := OIDCService{Store: db, Issuer: "http://localhost:1", ClientID: "armut"}
+	if _, err := service.CompleteLogin(context.Background(), "somestate", "somecode"); err != ErrInvalidOIDCState {
+		t.Errorf("want %v, got %v", ErrInvalidOIDCState, err)
+	}
+}
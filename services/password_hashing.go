@@ -0,0 +1,168 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"math"
+	"strings"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+// HashingPolicy describes how new passwords are hashed.
+// Hashes are self-describing, so hashes made under an older policy can still be verified
+// and are upgraded to the current policy when their owner signs in.
+//
+// Argon2id hashes are encoded in the PHC string format:
+// $argon2id$v=19$m=<memory in KiB>,t=<iterations>,p=<threads>$<salt>$<hash>
+//
+// The parameters are ints, so that values which do not fit the types argon2 takes are rejected by Validate
+// instead of wrapping around.
+type HashingPolicy struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    int
+	Argon2Memory  int
+	Argon2Threads int
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Validate reports the policies which cannot hash passwords, or which argon2 would panic with.
+func (p HashingPolicy) Validate() error {
+	switch p.Algorithm {
+	case Bcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, p.BcryptCost)
+		}
+	case Argon2id:
+		if p.Argon2Time < 1 || uint64(p.Argon2Time) > math.MaxUint32 {
+			return fmt.Errorf("argon2 time must be between 1 and %d, got %d", uint32(math.MaxUint32), p.Argon2Time)
+		}
+		if p.Argon2Memory < 1 || uint64(p.Argon2Memory) > math.MaxUint32 {
+			return fmt.Errorf("argon2 memory must be between 1 and %d KiB, got %d", uint32(math.MaxUint32), p.Argon2Memory)
+		}
+		if p.Argon2Threads < 1 || p.Argon2Threads > math.MaxUint8 {
+			return fmt.Errorf("argon2 threads must be between 1 and %d, got %d", math.MaxUint8, p.Argon2Threads)
+		}
+	default:
+		return fmt.Errorf("unknown password hashing algorithm: %q", p.Algorithm)
+	}
+
+	return nil
+}
+
+func (p HashingPolicy) Hash(password string) (string, error) {
+	switch p.Algorithm {
+	case Bcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("cannot hash the password: %v", err.Error())
+		}
+		return string(bytes), nil
+	case Argon2id:
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("cannot generate a salt: %v", err.Error())
+		}
+		params := p.argon2Params()
+		key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLength)
+		return encodeArgon2id(params, salt, key), nil
+	default:
+		return "", fmt.Errorf("unknown password hashing algorithm: %v", p.Algorithm)
+	}
+}
+
+// Verify reports whether the password matches the hash, regardless of the algorithm it is made with.
+func (p HashingPolicy) Verify(hash, password string) (bool, error) {
+	if strings.HasPrefix(hash, "$"+Argon2id+"$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, actual) == 1, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("cannot verify the password: %v", err.Error())
+	}
+	return true, nil
+}
+
+// NeedsRehash reports whether the hash is made with a different algorithm or parameters than the policy.
+func (p HashingPolicy) NeedsRehash(hash string) bool {
+	switch p.Algorithm {
+	case Bcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != p.BcryptCost
+	case Argon2id:
+		params, _, _, err := decodeArgon2id(hash)
+		return err != nil || params != p.argon2Params()
+	default:
+		return false
+	}
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func (p HashingPolicy) argon2Params() argon2Params {
+	return argon2Params{memory: uint32(p.Argon2Memory), time: uint32(p.Argon2Time), threads: uint8(p.Argon2Threads)}
+}
+
+func encodeArgon2id(params argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$%v$v=%d$m=%d,t=%d,p=%d$%v$%v",
+		Argon2id, argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	// argon2 panics with no iterations or threads
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil || params.time == 0 || params.threads == 0 {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	return params, salt, key, nil
+}
+
+var ErrMalformedHash error = fmt.Errorf("password hash is malformed")
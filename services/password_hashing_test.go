@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+var (
+	bcryptPolicy   = HashingPolicy{Algorithm: Bcrypt, BcryptCost: 4}
+	argon2idPolicy = HashingPolicy{Algorithm: Argon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1}
+)
+
+func TestHashingPolicyVerify(t *testing.T) {
+	tests := []struct {
+		Policy   HashingPolicy
+		Password string
+		Attempt  string
+		Expected bool
+	}{
+		{Policy: bcryptPolicy, Password: "hunter2", Attempt: "hunter2", Expected: true},
+		{Policy: bcryptPolicy, Password: "hunter2", Attempt: "hunter3", Expected: false},
+		{Policy: argon2idPolicy, Password: "hunter2", Attempt: "hunter2", Expected: true},
+		{Policy: argon2idPolicy, Password: "hunter2", Attempt: "hunter3", Expected: false},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			hash, err := tt.Policy.Hash(tt.Password)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Verification must not depend on the policy the hash is made with
+			result, err := HashingPolicy{}.Verify(hash, tt.Attempt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestHashingPolicyNeedsRehash(t *testing.T) {
+	bcryptHash, _ := bcryptPolicy.Hash("hunter2")
+	argon2idHash, _ := argon2idPolicy.Hash("hunter2")
+
+	stronger := argon2idPolicy
+	stronger.Argon2Time = 2
+
+	tests := []struct {
+		Policy   HashingPolicy
+		Hash     string
+		Expected bool
+	}{
+		{Policy: bcryptPolicy, Hash: bcryptHash, Expected: false},
+		{Policy: argon2idPolicy, Hash: argon2idHash, Expected: false},
+		{Policy: argon2idPolicy, Hash: bcryptHash, Expected: true},
+		{Policy: bcryptPolicy, Hash: argon2idHash, Expected: true},
+		{Policy: stronger, Hash: argon2idHash, Expected: true},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			if result := tt.Policy.NeedsRehash(tt.Hash); result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestHashingPolicyValidate(t *testing.T) {
+	tests := []struct {
+		Policy   HashingPolicy
+		Expected bool
+	}{
+		{Policy: bcryptPolicy, Expected: true},
+		{Policy: argon2idPolicy, Expected: true},
+		{Policy: HashingPolicy{Algorithm: Bcrypt, BcryptCost: 3}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Bcrypt, BcryptCost: 32}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Argon2id, Argon2Time: 0, Argon2Memory: 1024, Argon2Threads: 1}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Argon2id, Argon2Time: 1, Argon2Memory: 0, Argon2Threads: 1}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Argon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 0}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Argon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 256}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Argon2id, Argon2Time: 1 << 32, Argon2Memory: 1024, Argon2Threads: 1}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: Argon2id, Argon2Time: -1, Argon2Memory: 1024, Argon2Threads: 1}, Expected: false},
+		{Policy: HashingPolicy{Algorithm: "scrypt"}, Expected: false},
+		{Policy: HashingPolicy{}, Expected: false},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			if err := tt.Policy.Validate(); (err == nil) != tt.Expected {
+				t.Errorf("want valid %v, got %v", tt.Expected, err)
+			}
+		})
+	}
+}
+
+func TestHashingPolicyVerifyMalformed(t *testing.T) {
+	// argon2 panics with no iterations or threads, which must not be read from a stored hash
+	for _, hash := range []string{
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+	} {
+		if _, err := (HashingPolicy{}).Verify(hash, "hunter2"); err != ErrMalformedHash {
+			t.Errorf("want %v, got %v", ErrMalformedHash, err)
+		}
+	}
+}
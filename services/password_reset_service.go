@@ -0,0 +1,83 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_password_reset_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services ResetTokenCreator,ResetTokenConsumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// PasswordResetService issues single-use password reset tokens which expire after TokenTTL.
+// Only the hashes of the tokens are stored, in "password_reset:<hash>", and a user can have
+// a single valid token at a time, which is tracked in "password_reset_user:<username>".
+type PasswordResetService struct {
+	Store    *redis.Client
+	TokenTTL time.Duration
+}
+
+type ResetTokenCreator interface {
+	CreateResetToken(c context.Context, username string) (string, error)
+}
+
+type ResetTokenConsumer interface {
+	ConsumeResetToken(c context.Context, token string) (string, error)
+}
+
+func (p *PasswordResetService) CreateResetToken(c context.Context, username string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	hash := hashToken(token)
+
+	previous, err := p.Store.Get(c, resetUserKey(username)).Result()
+	if err != nil && err != redis.Nil {
+		return "", fmt.Errorf("cannot fetch the previous reset token: %v", err.Error())
+	}
+
+	pipe := p.Store.TxPipeline()
+	if previous != "" {
+		pipe.Del(c, resetTokenKey(previous))
+	}
+	pipe.Set(c, resetTokenKey(hash), username, p.TokenTTL)
+	pipe.Set(c, resetUserKey(username), hash, p.TokenTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return "", fmt.Errorf("cannot store the reset token: %v", err.Error())
+	}
+
+	return token, nil
+}
+
+// ConsumeResetToken invalidates the token and returns the username it was issued for.
+// Returns ErrInvalidResetToken if the token does not exist, has expired or is already used.
+func (p *PasswordResetService) ConsumeResetToken(c context.Context, token string) (string, error) {
+	key := resetTokenKey(hashToken(token))
+
+	pipe := p.Store.TxPipeline()
+	get := pipe.Get(c, key)
+	pipe.Del(c, key)
+	if _, err := pipe.Exec(c); err == redis.Nil {
+		return "", ErrInvalidResetToken
+	} else if err != nil {
+		return "", fmt.Errorf("cannot consume the reset token: %v", err.Error())
+	}
+
+	username := get.Val()
+	if err := p.Store.Del(c, resetUserKey(username)).Err(); err != nil {
+		return "", fmt.Errorf("cannot consume the reset token: %v", err.Error())
+	}
+
+	return username, nil
+}
+
+func resetTokenKey(hash string) string {
+	return "password_reset:" + hash
+}
+
+func resetUserKey(username string) string {
+	return "password_reset_user:" + username
+}
+
+var ErrInvalidResetToken error = fmt.Errorf("reset token is invalid or expired")
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"testing"
+	"time"
+)
+
+func TestConsumeResetToken(t *testing.T) {
+	key := "password_reset:" + hashToken("sometoken")
+
+	tests := []struct {
+		Token         string
+		Prepare       func(client *redismock.ClientMock)
+		Expected      string
+		ExpectedError error
+	}{
+		{
+			Token: "sometoken",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectTxPipeline()
+				(*client).ExpectGet(key).SetVal("aliparlakci")
+				(*client).ExpectDel(key).SetVal(1)
+				(*client).ExpectTxPipelineExec()
+				(*client).ExpectDel("password_reset_user:aliparlakci").SetVal(1)
+			},
+			Expected:      "aliparlakci",
+			ExpectedError: nil,
+		}, {
+			Token: "sometoken",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectTxPipeline()
+				(*client).ExpectGet(key).SetErr(redis.Nil)
+				(*client).ExpectDel(key).SetVal(0)
+				(*client).ExpectTxPipelineExec()
+			},
+			Expected:      "",
+			ExpectedError: ErrInvalidResetToken,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := PasswordResetService{Store: db, TokenTTL: time.Hour}
+			result, err := service.ConsumeResetToken(context.Background(), tt.Token)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
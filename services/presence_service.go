@@ -0,0 +1,114 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_presence_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services PresenceTracker,PresenceGetter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"time"
+)
+
+// PresenceService keeps when every user was last active in "presence:<id>", as a unix timestamp
+// which expires after LastSeenTTL. Users are online if they were active within OnlineWindow,
+// and away if they were active within AwayWindow.
+//
+// Every authenticated request counts as activity, and so do the live connections of the presence stream,
+// which keep their user online for as long as they are open.
+type PresenceService struct {
+	Store        *redis.Client
+	Users        UserIDResolver
+	OnlineWindow time.Duration
+	AwayWindow   time.Duration
+	LastSeenTTL  time.Duration
+}
+
+type PresenceTracker interface {
+	TouchPresence(c context.Context, userID primitive.ObjectID) error
+	ForgetPresence(c context.Context, userID primitive.ObjectID) error
+}
+
+type PresenceGetter interface {
+	GetPresences(c context.Context, usernames []string) ([]models.Presence, error)
+}
+
+// TouchPresence marks the user as active now.
+func (p *PresenceService) TouchPresence(c context.Context, userID primitive.ObjectID) error {
+	if err := p.Store.Set(c, presenceKey(userID), time.Now().Unix(), p.LastSeenTTL).Err(); err != nil {
+		return fmt.Errorf("cannot touch the presence: %v", err.Error())
+	}
+
+	return nil
+}
+
+// ForgetPresence removes when the user was last active, so that they appear offline.
+func (p *PresenceService) ForgetPresence(c context.Context, userID primitive.ObjectID) error {
+	if err := p.Store.Del(c, presenceKey(userID)).Err(); err != nil {
+		return fmt.Errorf("cannot forget the presence: %v", err.Error())
+	}
+
+	return nil
+}
+
+// GetPresences returns the presence of the users in the given order. Users who do not exist are offline.
+func (p *PresenceService) GetPresences(c context.Context, usernames []string) ([]models.Presence, error) {
+	presences := make([]models.Presence, len(usernames))
+	for i, username := range usernames {
+		presences[i] = models.Presence{Username: username, Status: models.PresenceOffline}
+	}
+	if len(usernames) == 0 {
+		return presences, nil
+	}
+
+	ids, err := p.Users.ResolveUserIDs(c, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []int{}
+	keys := []string{}
+	for i, username := range usernames {
+		if id, exists := ids[username]; exists {
+			indexes = append(indexes, i)
+			keys = append(keys, presenceKey(id))
+		}
+	}
+	if len(keys) == 0 {
+		return presences, nil
+	}
+
+	values, err := p.Store.MGet(c, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch the presences: %v", err.Error())
+	}
+
+	now := time.Now()
+	for j, i := range indexes {
+		value, ok := values[j].(string)
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		lastSeen := time.Unix(seconds, 0)
+		presences[i].LastSeen = &lastSeen
+		switch idle := now.Sub(lastSeen); {
+		case idle < p.OnlineWindow:
+			presences[i].Status = models.PresenceOnline
+		case idle < p.AwayWindow:
+			presences[i].Status = models.PresenceAway
+		}
+	}
+
+	return presences, nil
+}
+
+func presenceKey(userID primitive.ObjectID) string {
+	return "presence:" + userID.Hex()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redismock/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetPresences(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) string { return strconv.FormatInt(now.Add(-d).Unix(), 10) }
+	johndoeID, janedoeID := primitive.NewObjectID(), primitive.NewObjectID()
+	users := fakeResolver{"aliparlakci": aliparlakciID, "johndoe": johndoeID, "janedoe": janedoeID}
+
+	tests := []struct {
+		Usernames        []string
+		Prepare          func(client *redismock.ClientMock)
+		ExpectedStatuses []string
+		ExpectedSeen     []bool
+	}{
+		{
+			Usernames: []string{"aliparlakci", "johndoe", "janedoe", "veli"},
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectMGet("presence:"+aliparlakciID.Hex(), "presence:"+johndoeID.Hex(), "presence:"+janedoeID.Hex()).
+					SetVal([]interface{}{ago(10 * time.Second), ago(5 * time.Minute), ago(time.Hour)})
+			},
+			ExpectedStatuses: []string{models.PresenceOnline, models.PresenceAway, models.PresenceOffline, models.PresenceOffline},
+			ExpectedSeen:     []bool{true, true, true, false},
+		}, {
+			Usernames: []string{"aliparlakci", "veli"},
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectMGet("presence:" + aliparlakciID.Hex()).SetVal([]interface{}{nil})
+			},
+			ExpectedStatuses: []string{models.PresenceOffline, models.PresenceOffline},
+			ExpectedSeen:     []bool{false, false},
+		}, {
+			Usernames:        []string{"veli"},
+			Prepare:          func(client *redismock.ClientMock) {},
+			ExpectedStatuses: []string{models.PresenceOffline},
+			ExpectedSeen:     []bool{false},
+		}, {
+			Usernames:        []string{},
+			Prepare:          func(client *redismock.ClientMock) {},
+			ExpectedStatuses: []string{},
+			ExpectedSeen:     []bool{},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := PresenceService{Store: db, Users: users, OnlineWindow: 2 * time.Minute, AwayWindow: 15 * time.Minute}
+			result, err := service.GetPresences(context.Background(), tt.Usernames)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(tt.ExpectedStatuses) {
+				t.Fatalf("want %v presences, got %v", len(tt.ExpectedStatuses), len(result))
+			}
+			for j, presence := range result {
+				if presence.Username != tt.Usernames[j] {
+					t.Errorf("want %v, got %v", tt.Usernames[j], presence.Username)
+				}
+				if presence.Status != tt.ExpectedStatuses[j] {
+					t.Errorf("%v: want %v, got %v", presence.Username, tt.ExpectedStatuses[j], presence.Status)
+				}
+				if (presence.LastSeen != nil) != tt.ExpectedSeen[j] {
+					t.Errorf("%v: want last seen %v, got %v", presence.Username, tt.ExpectedSeen[j], presence.LastSeen)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_profile_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services ProfileGetter,ProfileUpdater,AvatarGetter,UserSearcher
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ProfileService manages the profiles of the users, which are kept in the users collection.
+// Avatar thumbnails are kept in the avatars collection, keyed by the id of the user.
+type ProfileService struct {
+	Users   *mongo.Collection
+	Avatars *mongo.Collection
+}
+
+type ProfileGetter interface {
+	GetProfile(c context.Context, username string) (models.PublicProfile, error)
+	GetProfilesByID(c context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.PublicProfile, error)
+}
+
+type ProfileUpdater interface {
+	UpdateProfile(c context.Context, username string, form models.ProfileForm, image io.Reader) error
+}
+
+type AvatarGetter interface {
+	GetAvatar(c context.Context, username string) (models.Avatar, error)
+}
+
+// UserSearcher searches the user directory, which leaves out private users and those who are not active.
+type UserSearcher interface {
+	SearchUsers(c context.Context, query string, skip, limit int64) ([]models.PublicProfile, error)
+}
+
+const (
+	maxDisplayNameLength = 64
+	maxBioLength         = 280
+)
+
+type avatar struct {
+	UserID primitive.ObjectID `bson:"_id"`
+	Image  []byte             `bson:"image"`
+}
+
+func (p *ProfileService) EnsureIndexes(c context.Context) error {
+	if _, err := p.Users.Indexes().CreateOne(c, mongo.IndexModel{Keys: bson.M{"search_bigrams": 1}}); err != nil {
+		return fmt.Errorf("mongo driver raised an error while creating the profile indexes: %v", err.Error())
+	}
+
+	return nil
+}
+
+// IndexSearchGrams sets the search grams of the users who have signed up before the user directory existed.
+func (p *ProfileService) IndexSearchGrams(c context.Context) error {
+	cursor, err := p.Users.Find(c,
+		bson.M{"search_bigrams": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"username": 1, "profile": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the users to index: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return fmt.Errorf("cannot decode user: %v", err.Error())
+		}
+
+		if _, err := p.Users.UpdateOne(c,
+			bson.M{"_id": user.UserID},
+			bson.M{"$set": bson.M{"search_bigrams": SearchGrams(user.Username, user.Profile.DisplayName)}},
+		); err != nil {
+			return fmt.Errorf("mongo driver raised an error while indexing the user: %v", err.Error())
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (p *ProfileService) GetProfile(c context.Context, username string) (models.PublicProfile, error) {
+	var user models.User
+
+	result := p.Users.FindOne(c, bson.M{"username": username}, options.FindOne().SetProjection(bson.M{"username": 1, "profile": 1}))
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return models.PublicProfile{}, ErrNoUser
+	} else if err != nil {
+		return models.PublicProfile{}, fmt.Errorf("mongo driver raised an error while fetching the profile: %v", err.Error())
+	}
+
+	if err := result.Decode(&user); err != nil {
+		return models.PublicProfile{}, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return publicProfile(user), nil
+}
+
+// GetProfilesByID fetches the profiles of the users at once. Users who do not exist are left out.
+func (p *ProfileService) GetProfilesByID(c context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.PublicProfile, error) {
+	profiles := make(map[primitive.ObjectID]models.PublicProfile, len(ids))
+	if len(ids) == 0 {
+		return profiles, nil
+	}
+
+	cursor, err := p.Users.Find(c,
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"username": 1, "profile": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while fetching the profiles: %v", err.Error())
+	}
+
+	for cursor.Next(c) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, fmt.Errorf("cannot decode user: %v", err.Error())
+		}
+
+		profiles[user.UserID] = publicProfile(user)
+	}
+
+	return profiles, nil
+}
+
+// UpdateProfile only updates the fields of the form which are not nil. The image, which can be nil, is stored as
+// the avatar of the user, unless the form removes the avatar. Every field and the image are validated before anything
+// is written, so that an invalid form does not update the profile partially.
+func (p *ProfileService) UpdateProfile(c context.Context, username string, form models.ProfileForm, image io.Reader) error {
+	set := bson.M{}
+	unset := bson.M{}
+	if form.DisplayName != nil {
+		name := strings.TrimSpace(*form.DisplayName)
+		if !validProfileText(name, maxDisplayNameLength, false) {
+			return ErrInvalidDisplayName
+		}
+		set["profile.display_name"] = name
+		set["search_bigrams"] = SearchGrams(username, name)
+	}
+	if form.Bio != nil {
+		text := strings.TrimSpace(*form.Bio)
+		if !validProfileText(text, maxBioLength, true) {
+			return ErrInvalidBio
+		}
+		set["profile.bio"] = text
+	}
+	var thumbnail []byte
+	if form.RemoveAvatar {
+		unset["profile.avatar_version"] = ""
+	} else if image != nil {
+		var err error
+		if thumbnail, err = makeThumbnail(image); err != nil {
+			return err
+		}
+		version, err := randomToken(6)
+		if err != nil {
+			return err
+		}
+		set["profile.avatar_version"] = version
+	}
+
+	if len(set) == 0 && len(unset) == 0 {
+		return nil
+	}
+
+	userID, err := p.userID(c, username)
+	if err != nil {
+		return err
+	}
+
+	// The avatar is stored before the profile references its version
+	if thumbnail != nil {
+		if _, err := p.Avatars.ReplaceOne(c,
+			bson.M{"_id": userID},
+			avatar{UserID: userID, Image: thumbnail},
+			options.Replace().SetUpsert(true),
+		); err != nil {
+			return fmt.Errorf("mongo driver raised an error while storing the avatar: %v", err.Error())
+		}
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := p.Users.UpdateOne(c, bson.M{"_id": userID}, update); err != nil {
+		return fmt.Errorf("mongo driver raised an error while updating the profile: %v", err.Error())
+	}
+
+	if form.RemoveAvatar {
+		if _, err := p.Avatars.DeleteOne(c, bson.M{"_id": userID}); err != nil {
+			return fmt.Errorf("mongo driver raised an error while removing the avatar: %v", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// GetAvatar returns the avatar of the user as PNG, with its version and whether the profile of the user is private.
+func (p *ProfileService) GetAvatar(c context.Context, username string) (models.Avatar, error) {
+	var user models.User
+	var a avatar
+
+	result := p.Users.FindOne(c, bson.M{"username": username}, options.FindOne().SetProjection(bson.M{"_id": 1, "profile": 1}))
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return models.Avatar{}, ErrNoAvatar
+	} else if err != nil {
+		return models.Avatar{}, fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+	if err := result.Decode(&user); err != nil {
+		return models.Avatar{}, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	result = p.Avatars.FindOne(c, bson.M{"_id": user.UserID})
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return models.Avatar{}, ErrNoAvatar
+	} else if err != nil {
+		return models.Avatar{}, fmt.Errorf("mongo driver raised an error while fetching the avatar: %v", err.Error())
+	}
+
+	if err := result.Decode(&a); err != nil {
+		return models.Avatar{}, fmt.Errorf("cannot decode the avatar: %v", err.Error())
+	}
+
+	return models.Avatar{Image: a.Image, Version: user.Profile.AvatarVersion, Private: user.Profile.Private}, nil
+}
+
+// SearchUsers finds the users whose username or display name starts with the query, or almost does.
+// The users who share the most search grams with the query are ranked, best matches first.
+func (p *ProfileService) SearchUsers(c context.Context, query string, skip, limit int64) ([]models.PublicProfile, error) {
+	query, grams, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := p.Users.Aggregate(c, mongo.Pipeline{
+		{{Key: "$match", Value: searchFilter(grams)}},
+		{{Key: "$project", Value: bson.M{
+			"username": 1,
+			"profile":  1,
+			"score":    bson.M{"$size": bson.M{"$setIntersection": bson.A{"$search_bigrams", grams}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "username", Value: 1}}}},
+		{{Key: "$limit", Value: maxSearchCandidates}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while searching the users: %v", err.Error())
+	}
+
+	var candidates []searchCandidate
+	if err := cursor.All(c, &candidates); err != nil {
+		return nil, fmt.Errorf("cannot decode users: %v", err.Error())
+	}
+
+	return searchResults(query, candidates, skip, limit), nil
+}
+
+// parseSearchQuery returns the query in the form usernames are stored in, and its search grams.
+func parseSearchQuery(query string) (string, []string, error) {
+	query = CanonicalUsername(query)
+	if length := len([]rune(query)); length < MinSearchQueryLength || length > maxSearchQueryLength {
+		return "", nil, ErrInvalidSearchQuery
+	}
+
+	grams := queryGrams(query)
+	if len(grams) == 0 {
+		return "", nil, ErrInvalidSearchQuery
+	}
+
+	return query, grams, nil
+}
+
+// searchFilter matches the users in the user directory who share a search gram with the query.
+func searchFilter(grams []string) bson.M {
+	return bson.M{
+		"search_bigrams":  bson.M{"$in": grams},
+		"status":          bson.M{"$nin": bson.A{models.StatusSuspended, models.StatusDeactivated}},
+		"profile.private": bson.M{"$ne": true},
+	}
+}
+
+// searchResults ranks the candidates and returns the page of the profiles which match the query.
+func searchResults(query string, candidates []searchCandidate, skip, limit int64) []models.PublicProfile {
+	matches := rankProfiles(query, candidates)
+	profiles := []models.PublicProfile{}
+	for i := skip; i < skip+limit && i < int64(len(matches)); i++ {
+		profiles = append(profiles, publicProfile(models.User{Username: matches[i].Username, Profile: matches[i].Profile}))
+	}
+
+	return profiles
+}
+
+func (p *ProfileService) userID(c context.Context, username string) (primitive.ObjectID, error) {
+	var user models.User
+
+	result := p.Users.FindOne(c, bson.M{"username": username}, options.FindOne().SetProjection(bson.M{"_id": 1}))
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return primitive.NilObjectID, ErrNoUser
+	} else if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+
+	if err := result.Decode(&user); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return user.UserID, nil
+}
+
+func publicProfile(user models.User) models.PublicProfile {
+	profile := models.PublicProfile{
+		Username:    user.Username,
+		DisplayName: user.Profile.DisplayName,
+		Bio:         user.Profile.Bio,
+	}
+	if profile.DisplayName == "" {
+		profile.DisplayName = user.Username
+	}
+	if user.Profile.AvatarVersion != "" {
+		profile.AvatarURL = "/api/users/" + url.PathEscape(user.Username) + "/avatar?v=" + user.Profile.AvatarVersion
+	}
+
+	return profile
+}
+
+// validProfileText rejects control characters, which could be used to spoof the text around it, except for line breaks if they are allowed.
+func validProfileText(text string, maxLength int, multiline bool) bool {
+	if !utf8.ValidString(text) || utf8.RuneCountInString(text) > maxLength {
+		return false
+	}
+
+	for _, r := range text {
+		if r == '\n' && multiline {
+			continue
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var ErrInvalidDisplayName error = fmt.Errorf("display name must be at most %d characters", maxDisplayNameLength)
+var ErrInvalidBio error = fmt.Errorf("bio must be at most %d characters", maxBioLength)
+var ErrInvalidSearchQuery error = fmt.Errorf("search query must be %d to %d characters", MinSearchQueryLength, maxSearchQueryLength)
+var ErrNoAvatar error = fmt.Errorf("user does not have an avatar")
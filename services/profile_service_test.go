@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"strings"
+	"testing"
+)
+
+// TestUpdateProfileValidatesFirst uses a ProfileService without collections, which panics if anything is written.
+func TestUpdateProfileValidatesFirst(t *testing.T) {
+	name := "John Doe"
+	invalidName := "John\u202eDoe"
+	bio := "hello"
+
+	tests := []struct {
+		Form          models.ProfileForm
+		Image         string
+		ExpectedError error
+	}{
+		{Form: models.ProfileForm{DisplayName: &name, Bio: &bio}, Image: "definitely not an image", ExpectedError: ErrInvalidAvatar},
+		{Form: models.ProfileForm{DisplayName: &invalidName}, Image: "definitely not an image", ExpectedError: ErrInvalidDisplayName},
+		{Form: models.ProfileForm{}, ExpectedError: nil},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			p := &ProfileService{}
+
+			var err error
+			if tt.Image != "" {
+				err = p.UpdateProfile(context.Background(), "johndoe", tt.Form, strings.NewReader(tt.Image))
+			} else {
+				err = p.UpdateProfile(context.Background(), "johndoe", tt.Form, nil)
+			}
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_proof_of_work_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services ProofOfWorkIssuer,ProofOfWorkVerifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redis/v8"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Challenges can only be used for the purpose they are issued for.
+const (
+	PurposeSignup = "signup"
+	PurposeSignin = "signin"
+)
+
+// difficultyCacheTTL keeps the failures and the signups from being counted for every challenge.
+const difficultyCacheTTL = 10 * time.Second
+
+// ProofOfWorkService issues hashcash-style challenges, which are "<purpose>.<difficulty>.<expires at>.<nonce>.<signature>",
+// signed with SigningKey, so that they do not need to be stored until they are solved. Solved challenges are kept in the
+// "pow:<hash>" keys until they expire, so that every challenge is only accepted once.
+//
+// The difficulty of signin challenges is BaseDifficulty bits, plus a bit for every doubling of the signin failures in
+// the last FailureWindow beyond FailuresPerBit. Signup challenges scale the same way with the signups in the last
+// FailureWindow beyond SignupsPerBit, since automated signups do not fail. Neither goes beyond MaxDifficulty bits.
+// Solutions are only required when Required is set.
+type ProofOfWorkService struct {
+	Store          *redis.Client
+	Failures       FailureCounter
+	Signups        SignupCounter
+	SigningKey     []byte
+	Required       bool
+	BaseDifficulty int
+	MaxDifficulty  int
+	FailuresPerBit int
+	SignupsPerBit  int
+	FailureWindow  time.Duration
+	ChallengeTTL   time.Duration
+
+	mu           sync.Mutex
+	difficulties map[string]cachedDifficulty
+}
+
+type cachedDifficulty struct {
+	difficulty int
+	at         time.Time
+}
+
+type ProofOfWorkIssuer interface {
+	IssueChallenge(c context.Context, purpose string) (models.Challenge, error)
+}
+
+type ProofOfWorkVerifier interface {
+	ProofOfWorkRequired() bool
+	VerifyProofOfWork(c context.Context, purpose, challenge, solution string) error
+}
+
+func (p *ProofOfWorkService) IssueChallenge(c context.Context, purpose string) (models.Challenge, error) {
+	if purpose != PurposeSignup && purpose != PurposeSignin {
+		return models.Challenge{}, ErrUnknownPurpose
+	}
+
+	difficulty := p.currentDifficulty(c, purpose)
+
+	nonce, err := randomToken(16)
+	if err != nil {
+		return models.Challenge{}, err
+	}
+
+	expiresAt := time.Now().Add(p.ChallengeTTL).Truncate(time.Second)
+	unsigned := strings.Join([]string{purpose, strconv.Itoa(difficulty), strconv.FormatInt(expiresAt.Unix(), 10), nonce}, ".")
+
+	return models.Challenge{
+		Challenge:  unsigned + "." + base64.RawURLEncoding.EncodeToString(p.sign(unsigned)),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+func (p *ProofOfWorkService) ProofOfWorkRequired() bool {
+	return p.Required
+}
+
+// VerifyProofOfWork accepts the solution of a challenge which has been issued for the purpose, has not expired
+// and has not been used before.
+func (p *ProofOfWorkService) VerifyProofOfWork(c context.Context, purpose, challenge, solution string) error {
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 5 || parts[0] != purpose {
+		return ErrInvalidProofOfWork
+	}
+
+	unsigned := strings.Join(parts[:4], ".")
+	signature, err := base64.RawURLEncoding.DecodeString(parts[4])
+	if err != nil || !hmac.Equal(signature, p.sign(unsigned)) {
+		return ErrInvalidProofOfWork
+	}
+
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ErrInvalidProofOfWork
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ErrInvalidProofOfWork
+	}
+
+	if time.Now().Unix() >= expiresAt || leadingZeroBits(sha256.Sum256([]byte(challenge+":"+solution))) < difficulty {
+		return ErrInvalidProofOfWork
+	}
+
+	pipe := p.Store.TxPipeline()
+	fresh := pipe.SetNX(c, proofOfWorkKey(challenge), 1, 0)
+	pipe.ExpireAt(c, proofOfWorkKey(challenge), time.Unix(expiresAt, 0))
+	if _, err := pipe.Exec(c); err != nil {
+		return fmt.Errorf("cannot record the solved challenge: %v", err.Error())
+	}
+	if !fresh.Val() {
+		return ErrInvalidProofOfWork
+	}
+
+	return nil
+}
+
+// currentDifficulty falls back to the base difficulty if the attempts cannot be counted,
+// since challenges must still be issued when the database is unavailable. The lock is not held while counting,
+// so that a slow count does not hold up the challenges whose difficulty is cached.
+func (p *ProofOfWorkService) currentDifficulty(c context.Context, purpose string) int {
+	p.mu.Lock()
+	cached, ok := p.difficulties[purpose]
+	p.mu.Unlock()
+	if ok && time.Since(cached.at) < difficultyCacheTTL {
+		return cached.difficulty
+	}
+
+	since := time.Now().Add(-p.FailureWindow)
+	var attempts int64
+	var err error
+	var perBit int
+	if purpose == PurposeSignup {
+		attempts, err = p.Signups.CountSignups(c, since)
+		perBit = p.SignupsPerBit
+	} else {
+		attempts, err = p.Failures.CountFailures(c, since)
+		perBit = p.FailuresPerBit
+	}
+	if err != nil {
+		return p.BaseDifficulty
+	}
+
+	difficulty := scaledDifficulty(p.BaseDifficulty, p.MaxDifficulty, perBit, attempts)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.difficulties == nil {
+		p.difficulties = make(map[string]cachedDifficulty)
+	}
+	p.difficulties[purpose] = cachedDifficulty{difficulty: difficulty, at: time.Now()}
+	return difficulty
+}
+
+func (p *ProofOfWorkService) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, p.SigningKey)
+	mac.Write([]byte("pow:" + unsigned))
+	return mac.Sum(nil)
+}
+
+// scaledDifficulty adds a bit for every doubling of the attempts beyond attemptsPerBit, which doubles the work
+// clients need to do every time the attempts double.
+func scaledDifficulty(base, max, attemptsPerBit int, attempts int64) int {
+	difficulty := base
+	if attemptsPerBit > 0 {
+		difficulty += bits.Len64(uint64(attempts / int64(attemptsPerBit)))
+	}
+
+	if difficulty > max {
+		return max
+	}
+	return difficulty
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+func proofOfWorkKey(challenge string) string {
+	return "pow:" + hashToken(challenge)
+}
+
+var ErrUnknownPurpose error = fmt.Errorf("purpose must be %q or %q", PurposeSignup, PurposeSignin)
+var ErrInvalidProofOfWork error = fmt.Errorf("proof of work is invalid, expired or already used")
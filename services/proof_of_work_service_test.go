@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/go-redis/redismock/v8"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeFailures int64
+
+func (f fakeFailures) CountFailures(c context.Context, since time.Time) (int64, error) {
+	return int64(f), nil
+}
+
+type fakeSignups int64
+
+func (f fakeSignups) CountSignups(c context.Context, since time.Time) (int64, error) {
+	return int64(f), nil
+}
+
+func TestCurrentDifficulty(t *testing.T) {
+	tests := []struct {
+		Purpose  string
+		Failures int64
+		Signups  int64
+		Expected int
+	}{
+		{Purpose: PurposeSignin, Failures: 10, Signups: 0, Expected: 17},
+		{Purpose: PurposeSignin, Failures: 0, Signups: 1000, Expected: 16},
+		{Purpose: PurposeSignup, Failures: 0, Signups: 40, Expected: 18},
+		{Purpose: PurposeSignup, Failures: 1000, Signups: 0, Expected: 16},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			service := ProofOfWorkService{
+				Failures:       fakeFailures(tt.Failures),
+				Signups:        fakeSignups(tt.Signups),
+				BaseDifficulty: 16,
+				MaxDifficulty:  24,
+				FailuresPerBit: 10,
+				SignupsPerBit:  20,
+				FailureWindow:  time.Minute,
+			}
+
+			if result := service.currentDifficulty(context.Background(), tt.Purpose); result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestScaledDifficulty(t *testing.T) {
+	tests := []struct {
+		Failures int64
+		Expected int
+	}{
+		{Failures: 0, Expected: 16},
+		{Failures: 9, Expected: 16},
+		{Failures: 10, Expected: 17},
+		{Failures: 39, Expected: 18},
+		{Failures: 40, Expected: 19},
+		{Failures: 100000, Expected: 24},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			if result := scaledDifficulty(16, 24, 10, tt.Failures); result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+// solve returns the first solution of the challenge which does, or does not, meet its difficulty.
+func solve(challenge string, difficulty int, valid bool) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		if (leadingZeroBits(sha256.Sum256([]byte(challenge+":"+solution))) >= difficulty) == valid {
+			return solution
+		}
+	}
+}
+
+func TestVerifyProofOfWork(t *testing.T) {
+	issuer := ProofOfWorkService{
+		Failures:       fakeFailures(0),
+		Signups:        fakeSignups(0),
+		SigningKey:     []byte("secret"),
+		BaseDifficulty: 8,
+		MaxDifficulty:  8,
+		ChallengeTTL:   time.Minute,
+	}
+	issued, err := issuer.IssueChallenge(context.Background(), PurposeSignup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := proofOfWorkKey(issued.Challenge)
+
+	parts := strings.Split(issued.Challenge, ".")
+	easier := strings.Join(append([]string{parts[0], "1"}, parts[2:]...), ".")
+
+	tests := []struct {
+		Purpose       string
+		Challenge     string
+		Solution      string
+		Prepare       func(client *redismock.ClientMock)
+		ExpectedError error
+	}{
+		{
+			Purpose:   PurposeSignup,
+			Challenge: issued.Challenge,
+			Solution:  solve(issued.Challenge, 8, true),
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectTxPipeline()
+				(*client).ExpectSetNX(key, 1, 0).SetVal(true)
+				(*client).ExpectExpireAt(key, issued.ExpiresAt).SetVal(true)
+				(*client).ExpectTxPipelineExec()
+			},
+			ExpectedError: nil,
+		}, {
+			Purpose:   PurposeSignup,
+			Challenge: issued.Challenge,
+			Solution:  solve(issued.Challenge, 8, true),
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectTxPipeline()
+				(*client).ExpectSetNX(key, 1, 0).SetVal(false)
+				(*client).ExpectExpireAt(key, issued.ExpiresAt).SetVal(true)
+				(*client).ExpectTxPipelineExec()
+			},
+			ExpectedError: ErrInvalidProofOfWork,
+		}, {
+			Purpose:       PurposeSignup,
+			Challenge:     issued.Challenge,
+			Solution:      solve(issued.Challenge, 8, false),
+			ExpectedError: ErrInvalidProofOfWork,
+		}, {
+			Purpose:       PurposeSignin,
+			Challenge:     issued.Challenge,
+			Solution:      solve(issued.Challenge, 8, true),
+			ExpectedError: ErrInvalidProofOfWork,
+		}, {
+			Purpose:       PurposeSignup,
+			Challenge:     easier,
+			Solution:      solve(easier, 1, true),
+			ExpectedError: ErrInvalidProofOfWork,
+		}, {
+			Purpose:       PurposeSignup,
+			Challenge:     "signup.0.1.nonce",
+			Solution:      "0",
+			ExpectedError: ErrInvalidProofOfWork,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			if tt.Prepare != nil {
+				tt.Prepare(&mock)
+			}
+
+			service := ProofOfWorkService{Store: db, SigningKey: []byte("secret")}
+			err := service.VerifyProofOfWork(context.Background(), tt.Purpose, tt.Challenge, tt.Solution)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
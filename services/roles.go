@@ -0,0 +1,40 @@
+package services
+
+import "github.com/aliparlakci/armut-backend-assessment/models"
+
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+// Permissions are granted to users through their roles. Routes require permissions rather than roles,
+// so that new roles can be introduced without touching the routes.
+const (
+	PermissionListUsers      = "users:list"
+	PermissionSuspendUsers   = "users:suspend"
+	PermissionReadActivities = "activities:read"
+	PermissionReadAuditLog   = "audit:read"
+	PermissionManageInvites  = "invites:manage"
+	PermissionImpersonate    = "users:impersonate"
+)
+
+var rolePermissions = map[string][]string{
+	RoleAdmin:   {PermissionListUsers, PermissionSuspendUsers, PermissionReadActivities, PermissionReadAuditLog, PermissionManageInvites, PermissionImpersonate},
+	RoleSupport: {PermissionListUsers, PermissionReadActivities},
+}
+
+func IsRole(role string) bool {
+	_, exists := rolePermissions[role]
+	return exists
+}
+
+func HasPermission(user models.User, permission string) bool {
+	for _, role := range user.Roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
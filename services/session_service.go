@@ -1,55 +1,533 @@
 package services
 
-//go:generate mockgen -destination=../mocks/mock_session_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services SessionFetcher,SessionCreator,SessionRevoker
+//go:generate mockgen -destination=../mocks/mock_session_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services SessionFetcher,SessionCreator,SessionRevoker,SessionRenewer,SessionLister,ImpersonationFetcher,ImpersonationManager,ImpersonationExpirer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// SessionService stores sessions as redis hashes which expire after IdleTimeout of inactivity,
+// but never live longer than AbsoluteTimeout. Both timeouts must be positive.
+// Sessions reference their users by their ids, and the session ids of every user are indexed
+// in the "user_sessions:<id>" set.
+//
+// Sessions which were created before users were referenced by their ids have the username of the user instead,
+// and are indexed in the "sessions:<username>" set. They are upgraded when they are used, or by MigrateSessions.
+//
+// Session ids are secrets, so sessions are exposed to users through their public ids,
+// which are derived from the session ids with PublicSessionId.
+//
+// Impersonation sessions are sessions of a user which an admin has started, and have the id of the admin in their
+// "impersonator_id" field. They are indexed among the sessions of the user, so that they end together with them,
+// and they never live longer than ImpersonationTimeout. The impersonations are also kept in the "impersonations"
+// sorted set until they end, so that the ones which time out can be found by ExpireImpersonations.
 type SessionService struct {
-	Store *redis.Client
+	Store                *redis.Client
+	Users                UserIDResolver
+	IdleTimeout          time.Duration
+	AbsoluteTimeout      time.Duration
+	ImpersonationTimeout time.Duration
 }
 
 type SessionFetcher interface {
-	FetchSession(c context.Context, sessionId string) (string, error)
+	FetchSession(c context.Context, sessionId string) (primitive.ObjectID, error)
 }
 
 type SessionCreator interface {
-	CreateSession(c context.Context, data string) (string, error)
+	CreateSession(c context.Context, username, ip, userAgent string) (string, time.Duration, error)
 }
 
 type SessionRevoker interface {
 	RevokeSession(c context.Context, sessionId string) error
+	RevokeUserSession(c context.Context, username, publicId string) error
+	RevokeOtherSessions(c context.Context, username, sessionId string) error
 }
 
-func (s *SessionService) FetchSession(c context.Context, sessionId string) (string, error) {
-	session, err := s.Store.Get(c, sessionId).Result()
-	if err == redis.Nil {
-		return session, ErrNoSession
-	} else if err != nil {
-		return session, err
+type SessionRenewer interface {
+	RenewSession(c context.Context, sessionId string) (time.Duration, error)
+}
+
+type SessionLister interface {
+	ListSessions(c context.Context, username string) ([]models.Session, error)
+}
+
+type ImpersonationFetcher interface {
+	FetchImpersonator(c context.Context, sessionId string) (primitive.ObjectID, error)
+}
+
+type ImpersonationManager interface {
+	StartImpersonation(c context.Context, username string, impersonatorID primitive.ObjectID, returnSessionId, ip, userAgent string) (string, time.Duration, error)
+	EndImpersonation(c context.Context, sessionId string) (string, error)
+}
+
+type ImpersonationExpirer interface {
+	ExpireImpersonations(c context.Context) ([]models.Impersonation, error)
+}
+
+// FetchSession returns the id of the user of the session.
+func (s *SessionService) FetchSession(c context.Context, sessionId string) (primitive.ObjectID, error) {
+	fields, err := s.Store.HMGet(c, sessionId, "user_id", "username").Result()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if userID, ok := fields[0].(string); ok {
+		id, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return primitive.NilObjectID, fmt.Errorf("cannot parse the user id of the session: %v", err.Error())
+		}
+		return id, nil
+	}
+
+	if username, ok := fields[1].(string); ok {
+		userID, err := s.Users.ResolveUserID(c, username)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		return userID, s.upgradeSession(c, sessionId, userID)
+	}
+
+	return primitive.NilObjectID, ErrNoSession
+}
+
+// upgradeSession makes a session which has the username of its user reference the id of the user instead.
+func (s *SessionService) upgradeSession(c context.Context, sessionId string, userID primitive.ObjectID) error {
+	pipe := s.Store.TxPipeline()
+	pipe.HSet(c, sessionId, "user_id", userID.Hex())
+	pipe.HDel(c, sessionId, "username")
+	pipe.SAdd(c, userSessionsKey(userID), sessionId)
+	pipe.Expire(c, userSessionsKey(userID), s.AbsoluteTimeout)
+	if _, err := pipe.Exec(c); err != nil {
+		return fmt.Errorf("cannot upgrade the session: %v", err.Error())
+	}
+
+	return nil
+}
+
+// MigrateSessions upgrades every session which has the username of its user, which may be older than canonical
+// usernames. Sessions of users who no longer exist are revoked.
+func (s *SessionService) MigrateSessions(c context.Context) error {
+	iter := s.Store.Scan(c, 0, legacySessionIndexKey("*"), 0).Iterator()
+	for iter.Next(c) {
+		username := strings.TrimPrefix(iter.Val(), legacySessionIndexKey(""))
+
+		sessionIds, err := s.Store.SMembers(c, iter.Val()).Result()
+		if err != nil {
+			return fmt.Errorf("cannot fetch the sessions of the user: %v", err.Error())
+		}
+
+		userID, err := s.Users.ResolveUserID(c, CanonicalUsername(username))
+		if err == ErrNoUser {
+			if err := s.revoke(c, primitive.NilObjectID, username, sessionIds...); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for _, sessionId := range sessionIds {
+			if exists, err := s.Store.HExists(c, sessionId, "username").Result(); err != nil {
+				return fmt.Errorf("cannot fetch the session: %v", err.Error())
+			} else if !exists {
+				continue
+			}
+
+			if err := s.upgradeSession(c, sessionId, userID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.Store.Del(c, iter.Val()).Err(); err != nil {
+			return fmt.Errorf("cannot remove the session index: %v", err.Error())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("cannot scan the session indexes: %v", err.Error())
+	}
+
+	return nil
+}
+
+// CreateSession returns the id of the new session and the duration it is valid for.
+func (s *SessionService) CreateSession(c context.Context, username, ip, userAgent string) (string, time.Duration, error) {
+	userID, err := s.Users.ResolveUserID(c, username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ttl := s.lifetime(time.Now())
+	sessionId, err := s.create(c, userID, ttl, ip, userAgent)
+	return sessionId, ttl, err
+}
+
+// StartImpersonation creates a session of the user for the impersonator. returnSessionId is the session of the
+// impersonator, which EndImpersonation gives back so that the impersonator can be signed back in to it.
+func (s *SessionService) StartImpersonation(c context.Context, username string, impersonatorID primitive.ObjectID, returnSessionId, ip, userAgent string) (string, time.Duration, error) {
+	userID, err := s.Users.ResolveUserID(c, username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ttl := s.lifetime(time.Now())
+	if ttl > s.ImpersonationTimeout {
+		ttl = s.ImpersonationTimeout
+	}
+
+	sessionId, err := s.create(c, userID, ttl, ip, userAgent,
+		"impersonator_id", impersonatorID.Hex(),
+		"return_session_id", returnSessionId,
+	)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := s.Store.ZAdd(c, impersonationsKey, &redis.Z{
+		Score:  float64(time.Now().Add(ttl).Unix()),
+		Member: impersonationMember(sessionId, userID.Hex(), impersonatorID.Hex()),
+	}).Err(); err != nil {
+		_ = s.RevokeSession(c, sessionId)
+		return "", 0, fmt.Errorf("cannot track the impersonation: %v", err.Error())
+	}
+
+	return sessionId, ttl, nil
+}
+
+// FetchImpersonator returns the id of the impersonator of the session, which is zero if the session is not an
+// impersonation session. Impersonation sessions which have outlived ImpersonationTimeout are revoked.
+func (s *SessionService) FetchImpersonator(c context.Context, sessionId string) (primitive.ObjectID, error) {
+	fields, err := s.Store.HMGet(c, sessionId, "impersonator_id", "created_at").Result()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	impersonator, ok := fields[0].(string)
+	if !ok {
+		return primitive.NilObjectID, nil
+	}
+	impersonatorID, err := primitive.ObjectIDFromHex(impersonator)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("cannot parse the impersonator id of the session: %v", err.Error())
+	}
+
+	createdAt, _ := fields[1].(string)
+	unix, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("cannot parse the creation time of the session: %v", err.Error())
+	}
+	if time.Since(time.Unix(unix, 0)) >= s.ImpersonationTimeout {
+		if err := s.RevokeSession(c, sessionId); err != nil {
+			return primitive.NilObjectID, err
+		}
+		return primitive.NilObjectID, ErrNoSession
+	}
+
+	return impersonatorID, nil
+}
+
+// EndImpersonation revokes the impersonation session and returns the session of the impersonator it was started from,
+// which is empty if there was none.
+func (s *SessionService) EndImpersonation(c context.Context, sessionId string) (string, error) {
+	fields, err := s.Store.HMGet(c, sessionId, "impersonator_id", "return_session_id", "user_id").Result()
+	if err != nil {
+		return "", err
+	}
+	impersonator, ok := fields[0].(string)
+	if !ok {
+		return "", ErrNotImpersonating
+	}
+
+	if err := s.RevokeSession(c, sessionId); err != nil {
+		return "", err
+	}
+
+	// The impersonation has ended before timing out, so that ExpireImpersonations does not end it again
+	user, _ := fields[2].(string)
+	if err := s.Store.ZRem(c, impersonationsKey, impersonationMember(sessionId, user, impersonator)).Err(); err != nil {
+		return "", fmt.Errorf("cannot stop tracking the impersonation: %v", err.Error())
+	}
+
+	returnSessionId, _ := fields[1].(string)
+	return returnSessionId, nil
+}
+
+// ExpireImpersonations returns the impersonations which have timed out since it was last called, including those
+// whose sessions have been revoked without ending the impersonation. Each impersonation is returned only once,
+// even if it is called concurrently.
+func (s *SessionService) ExpireImpersonations(c context.Context) ([]models.Impersonation, error) {
+	members, err := s.Store.ZRangeByScoreWithScores(c, impersonationsKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch the expired impersonations: %v", err.Error())
+	}
+
+	impersonations := make([]models.Impersonation, 0, len(members))
+	for _, member := range members {
+		removed, err := s.Store.ZRem(c, impersonationsKey, member.Member).Result()
+		if err != nil {
+			return impersonations, fmt.Errorf("cannot stop tracking the impersonation: %v", err.Error())
+		}
+		if removed == 0 {
+			continue
+		}
+
+		parts := strings.Split(member.Member.(string), ":")
+		if len(parts) != 3 {
+			continue
+		}
+		userID, userErr := primitive.ObjectIDFromHex(parts[1])
+		impersonatorID, impersonatorErr := primitive.ObjectIDFromHex(parts[2])
+		if userErr != nil || impersonatorErr != nil {
+			continue
+		}
+
+		impersonations = append(impersonations, models.Impersonation{
+			UserID:         userID,
+			ImpersonatorID: impersonatorID,
+			EndedAt:        time.Unix(int64(member.Score), 0),
+		})
 	}
-	return session, nil
+
+	return impersonations, nil
 }
 
-func (s *SessionService) CreateSession(c context.Context, data string) (string, error) {
+// create stores a new session of the user, which expires after ttl, with the extra fields.
+func (s *SessionService) create(c context.Context, userID primitive.ObjectID, ttl time.Duration, ip, userAgent string, fields ...interface{}) (string, error) {
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return "", fmt.Errorf("cannot create new uuid: %v", err.Error())
 	}
-	// TODO: Set expiration
-	if err := s.Store.Set(c, id.String(), data, 0).Err(); err != nil {
+
+	createdAt := time.Now()
+
+	pipe := s.Store.TxPipeline()
+	pipe.HSet(c, id.String(), append([]interface{}{
+		"user_id", userID.Hex(),
+		"created_at", createdAt.Unix(),
+		"last_seen", createdAt.Unix(),
+		"ip", ip,
+		"user_agent", userAgent,
+	}, fields...)...)
+	pipe.Expire(c, id.String(), ttl)
+	pipe.SAdd(c, userSessionsKey(userID), id.String())
+	pipe.Expire(c, userSessionsKey(userID), s.AbsoluteTimeout)
+	if _, err := pipe.Exec(c); err != nil {
 		return "", fmt.Errorf("cannot create a new session: %v", err.Error())
 	}
 
 	return id.String(), nil
 }
 
+// RenewSession pushes the idle expiration of the session forward and returns the new lifetime of it.
+// The session is revoked if it has outlived the absolute timeout.
+func (s *SessionService) RenewSession(c context.Context, sessionId string) (time.Duration, error) {
+	createdAt, err := s.Store.HGet(c, sessionId, "created_at").Result()
+	if err == redis.Nil {
+		return 0, ErrNoSession
+	} else if err != nil {
+		return 0, err
+	}
+
+	unix, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse the creation time of the session: %v", err.Error())
+	}
+
+	ttl := s.lifetime(time.Unix(unix, 0))
+	if ttl <= 0 {
+		if err := s.RevokeSession(c, sessionId); err != nil {
+			return 0, err
+		}
+		return 0, ErrNoSession
+	}
+
+	pipe := s.Store.TxPipeline()
+	pipe.HSet(c, sessionId, "last_seen", time.Now().Unix())
+	pipe.Expire(c, sessionId, ttl)
+	if _, err := pipe.Exec(c); err != nil {
+		return 0, fmt.Errorf("cannot renew the session: %v", err.Error())
+	}
+
+	return ttl, nil
+}
+
 func (s *SessionService) RevokeSession(c context.Context, sessionId string) error {
-	return s.Store.Del(c, sessionId).Err()
+	userID, err := s.FetchSession(c, sessionId)
+	if err != nil {
+		return err
+	}
+
+	return s.revoke(c, userID, "", sessionId)
+}
+
+// RevokeUserSession revokes the session of the user with the given public id.
+func (s *SessionService) RevokeUserSession(c context.Context, username, publicId string) error {
+	userID, sessionIds, err := s.userSessions(c, username)
+	if err != nil {
+		return err
+	}
+
+	for _, sessionId := range sessionIds {
+		if PublicSessionId(sessionId) == publicId {
+			return s.revoke(c, userID, username, sessionId)
+		}
+	}
+
+	return ErrNoSession
+}
+
+// RevokeOtherSessions revokes every session of the user except the given one.
+func (s *SessionService) RevokeOtherSessions(c context.Context, username, sessionId string) error {
+	userID, sessionIds, err := s.userSessions(c, username)
+	if err != nil {
+		return err
+	}
+
+	others := make([]string, 0, len(sessionIds))
+	for _, id := range sessionIds {
+		if id != sessionId {
+			others = append(others, id)
+		}
+	}
+
+	return s.revoke(c, userID, username, others...)
+}
+
+// ListSessions returns the active sessions of the user, most recently used first.
+// Sessions which have expired since are removed from the index.
+func (s *SessionService) ListSessions(c context.Context, username string) ([]models.Session, error) {
+	results := make([]models.Session, 0)
+
+	userID, sessionIds, err := s.userSessions(c, username)
+	if err != nil {
+		return results, err
+	}
+
+	pipe := s.Store.Pipeline()
+	commands := make([]*redis.StringStringMapCmd, len(sessionIds))
+	for i, sessionId := range sessionIds {
+		commands[i] = pipe.HGetAll(c, sessionId)
+	}
+	if _, err := pipe.Exec(c); err != nil && err != redis.Nil {
+		return results, fmt.Errorf("cannot fetch the sessions of the user: %v", err.Error())
+	}
+
+	expired := make([]string, 0)
+	for i, command := range commands {
+		fields := command.Val()
+		if len(fields) == 0 {
+			expired = append(expired, sessionIds[i])
+			continue
+		}
+
+		createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+		lastSeen, _ := strconv.ParseInt(fields["last_seen"], 10, 64)
+		results = append(results, models.Session{
+			ID:           PublicSessionId(sessionIds[i]),
+			IP:           fields["ip"],
+			UserAgent:    fields["user_agent"],
+			CreatedAt:    time.Unix(createdAt, 0),
+			LastSeen:     time.Unix(lastSeen, 0),
+			Impersonated: fields["impersonator_id"] != "",
+		})
+	}
+
+	if len(expired) > 0 {
+		if err := s.revoke(c, userID, username, expired...); err != nil {
+			return results, err
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].LastSeen.After(results[j].LastSeen)
+	})
+
+	return results, nil
+}
+
+// userSessions returns the id of the user and the ids of their sessions, including the ones which have not been upgraded.
+func (s *SessionService) userSessions(c context.Context, username string) (primitive.ObjectID, []string, error) {
+	userID, err := s.Users.ResolveUserID(c, username)
+	if err != nil {
+		return primitive.NilObjectID, nil, err
+	}
+
+	sessionIds, err := s.Store.SUnion(c, userSessionsKey(userID), legacySessionIndexKey(username)).Result()
+	if err != nil {
+		return primitive.NilObjectID, nil, fmt.Errorf("cannot fetch the sessions of the user: %v", err.Error())
+	}
+
+	return userID, sessionIds, nil
+}
+
+// revoke removes the sessions from the index of the user, and from the index of their username if it is given.
+func (s *SessionService) revoke(c context.Context, userID primitive.ObjectID, username string, sessionIds ...string) error {
+	if len(sessionIds) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(sessionIds))
+	for i, sessionId := range sessionIds {
+		members[i] = sessionId
+	}
+
+	pipe := s.Store.TxPipeline()
+	pipe.Del(c, sessionIds...)
+	if !userID.IsZero() {
+		pipe.SRem(c, userSessionsKey(userID), members...)
+	}
+	if username != "" {
+		pipe.SRem(c, legacySessionIndexKey(username), members...)
+	}
+	if _, err := pipe.Exec(c); err != nil {
+		return fmt.Errorf("cannot revoke sessions: %v", err.Error())
+	}
+
+	return nil
+}
+
+// lifetime is the time a session created at createdAt has left if it were used right now.
+func (s *SessionService) lifetime(createdAt time.Time) time.Duration {
+	remaining := time.Until(createdAt.Add(s.AbsoluteTimeout))
+	if remaining < s.IdleTimeout {
+		return remaining.Truncate(time.Second)
+	}
+	return s.IdleTimeout
+}
+
+// PublicSessionId derives the id of a session which is safe to show to the user.
+func PublicSessionId(sessionId string) string {
+	sum := sha256.Sum256([]byte(sessionId))
+	return hex.EncodeToString(sum[:8])
+}
+
+// impersonationsKey is a sorted set of the impersonations, scored by when they time out.
+const impersonationsKey = "impersonations"
+
+func impersonationMember(sessionId, userID, impersonatorID string) string {
+	return sessionId + ":" + userID + ":" + impersonatorID
+}
+
+func userSessionsKey(userID primitive.ObjectID) string {
+	return "user_sessions:" + userID.Hex()
+}
+
+func legacySessionIndexKey(username string) string {
+	return "sessions:" + username
 }
 
-var ErrNoSession error = fmt.Errorf("session does not exist")
\ No newline at end of file
+var ErrNoSession error = fmt.Errorf("session does not exist")
+var ErrNotImpersonating error = fmt.Errorf("session is not an impersonation session")
@@ -5,29 +5,69 @@ import (
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/go-redis/redismock/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
 	"testing"
+	"time"
 )
 
+var aliparlakciID, _ = primitive.ObjectIDFromHex("61583a4fd4d6b4d5c8a3b2e1")
+
+// fakeResolver resolves the usernames it has, and returns ErrNoUser for the others.
+type fakeResolver map[string]primitive.ObjectID
+
+func (f fakeResolver) ResolveUserID(c context.Context, username string) (primitive.ObjectID, error) {
+	if id, exists := f[username]; exists {
+		return id, nil
+	}
+	return primitive.NilObjectID, ErrNoUser
+}
+
+func (f fakeResolver) ResolveUserIDs(c context.Context, usernames []string) (map[string]primitive.ObjectID, error) {
+	ids := map[string]primitive.ObjectID{}
+	for _, username := range usernames {
+		if id, exists := f[username]; exists {
+			ids[username] = id
+		}
+	}
+	return ids, nil
+}
+
+var users = fakeResolver{"aliparlakci": aliparlakciID}
+
 func TestFetchSession(t *testing.T) {
 	tests := []struct {
 		SessionId     string
 		Prepare       func(client *redismock.ClientMock)
-		Expected      string
+		Expected      primitive.ObjectID
 		ExpectedError error
 	}{
 		{
 			SessionId: "ididntchosethisshinylife",
 			Prepare: func(client *redismock.ClientMock) {
-				(*client).ExpectGet("ididntchosethisshinylife").SetVal("aliparlakci")
+				(*client).ExpectHMGet("ididntchosethisshinylife", "user_id", "username").SetVal([]interface{}{aliparlakciID.Hex(), nil})
 			},
-			Expected:      "aliparlakci",
+			Expected:      aliparlakciID,
 			ExpectedError: nil,
 		}, {
 			SessionId: "ididntchosethisshinylife",
 			Prepare: func(client *redismock.ClientMock) {
-				(*client).ExpectGet("ididntchosethisshinylife").SetErr(redis.Nil)
+				(*client).ExpectHMGet("ididntchosethisshinylife", "user_id", "username").SetVal([]interface{}{nil, "aliparlakci"})
+				(*client).ExpectTxPipeline()
+				(*client).ExpectHSet("ididntchosethisshinylife", "user_id", aliparlakciID.Hex()).SetVal(1)
+				(*client).ExpectHDel("ididntchosethisshinylife", "username").SetVal(1)
+				(*client).ExpectSAdd("user_sessions:"+aliparlakciID.Hex(), "ididntchosethisshinylife").SetVal(1)
+				(*client).ExpectExpire("user_sessions:"+aliparlakciID.Hex(), 24*time.Hour).SetVal(true)
+				(*client).ExpectTxPipelineExec()
 			},
-			Expected:      "",
+			Expected:      aliparlakciID,
+			ExpectedError: nil,
+		}, {
+			SessionId: "ididntchosethisshinylife",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("ididntchosethisshinylife", "user_id", "username").SetVal([]interface{}{nil, nil})
+			},
+			Expected:      primitive.NilObjectID,
 			ExpectedError: ErrNoSession,
 		},
 	}
@@ -39,7 +79,7 @@ func TestFetchSession(t *testing.T) {
 
 			tt.Prepare(&mock)
 
-			service := SessionService{Store: db}
+			service := SessionService{Store: db, Users: users, AbsoluteTimeout: 24 * time.Hour}
 			result, err := service.FetchSession(context.Background(), tt.SessionId)
 
 			if err != tt.ExpectedError {
@@ -48,6 +88,68 @@ func TestFetchSession(t *testing.T) {
 			if result != tt.Expected {
 				t.Errorf("want %v, got %v", tt.Expected, result)
 			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFetchImpersonator(t *testing.T) {
+	impersonatorID := primitive.NewObjectID()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	expired := strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix(), 10)
+
+	tests := []struct {
+		Prepare       func(client *redismock.ClientMock)
+		Expected      primitive.ObjectID
+		ExpectedError error
+	}{
+		{
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("someuuid", "impersonator_id", "created_at").SetVal([]interface{}{nil, now})
+			},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: nil,
+		}, {
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("someuuid", "impersonator_id", "created_at").SetVal([]interface{}{impersonatorID.Hex(), now})
+			},
+			Expected:      impersonatorID,
+			ExpectedError: nil,
+		}, {
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("someuuid", "impersonator_id", "created_at").SetVal([]interface{}{impersonatorID.Hex(), expired})
+				(*client).ExpectHMGet("someuuid", "user_id", "username").SetVal([]interface{}{aliparlakciID.Hex(), nil})
+				(*client).ExpectTxPipeline()
+				(*client).ExpectDel("someuuid").SetVal(1)
+				(*client).ExpectSRem("user_sessions:"+aliparlakciID.Hex(), "someuuid").SetVal(1)
+				(*client).ExpectTxPipelineExec()
+			},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: ErrNoSession,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := SessionService{Store: db, Users: users, AbsoluteTimeout: 24 * time.Hour, ImpersonationTimeout: time.Hour}
+			result, err := service.FetchImpersonator(context.Background(), "someuuid")
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
 		})
 	}
 }
@@ -61,7 +163,18 @@ func TestCreateSession(t *testing.T) {
 		{
 			Data: "aliparlakci",
 			Prepare: func(client *redismock.ClientMock) {
-				(*client).Regexp().ExpectSet(`(.)*`, "aliparlakci", 0).SetVal("")
+				(*client).ExpectTxPipeline()
+				(*client).Regexp().ExpectHSet(`(.)*`,
+					"user_id", aliparlakciID.Hex(),
+					"created_at", `[0-9]+`,
+					"last_seen", `[0-9]+`,
+					"ip", "127.0.0.1",
+					"user_agent", "curl/7.79.1",
+				).SetVal(5)
+				(*client).Regexp().ExpectExpire(`(.)*`, time.Hour).SetVal(true)
+				(*client).Regexp().ExpectSAdd("user_sessions:"+aliparlakciID.Hex(), `(.)*`).SetVal(1)
+				(*client).ExpectExpire("user_sessions:"+aliparlakciID.Hex(), 24*time.Hour).SetVal(true)
+				(*client).ExpectTxPipelineExec()
 			},
 			ExpectedError: nil,
 		},
@@ -74,28 +187,106 @@ func TestCreateSession(t *testing.T) {
 
 			tt.Prepare(&mock)
 
-			service := SessionService{Store: db}
-			_, err := service.CreateSession(context.Background(), tt.Data)
+			service := SessionService{Store: db, Users: users, IdleTimeout: time.Hour, AbsoluteTimeout: 24 * time.Hour}
+			_, ttl, err := service.CreateSession(context.Background(), tt.Data, "127.0.0.1", "curl/7.79.1")
 
 			if err != tt.ExpectedError {
 				t.Errorf("want %v, got %v", tt.ExpectedError, err)
 			}
+			if ttl != time.Hour {
+				t.Errorf("want %v, got %v", time.Hour, ttl)
+			}
+		})
+	}
+}
+
+func TestRenewSession(t *testing.T) {
+	tests := []struct {
+		SessionId     string
+		Prepare       func(client *redismock.ClientMock)
+		Expected      time.Duration
+		ExpectedError error
+	}{
+		{
+			SessionId: "someuuid",
+			Prepare: func(client *redismock.ClientMock) {
+				createdAt := time.Now().Add(-2 * time.Hour).Unix()
+				(*client).ExpectHGet("someuuid", "created_at").SetVal(strconv.FormatInt(createdAt, 10))
+				(*client).ExpectTxPipeline()
+				(*client).Regexp().ExpectHSet("someuuid", "last_seen", `[0-9]+`).SetVal(0)
+				(*client).ExpectExpire("someuuid", time.Hour).SetVal(true)
+				(*client).ExpectTxPipelineExec()
+			},
+			Expected:      time.Hour,
+			ExpectedError: nil,
+		}, {
+			SessionId: "someuuid",
+			Prepare: func(client *redismock.ClientMock) {
+				createdAt := time.Now().Add(-25 * time.Hour).Unix()
+				(*client).ExpectHGet("someuuid", "created_at").SetVal(strconv.FormatInt(createdAt, 10))
+				(*client).ExpectHMGet("someuuid", "user_id", "username").SetVal([]interface{}{aliparlakciID.Hex(), nil})
+				(*client).ExpectTxPipeline()
+				(*client).ExpectDel("someuuid").SetVal(1)
+				(*client).ExpectSRem("user_sessions:"+aliparlakciID.Hex(), "someuuid").SetVal(1)
+				(*client).ExpectTxPipelineExec()
+			},
+			Expected:      0,
+			ExpectedError: ErrNoSession,
+		}, {
+			SessionId: "someuuid",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHGet("someuuid", "created_at").SetErr(redis.Nil)
+			},
+			Expected:      0,
+			ExpectedError: ErrNoSession,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := SessionService{Store: db, IdleTimeout: time.Hour, AbsoluteTimeout: 24 * time.Hour}
+			result, err := service.RenewSession(context.Background(), tt.SessionId)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
 		})
 	}
 }
 
 func TestRevokeSession(t *testing.T) {
 	tests := []struct {
-		Data    string
-		Prepare func(client *redismock.ClientMock)
+		Data          string
+		Prepare       func(client *redismock.ClientMock)
 		ExpectedError error
 	}{
 		{
 			Data: "someuuid",
 			Prepare: func(client *redismock.ClientMock) {
-				(*client).Regexp().ExpectDel("someuuid").SetErr(redis.Nil)
+				(*client).ExpectHMGet("someuuid", "user_id", "username").SetVal([]interface{}{aliparlakciID.Hex(), nil})
+				(*client).ExpectTxPipeline()
+				(*client).ExpectDel("someuuid").SetVal(1)
+				(*client).ExpectSRem("user_sessions:"+aliparlakciID.Hex(), "someuuid").SetVal(1)
+				(*client).ExpectTxPipelineExec()
 			},
-			ExpectedError: redis.Nil,
+			ExpectedError: nil,
+		}, {
+			Data: "someuuid",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("someuuid", "user_id", "username").SetVal([]interface{}{nil, nil})
+			},
+			ExpectedError: ErrNoSession,
 		},
 	}
 
@@ -106,7 +297,7 @@ func TestRevokeSession(t *testing.T) {
 
 			tt.Prepare(&mock)
 
-			service := SessionService{Store: db}
+			service := SessionService{Store: db, Users: users}
 			err := service.RevokeSession(context.Background(), tt.Data)
 
 			if err != tt.ExpectedError {
@@ -115,3 +306,134 @@ func TestRevokeSession(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokeUserSession(t *testing.T) {
+	tests := []struct {
+		PublicId      string
+		Prepare       func(client *redismock.ClientMock)
+		ExpectedError error
+	}{
+		{
+			PublicId: PublicSessionId("someuuid"),
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectSUnion("user_sessions:"+aliparlakciID.Hex(), "sessions:aliparlakci").SetVal([]string{"otheruuid", "someuuid"})
+				(*client).ExpectTxPipeline()
+				(*client).ExpectDel("someuuid").SetVal(1)
+				(*client).ExpectSRem("user_sessions:"+aliparlakciID.Hex(), "someuuid").SetVal(1)
+				(*client).ExpectSRem("sessions:aliparlakci", "someuuid").SetVal(1)
+				(*client).ExpectTxPipelineExec()
+			},
+			ExpectedError: nil,
+		}, {
+			PublicId: PublicSessionId("someuuid"),
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectSUnion("user_sessions:"+aliparlakciID.Hex(), "sessions:aliparlakci").SetVal([]string{"otheruuid"})
+			},
+			ExpectedError: ErrNoSession,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := SessionService{Store: db, Users: users}
+			err := service.RevokeUserSession(context.Background(), "aliparlakci", tt.PublicId)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestEndImpersonation(t *testing.T) {
+	impersonatorID := primitive.NewObjectID()
+	member := "someuuid:" + aliparlakciID.Hex() + ":" + impersonatorID.Hex()
+
+	tests := []struct {
+		Prepare       func(client *redismock.ClientMock)
+		Expected      string
+		ExpectedError error
+	}{
+		{
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("someuuid", "impersonator_id", "return_session_id", "user_id").SetVal([]interface{}{impersonatorID.Hex(), "otheruuid", aliparlakciID.Hex()})
+				(*client).ExpectHMGet("someuuid", "user_id", "username").SetVal([]interface{}{aliparlakciID.Hex(), nil})
+				(*client).ExpectTxPipeline()
+				(*client).ExpectDel("someuuid").SetVal(1)
+				(*client).ExpectSRem("user_sessions:"+aliparlakciID.Hex(), "someuuid").SetVal(1)
+				(*client).ExpectTxPipelineExec()
+				(*client).ExpectZRem("impersonations", member).SetVal(1)
+			},
+			Expected:      "otheruuid",
+			ExpectedError: nil,
+		}, {
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectHMGet("someuuid", "impersonator_id", "return_session_id", "user_id").SetVal([]interface{}{nil, nil, aliparlakciID.Hex()})
+			},
+			Expected:      "",
+			ExpectedError: ErrNotImpersonating,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := SessionService{Store: db, Users: users, AbsoluteTimeout: 24 * time.Hour, ImpersonationTimeout: time.Hour}
+			result, err := service.EndImpersonation(context.Background(), "someuuid")
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestExpireImpersonations(t *testing.T) {
+	impersonatorID := primitive.NewObjectID()
+	expired := time.Date(2021, 11, 20, 12, 0, 0, 0, time.UTC)
+	member := "someuuid:" + aliparlakciID.Hex() + ":" + impersonatorID.Hex()
+	claimed := "otheruuid:" + aliparlakciID.Hex() + ":" + impersonatorID.Hex()
+
+	db, mock := redismock.NewClientMock()
+	mock.Regexp().ExpectZRangeByScoreWithScores("impersonations", &redis.ZRangeBy{Min: "-inf", Max: `^\d+$`}).SetVal([]redis.Z{
+		{Score: float64(expired.Unix()), Member: member},
+		{Score: float64(expired.Unix()), Member: claimed},
+	})
+	mock.ExpectZRem("impersonations", member).SetVal(1)
+	// Another server has ended the other impersonation first
+	mock.ExpectZRem("impersonations", claimed).SetVal(0)
+
+	service := SessionService{Store: db, Users: users, AbsoluteTimeout: 24 * time.Hour, ImpersonationTimeout: time.Hour}
+	impersonations, err := service.ExpireImpersonations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(impersonations) != 1 {
+		t.Fatalf("want 1 impersonation, got %v", impersonations)
+	}
+	if impersonations[0].UserID != aliparlakciID || impersonations[0].ImpersonatorID != impersonatorID || !impersonations[0].EndedAt.Equal(expired) {
+		t.Errorf("want the impersonation of aliparlakci, got %v", impersonations[0])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_token_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services TokenIssuer,AccessTokenVerifier,TokenRevoker
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+// TokenService issues access tokens, which are JWTs signed with SigningKey using HS256 and are valid for AccessTTL,
+// along with refresh tokens, which are valid for RefreshTTL and can be exchanged for a new pair of tokens once.
+//
+// Every refresh token descends from a token family started by a password grant. The family is kept in
+// "token_family:<family>" and indexed per user in "token_families:<id>". Refresh tokens are kept in
+// "refresh_token:<hash>" even after they are used, so that if one is used a second time, which means that
+// it has leaked, the whole family along with its access tokens is revoked.
+//
+// Tokens reference their users by their ids. The tokens which were issued before that have the username of
+// the user instead, and are rejected as invalid, so their users have to sign in again.
+type TokenService struct {
+	Store      *redis.Client
+	Users      UserIDResolver
+	SigningKey []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type TokenIssuer interface {
+	IssueTokens(c context.Context, username string) (models.TokenPair, error)
+	RefreshTokens(c context.Context, refreshToken string) (models.TokenPair, primitive.ObjectID, error)
+}
+
+type AccessTokenVerifier interface {
+	VerifyAccessToken(c context.Context, accessToken string) (primitive.ObjectID, error)
+}
+
+type TokenRevoker interface {
+	RevokeUserTokens(c context.Context, username string) error
+}
+
+type accessTokenClaims struct {
+	Subject   string `json:"sub"`
+	Family    string `json:"fam"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueTokens starts a new token family for the user.
+func (t *TokenService) IssueTokens(c context.Context, username string) (models.TokenPair, error) {
+	userID, err := t.Users.ResolveUserID(c, username)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	family, err := randomToken(16)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	pipe := t.Store.TxPipeline()
+	pipe.Set(c, tokenFamilyKey(family), userID.Hex(), t.RefreshTTL)
+	pipe.SAdd(c, tokenFamiliesKey(userID), family)
+	pipe.Expire(c, tokenFamiliesKey(userID), t.RefreshTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return models.TokenPair{}, fmt.Errorf("cannot create a token family: %v", err.Error())
+	}
+
+	return t.issue(c, userID, family)
+}
+
+// RefreshTokens exchanges the refresh token for a new pair of tokens in the same family.
+// Returns the id of the user the tokens are issued for, which is also returned along with ErrRefreshTokenReused.
+func (t *TokenService) RefreshTokens(c context.Context, refreshToken string) (models.TokenPair, primitive.ObjectID, error) {
+	key := refreshTokenKey(refreshToken)
+
+	fields, err := t.Store.HGetAll(c, key).Result()
+	if err != nil {
+		return models.TokenPair{}, primitive.NilObjectID, fmt.Errorf("cannot fetch the refresh token: %v", err.Error())
+	}
+	userID, err := primitive.ObjectIDFromHex(fields["user_id"])
+	if err != nil {
+		return models.TokenPair{}, primitive.NilObjectID, ErrInvalidRefreshToken
+	}
+	family := fields["family"]
+
+	if exists, err := t.Store.Exists(c, tokenFamilyKey(family)).Result(); err != nil {
+		return models.TokenPair{}, userID, fmt.Errorf("cannot fetch the token family: %v", err.Error())
+	} else if exists == 0 {
+		return models.TokenPair{}, userID, ErrInvalidRefreshToken
+	}
+
+	// Marking the token as used and checking whether it was already used must be a single step,
+	// otherwise two concurrent refreshes could both succeed.
+	if uses, err := t.Store.HIncrBy(c, key, "uses", 1).Result(); err != nil {
+		return models.TokenPair{}, userID, fmt.Errorf("cannot use the refresh token: %v", err.Error())
+	} else if uses > 1 {
+		if err := t.revokeFamilies(c, userID, family); err != nil {
+			return models.TokenPair{}, userID, err
+		}
+		return models.TokenPair{}, userID, ErrRefreshTokenReused
+	}
+
+	if err := t.Store.Expire(c, tokenFamilyKey(family), t.RefreshTTL).Err(); err != nil {
+		return models.TokenPair{}, userID, fmt.Errorf("cannot extend the token family: %v", err.Error())
+	}
+
+	pair, err := t.issue(c, userID, family)
+	return pair, userID, err
+}
+
+// VerifyAccessToken returns the id of the user the access token is issued for.
+func (t *TokenService) VerifyAccessToken(c context.Context, accessToken string) (primitive.ObjectID, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, t.sign(parts[0]+"."+parts[1])) {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+
+	var claims accessTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+
+	if exists, err := t.Store.Exists(c, tokenFamilyKey(claims.Family)).Result(); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("cannot fetch the token family: %v", err.Error())
+	} else if exists == 0 {
+		return primitive.NilObjectID, ErrInvalidAccessToken
+	}
+
+	return userID, nil
+}
+
+// RevokeUserTokens revokes every token family of the user.
+func (t *TokenService) RevokeUserTokens(c context.Context, username string) error {
+	userID, err := t.Users.ResolveUserID(c, username)
+	if err != nil {
+		return err
+	}
+
+	families, err := t.Store.SMembers(c, tokenFamiliesKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("cannot fetch the token families of the user: %v", err.Error())
+	}
+
+	return t.revokeFamilies(c, userID, families...)
+}
+
+func (t *TokenService) issue(c context.Context, userID primitive.ObjectID, family string) (models.TokenPair, error) {
+	now := time.Now()
+
+	payload, err := json.Marshal(accessTokenClaims{
+		Subject:   userID.Hex(),
+		Family:    family,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(t.AccessTTL).Unix(),
+	})
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("cannot encode the access token: %v", err.Error())
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	accessToken := unsigned + "." + base64.RawURLEncoding.EncodeToString(t.sign(unsigned))
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	pipe := t.Store.TxPipeline()
+	pipe.HSet(c, refreshTokenKey(refreshToken), "user_id", userID.Hex(), "family", family, "uses", 0)
+	pipe.Expire(c, refreshTokenKey(refreshToken), t.RefreshTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return models.TokenPair{}, fmt.Errorf("cannot store the refresh token: %v", err.Error())
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(t.AccessTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (t *TokenService) revokeFamilies(c context.Context, userID primitive.ObjectID, families ...string) error {
+	if len(families) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(families))
+	members := make([]interface{}, len(families))
+	for i, family := range families {
+		keys[i] = tokenFamilyKey(family)
+		members[i] = family
+	}
+
+	pipe := t.Store.TxPipeline()
+	pipe.Del(c, keys...)
+	pipe.SRem(c, tokenFamiliesKey(userID), members...)
+	if _, err := pipe.Exec(c); err != nil {
+		return fmt.Errorf("cannot revoke the token families: %v", err.Error())
+	}
+
+	return nil
+}
+
+func (t *TokenService) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, t.SigningKey)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func refreshTokenKey(refreshToken string) string {
+	return "refresh_token:" + hashToken(refreshToken)
+}
+
+func tokenFamilyKey(family string) string {
+	return "token_family:" + family
+}
+
+func tokenFamiliesKey(userID primitive.ObjectID) string {
+	return "token_families:" + userID.Hex()
+}
+
+var ErrInvalidAccessToken error = fmt.Errorf("access token is invalid or expired")
+var ErrInvalidRefreshToken error = fmt.Errorf("refresh token is invalid or expired")
+var ErrRefreshTokenReused error = fmt.Errorf("refresh token is already used")
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redismock/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyAccessToken(t *testing.T) {
+	service := TokenService{SigningKey: []byte("secret"), AccessTTL: time.Minute}
+	expired := TokenService{SigningKey: []byte("secret"), AccessTTL: -time.Minute}
+	other := TokenService{SigningKey: []byte("another secret"), AccessTTL: time.Minute}
+	userID := primitive.NewObjectID()
+
+	sign := func(service TokenService) string {
+		db, mock := redismock.NewClientMock()
+		mock.ExpectTxPipeline()
+		mock.Regexp().ExpectHSet(`refresh_token:(.)*`, "user_id", userID.Hex(), "family", "somefamily", "uses", 0).SetVal(3)
+		mock.Regexp().ExpectExpire(`refresh_token:(.)*`, service.RefreshTTL).SetVal(true)
+		mock.ExpectTxPipelineExec()
+
+		service.Store = db
+		pair, err := service.issue(context.Background(), userID, "somefamily")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair.AccessToken
+	}
+
+	valid := sign(service)
+	segments := strings.Split(valid, ".")
+	tampered := segments[0] + "." + strings.TrimRight(segments[1], "=") + "e30." + segments[2]
+
+	payload, _ := json.Marshal(accessTokenClaims{Subject: "aliparlakci", Family: "somefamily", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	legacy := unsigned + "." + base64.RawURLEncoding.EncodeToString(service.sign(unsigned))
+
+	tests := []struct {
+		AccessToken   string
+		Prepare       func(client *redismock.ClientMock)
+		Expected      primitive.ObjectID
+		ExpectedError error
+	}{
+		{
+			AccessToken: valid,
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectExists("token_family:somefamily").SetVal(1)
+			},
+			Expected:      userID,
+			ExpectedError: nil,
+		}, {
+			AccessToken: valid,
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectExists("token_family:somefamily").SetVal(0)
+			},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: ErrInvalidAccessToken,
+		}, {
+			AccessToken:   sign(expired),
+			Prepare:       func(client *redismock.ClientMock) {},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: ErrInvalidAccessToken,
+		}, {
+			AccessToken:   sign(other),
+			Prepare:       func(client *redismock.ClientMock) {},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: ErrInvalidAccessToken,
+		}, {
+			AccessToken:   tampered,
+			Prepare:       func(client *redismock.ClientMock) {},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: ErrInvalidAccessToken,
+		}, {
+			AccessToken:   legacy,
+			Prepare:       func(client *redismock.ClientMock) {},
+			Expected:      primitive.NilObjectID,
+			ExpectedError: ErrInvalidAccessToken,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service.Store = db
+			result, err := service.VerifyAccessToken(context.Background(), tt.AccessToken)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRefreshTokensReuse(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	key := "refresh_token:" + hashToken("somerefreshtoken")
+	userID := primitive.NewObjectID()
+
+	mock.ExpectHGetAll(key).SetVal(map[string]string{"user_id": userID.Hex(), "family": "somefamily", "uses": "1"})
+	mock.ExpectExists("token_family:somefamily").SetVal(1)
+	mock.ExpectHIncrBy(key, "uses", 1).SetVal(2)
+	mock.ExpectTxPipeline()
+	mock.ExpectDel("token_family:somefamily").SetVal(1)
+	mock.ExpectSRem("token_families:"+userID.Hex(), "somefamily").SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	service := TokenService{Store: db, SigningKey: []byte("secret"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	_, result, err := service.RefreshTokens(context.Background(), "somerefreshtoken")
+
+	if err != ErrRefreshTokenReused {
+		t.Errorf("want %v, got %v", ErrRefreshTokenReused, err)
+	}
+	if result != userID {
+		t.Errorf("want %v, got %v", userID, result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// randomToken returns a url-safe token made of n random bytes.
+func randomToken(n int) (string, error) {
+	bytes, err := randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, fmt.Errorf("cannot generate random bytes: %v", err.Error())
+	}
+
+	return bytes, nil
+}
+
+// hashToken is used to store tokens, so that they cannot be used by anyone who can read the store.
+// Tokens are random enough that they do not need a salted and slow hash like passwords do.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test vectors are taken from RFC 4226 and RFC 6238, truncated to 6 digits
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		Code          string
+		Now           time.Time
+		ExpectedStep  uint64
+		ExpectedValid bool
+	}{
+		{Code: "287082", Now: time.Unix(59, 0), ExpectedStep: 1, ExpectedValid: true},
+		{Code: "081804", Now: time.Unix(1111111109, 0), ExpectedStep: 37037036, ExpectedValid: true},
+		{Code: "050471", Now: time.Unix(1111111111, 0), ExpectedStep: 37037037, ExpectedValid: true},
+		{Code: "081804", Now: time.Unix(1111111111, 0), ExpectedStep: 37037036, ExpectedValid: true},
+		{Code: "005924", Now: time.Unix(1234567890, 0), ExpectedStep: 41152263, ExpectedValid: true},
+		{Code: "005924", Now: time.Unix(1234567890+90, 0), ExpectedStep: 0, ExpectedValid: false},
+		{Code: "12345", Now: time.Unix(59, 0), ExpectedStep: 0, ExpectedValid: false},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			step, valid := validateTOTP(secret, tt.Code, tt.Now)
+
+			if valid != tt.ExpectedValid {
+				t.Errorf("want %v, got %v", tt.ExpectedValid, valid)
+			}
+			if step != tt.ExpectedStep {
+				t.Errorf("want %v, got %v", tt.ExpectedStep, step)
+			}
+		})
+	}
+}
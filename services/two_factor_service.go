@@ -0,0 +1,283 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_two_factor_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services TwoFactorEnroller,TwoFactorVerifier,PendingSigninCreator,PendingSigninCompleter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TwoFactorService manages TOTP based two-factor authentication of the users.
+//
+// A user with two-factor authentication enabled signs in in two steps. The password yields a pending signin,
+// which is kept in redis as "pending_signin:<hash>" for PendingTTL and turns into a session once it is
+// completed with a TOTP or recovery code. A pending signin is discarded after MaxAttempts wrong codes.
+type TwoFactorService struct {
+	Collection  *mongo.Collection
+	Store       *redis.Client
+	Issuer      string
+	PendingTTL  time.Duration
+	MaxAttempts int
+}
+
+type TwoFactorEnroller interface {
+	BeginEnrollment(c context.Context, username string) (models.TwoFactorEnrollment, error)
+	ConfirmEnrollment(c context.Context, username, code string) ([]string, error)
+	DisableTwoFactor(c context.Context, username, code string) error
+}
+
+type TwoFactorVerifier interface {
+	VerifyTwoFactor(c context.Context, username, code string) (bool, error)
+}
+
+type PendingSigninCreator interface {
+	CreatePendingSignin(c context.Context, username string) (string, error)
+}
+
+type PendingSigninCompleter interface {
+	CompletePendingSignin(c context.Context, pendingId, code string) (string, error)
+}
+
+const recoveryCodeCount = 10
+
+// BeginEnrollment generates a new secret for the user, which does not take effect until it is confirmed.
+func (t *TwoFactorService) BeginEnrollment(c context.Context, username string) (models.TwoFactorEnrollment, error) {
+	var enrollment models.TwoFactorEnrollment
+
+	user, err := t.getUser(c, username)
+	if err != nil {
+		return enrollment, err
+	}
+	if user.TwoFactorEnabled() {
+		return enrollment, ErrTwoFactorEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return enrollment, err
+	}
+
+	if _, err := t.Collection.UpdateOne(c,
+		bson.M{"username": username},
+		bson.M{"$set": bson.M{"pending_totp_secret": secret}},
+	); err != nil {
+		return enrollment, fmt.Errorf("mongo driver raised an error while storing the totp secret: %v", err.Error())
+	}
+
+	enrollment.Secret = secret
+	enrollment.URI = totpURI(t.Issuer, username, secret)
+	return enrollment, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication if the code is valid for the pending secret.
+// Returns the recovery codes of the user, which are not retrievable afterwards.
+func (t *TwoFactorService) ConfirmEnrollment(c context.Context, username, code string) ([]string, error) {
+	user, err := t.getUser(c, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.PendingTOTPSecret == "" {
+		return nil, ErrNoEnrollment
+	}
+
+	if valid, err := t.checkTOTP(c, user.UserID, user.PendingTOTPSecret, code); err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashToken(codes[i])
+	}
+
+	if _, err := t.Collection.UpdateOne(c,
+		bson.M{"username": username, "pending_totp_secret": user.PendingTOTPSecret},
+		bson.M{
+			"$set":   bson.M{"totp_secret": user.PendingTOTPSecret, "recovery_codes": hashes},
+			"$unset": bson.M{"pending_totp_secret": ""},
+		},
+	); err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while enabling two-factor authentication: %v", err.Error())
+	}
+
+	return codes, nil
+}
+
+func (t *TwoFactorService) DisableTwoFactor(c context.Context, username, code string) error {
+	if valid, err := t.VerifyTwoFactor(c, username, code); err != nil {
+		return err
+	} else if !valid {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if _, err := t.Collection.UpdateOne(c,
+		bson.M{"username": username},
+		bson.M{"$unset": bson.M{"totp_secret": "", "pending_totp_secret": "", "recovery_codes": ""}},
+	); err != nil {
+		return fmt.Errorf("mongo driver raised an error while disabling two-factor authentication: %v", err.Error())
+	}
+
+	return nil
+}
+
+// VerifyTwoFactor accepts either a TOTP code or one of the recovery codes of the user.
+// Recovery codes and TOTP codes can only be used once.
+func (t *TwoFactorService) VerifyTwoFactor(c context.Context, username, code string) (bool, error) {
+	user, err := t.getUser(c, username)
+	if err != nil {
+		return false, err
+	}
+	if !user.TwoFactorEnabled() {
+		return false, ErrTwoFactorDisabled
+	}
+
+	if len(code) == totpDigits {
+		return t.checkTOTP(c, user.UserID, user.TOTPSecret, code)
+	}
+
+	result, err := t.Collection.UpdateOne(c,
+		bson.M{"username": username, "recovery_codes": hashToken(normalizeRecoveryCode(code))},
+		bson.M{"$pull": bson.M{"recovery_codes": hashToken(normalizeRecoveryCode(code))}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("mongo driver raised an error while using a recovery code: %v", err.Error())
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (t *TwoFactorService) CreatePendingSignin(c context.Context, username string) (string, error) {
+	pendingId, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := pendingSigninKey(pendingId)
+	pipe := t.Store.TxPipeline()
+	pipe.HSet(c, key, "username", username, "attempts", 0)
+	pipe.Expire(c, key, t.PendingTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return "", fmt.Errorf("cannot create a pending signin: %v", err.Error())
+	}
+
+	return pendingId, nil
+}
+
+// CompletePendingSignin returns the username of the pending signin if the code is valid.
+// The username is also returned along with ErrInvalidTwoFactorCode so that the failure can be attributed.
+func (t *TwoFactorService) CompletePendingSignin(c context.Context, pendingId, code string) (string, error) {
+	key := pendingSigninKey(pendingId)
+
+	username, err := t.Store.HGet(c, key, "username").Result()
+	if err == redis.Nil {
+		return "", ErrNoPendingSignin
+	} else if err != nil {
+		return "", fmt.Errorf("cannot fetch the pending signin: %v", err.Error())
+	}
+
+	valid, err := t.VerifyTwoFactor(c, username, code)
+	if err != nil {
+		return username, err
+	}
+
+	if !valid {
+		attempts, err := t.Store.HIncrBy(c, key, "attempts", 1).Result()
+		if err != nil {
+			return username, fmt.Errorf("cannot count the failed attempt: %v", err.Error())
+		}
+		if attempts >= int64(t.MaxAttempts) {
+			if err := t.Store.Del(c, key).Err(); err != nil {
+				return username, fmt.Errorf("cannot discard the pending signin: %v", err.Error())
+			}
+		}
+		return username, ErrInvalidTwoFactorCode
+	}
+
+	if deleted, err := t.Store.Del(c, key).Result(); err != nil {
+		return username, fmt.Errorf("cannot complete the pending signin: %v", err.Error())
+	} else if deleted == 0 {
+		// Another request has completed or discarded it in the meantime
+		return username, ErrNoPendingSignin
+	}
+
+	return username, nil
+}
+
+// checkTOTP rejects codes which have already been used in their time step, which are kept in
+// "totp_used:<id>:<step>".
+func (t *TwoFactorService) checkTOTP(c context.Context, userID primitive.ObjectID, secret, code string) (bool, error) {
+	step, valid := validateTOTP(secret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	key := "totp_used:" + userID.Hex() + ":" + strconv.FormatUint(step, 10)
+	fresh, err := t.Store.SetNX(c, key, 1, (2*totpSkew+1)*totpPeriod*time.Second).Result()
+	if err != nil {
+		return false, fmt.Errorf("cannot record the used totp code: %v", err.Error())
+	}
+
+	return fresh, nil
+}
+
+func (t *TwoFactorService) getUser(c context.Context, username string) (models.User, error) {
+	var user models.User
+
+	result := t.Collection.FindOne(c, bson.M{"username": username})
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return user, ErrNoUser
+	} else if err != nil {
+		return user, fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+
+	if err := result.Decode(&user); err != nil {
+		return user, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+// newRecoveryCode returns a code like "abcd-efgh-ijkl-mnop".
+func newRecoveryCode() (string, error) {
+	bytes, err := randomBytes(10)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(totpEncoding.EncodeToString(bytes))
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 16 {
+		return code
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
+
+func pendingSigninKey(pendingId string) string {
+	return "pending_signin:" + hashToken(pendingId)
+}
+
+var ErrTwoFactorEnabled error = fmt.Errorf("two-factor authentication is already enabled")
+var ErrTwoFactorDisabled error = fmt.Errorf("two-factor authentication is not enabled")
+var ErrNoEnrollment error = fmt.Errorf("two-factor authentication enrollment is not started")
+var ErrInvalidTwoFactorCode error = fmt.Errorf("two-factor authentication code is invalid")
+var ErrNoPendingSignin error = fmt.Errorf("pending signin does not exist")
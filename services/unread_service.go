@@ -0,0 +1,159 @@
+package services
+
+//go:generate mockgen -destination=../mocks/mock_unread_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services UnreadCounter
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+)
+
+// UnreadCounterService keeps the number of unread messages of every user in redis,
+// so that polling for new messages does not hit mongodb.
+// Totals are stored in "unread:<receiver>" and per-conversation counts
+// in the "unread:<receiver>:from" hash, keyed by the sender. Users are referenced by their hex ids.
+type UnreadCounterService struct {
+	Store *redis.Client
+}
+
+type UnreadCounter interface {
+	IncrementUnread(c context.Context, receiver, sender string) error
+	DecrementUnread(c context.Context, receiver, sender string, n int64) error
+	UnreadCount(c context.Context, receiver string) (int, error)
+	UnreadCountFrom(c context.Context, receiver, sender string) (int, error)
+	SetUnread(c context.Context, receiver string, conversations map[string]int64) error
+	CountedReceivers(c context.Context) ([]string, error)
+	DeleteUnread(c context.Context, receiver string) error
+}
+
+// Counters are only touched when they already exist. A missing counter is seeded from mongodb
+// on its first read, which already takes the change into account.
+var updateUnread = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("INCRBY", KEYS[1], ARGV[2])
+	redis.call("HINCRBY", KEYS[2], ARGV[1], ARGV[2])
+end
+return 0
+`)
+
+func (u *UnreadCounterService) IncrementUnread(c context.Context, receiver, sender string) error {
+	keys := []string{unreadTotalKey(receiver), unreadConversationsKey(receiver)}
+	if err := updateUnread.Run(c, u.Store, keys, sender, 1).Err(); err != nil {
+		return fmt.Errorf("cannot increment unread counters: %v", err.Error())
+	}
+
+	return nil
+}
+
+func (u *UnreadCounterService) DecrementUnread(c context.Context, receiver, sender string, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+
+	keys := []string{unreadTotalKey(receiver), unreadConversationsKey(receiver)}
+	if err := updateUnread.Run(c, u.Store, keys, sender, -n).Err(); err != nil {
+		return fmt.Errorf("cannot decrement unread counters: %v", err.Error())
+	}
+
+	return nil
+}
+
+// UnreadCount returns ErrNoCounter if there is no counter for the receiver yet.
+func (u *UnreadCounterService) UnreadCount(c context.Context, receiver string) (int, error) {
+	count, err := u.Store.Get(c, unreadTotalKey(receiver)).Int()
+	if err == redis.Nil {
+		return 0, ErrNoCounter
+	} else if err != nil {
+		return 0, err
+	}
+
+	return clampUnread(count), nil
+}
+
+// UnreadCountFrom returns ErrNoCounter if there is no counter for the receiver yet.
+func (u *UnreadCounterService) UnreadCountFrom(c context.Context, receiver, sender string) (int, error) {
+	if exists, err := u.Store.Exists(c, unreadTotalKey(receiver)).Result(); err != nil {
+		return 0, err
+	} else if exists == 0 {
+		return 0, ErrNoCounter
+	}
+
+	count, err := u.Store.HGet(c, unreadConversationsKey(receiver), sender).Int()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return clampUnread(count), nil
+}
+
+// SetUnread overwrites the counters of the receiver with the given per-sender counts.
+func (u *UnreadCounterService) SetUnread(c context.Context, receiver string, conversations map[string]int64) error {
+	var total int64
+	values := make([]interface{}, 0, len(conversations)*2)
+	for sender, count := range conversations {
+		total += count
+		values = append(values, sender, count)
+	}
+
+	pipe := u.Store.TxPipeline()
+	pipe.Del(c, unreadConversationsKey(receiver))
+	pipe.Set(c, unreadTotalKey(receiver), total, 0)
+	if len(values) > 0 {
+		pipe.HSet(c, unreadConversationsKey(receiver), values...)
+	}
+	if _, err := pipe.Exec(c); err != nil {
+		return fmt.Errorf("cannot set unread counters: %v", err.Error())
+	}
+
+	return nil
+}
+
+// CountedReceivers returns the receivers which currently have an unread counter, which are the hex ids of the users,
+// unless their counters are older than the ids.
+func (u *UnreadCounterService) CountedReceivers(c context.Context) ([]string, error) {
+	receivers := make([]string, 0)
+
+	iter := u.Store.Scan(c, 0, "unread:*", 0).Iterator()
+	for iter.Next(c) {
+		key := iter.Val()
+		if strings.HasSuffix(key, ":from") {
+			continue
+		}
+		receivers = append(receivers, strings.TrimPrefix(key, "unread:"))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan unread counters: %v", err.Error())
+	}
+
+	return receivers, nil
+}
+
+func (u *UnreadCounterService) DeleteUnread(c context.Context, receiver string) error {
+	if err := u.Store.Del(c, unreadTotalKey(receiver), unreadConversationsKey(receiver)).Err(); err != nil {
+		return fmt.Errorf("cannot delete unread counters: %v", err.Error())
+	}
+
+	return nil
+}
+
+func unreadTotalKey(receiver string) string {
+	return "unread:" + receiver
+}
+
+func unreadConversationsKey(receiver string) string {
+	return "unread:" + receiver + ":from"
+}
+
+// Counters are decremented without a lower bound, so they may go below zero
+// until the next reconciliation.
+func clampUnread(count int) int {
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+var ErrNoCounter error = fmt.Errorf("unread counter does not exist")
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"testing"
+)
+
+func TestUnreadCount(t *testing.T) {
+	tests := []struct {
+		Receiver      string
+		Prepare       func(client *redismock.ClientMock)
+		Expected      int
+		ExpectedError error
+	}{
+		{
+			Receiver: "aliparlakci",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectGet("unread:aliparlakci").SetVal("3")
+			},
+			Expected:      3,
+			ExpectedError: nil,
+		}, {
+			Receiver: "aliparlakci",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectGet("unread:aliparlakci").SetVal("-2")
+			},
+			Expected:      0,
+			ExpectedError: nil,
+		}, {
+			Receiver: "aliparlakci",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectGet("unread:aliparlakci").SetErr(redis.Nil)
+			},
+			Expected:      0,
+			ExpectedError: ErrNoCounter,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := UnreadCounterService{Store: db}
+			result, err := service.UnreadCount(context.Background(), tt.Receiver)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestUnreadCountFrom(t *testing.T) {
+	tests := []struct {
+		Receiver      string
+		Sender        string
+		Prepare       func(client *redismock.ClientMock)
+		Expected      int
+		ExpectedError error
+	}{
+		{
+			Receiver: "aliparlakci",
+			Sender:   "johndoe",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectExists("unread:aliparlakci").SetVal(1)
+				(*client).ExpectHGet("unread:aliparlakci:from", "johndoe").SetVal("2")
+			},
+			Expected:      2,
+			ExpectedError: nil,
+		}, {
+			Receiver: "aliparlakci",
+			Sender:   "johndoe",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectExists("unread:aliparlakci").SetVal(1)
+				(*client).ExpectHGet("unread:aliparlakci:from", "johndoe").SetErr(redis.Nil)
+			},
+			Expected:      0,
+			ExpectedError: nil,
+		}, {
+			Receiver: "aliparlakci",
+			Sender:   "johndoe",
+			Prepare: func(client *redismock.ClientMock) {
+				(*client).ExpectExists("unread:aliparlakci").SetVal(0)
+			},
+			Expected:      0,
+			ExpectedError: ErrNoCounter,
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+
+			tt.Prepare(&mock)
+
+			service := UnreadCounterService{Store: db}
+			result, err := service.UnreadCountFrom(context.Background(), tt.Receiver, tt.Sender)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MinSearchQueryLength is the shortest query which is searched for.
+	MinSearchQueryLength = 2
+	maxSearchQueryLength = 64
+	// maxSearchCandidates bounds how many users a search ranks, and so how deep its results can be paginated.
+	maxSearchCandidates = 200
+)
+
+// searchWords splits the text into its words in the form usernames are stored in,
+// so that "Ali Parlakçı" is found by "PARLAKÇI" as well.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(CanonicalUsername(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchGrams returns the bigrams a user is indexed with. The words are padded with a space at both ends,
+// so that the first grams of a word are enough to find it by its prefix. Bigrams rather than trigrams are used
+// since a typo breaks at most three grams of the query: a query of n characters has n grams once padded,
+// so a query with as many typos as allowedTypos allows still shares a gram with the words it almost matches.
+func SearchGrams(username, displayName string) []string {
+	seen := map[string]bool{}
+	grams := []string{}
+
+	for _, word := range append(searchWords(username), searchWords(displayName)...) {
+		for _, gram := range bigrams(" " + word + " ") {
+			if !seen[gram] {
+				seen[gram] = true
+				grams = append(grams, gram)
+			}
+		}
+	}
+
+	return grams
+}
+
+// queryGrams only pads the query at the start, since the query is a prefix of a word.
+func queryGrams(query string) []string {
+	grams := []string{}
+	for _, word := range searchWords(query) {
+		grams = append(grams, bigrams(" "+word)...)
+	}
+	return grams
+}
+
+func bigrams(text string) []string {
+	runes := []rune(text)
+	grams := make([]string, 0, len(runes))
+	for i := 0; i+2 <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+2]))
+	}
+	return grams
+}
+
+// searchMatch is how well a user matches a query. Lower ranks and distances are better.
+type searchMatch struct {
+	rank     int
+	distance int
+}
+
+const (
+	rankExactUsername = iota
+	rankUsernamePrefix
+	rankNamePrefix
+	rankTypo
+)
+
+// matchUser reports whether the user matches the query by the prefix of their username or of a word of their
+// display name, allowing for a few typos in longer queries.
+func matchUser(query, username, displayName string) (searchMatch, bool) {
+	if username == query {
+		return searchMatch{rank: rankExactUsername}, true
+	}
+	if strings.HasPrefix(username, query) {
+		return searchMatch{rank: rankUsernamePrefix}, true
+	}
+
+	// The whole display name is also a word, so that queries of several words match it
+	words := append([]string{username, CanonicalUsername(displayName)}, searchWords(username)...)
+	words = append(words, searchWords(displayName)...)
+	for _, word := range words {
+		if strings.HasPrefix(word, query) {
+			return searchMatch{rank: rankNamePrefix}, true
+		}
+	}
+
+	q := []rune(query)
+	allowed := allowedTypos(len(q))
+	best := allowed + 1
+	for _, word := range words {
+		w := []rune(word)
+		// The query can be missing a character of the prefix or have an extra one
+		for length := len(q) - 1; length <= len(q)+1; length++ {
+			if length < 1 || length > len(w) {
+				continue
+			}
+			if d := editDistance(q, w[:length]); d < best {
+				best = d
+			}
+		}
+	}
+	if best > allowed {
+		return searchMatch{}, false
+	}
+
+	return searchMatch{rank: rankTypo, distance: best}, true
+}
+
+func allowedTypos(queryLength int) int {
+	switch {
+	case queryLength < 4:
+		return 0
+	case queryLength < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the optimal string alignment distance, i.e. the Levenshtein distance
+// which also counts swapping two adjacent characters as a single typo.
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = smallest(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = smallest(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+func smallest(first int, rest ...int) int {
+	for _, n := range rest {
+		if n < first {
+			first = n
+		}
+	}
+	return first
+}
+
+type searchCandidate struct {
+	Username string         `bson:"username"`
+	Profile  models.Profile `bson:"profile"`
+	match    searchMatch
+}
+
+// rankProfiles drops the profiles which do not match the query and orders the rest by how well they match.
+func rankProfiles(query string, profiles []searchCandidate) []searchCandidate {
+	matches := make([]searchCandidate, 0, len(profiles))
+	for _, candidate := range profiles {
+		if match, ok := matchUser(query, candidate.Username, candidate.Profile.DisplayName); ok {
+			candidate.match = match
+			matches = append(matches, candidate)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].match.rank != matches[j].match.rank {
+			return matches[i].match.rank < matches[j].match.rank
+		}
+		if matches[i].match.distance != matches[j].match.distance {
+			return matches[i].match.distance < matches[j].match.distance
+		}
+		return matches[i].Username < matches[j].Username
+	})
+
+	return matches
+}
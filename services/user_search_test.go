@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+// TestSearchUsers runs the steps of SearchUsers against the stored form of the users,
+// with the aggregation's $match evaluated by matches.
+func TestSearchUsers(t *testing.T) {
+	users := []models.User{
+		{Username: "janedoe"},
+		{Username: "jane.smith", Profile: models.Profile{DisplayName: "Jane Smith"}},
+		{Username: "janet", Profile: models.Profile{DisplayName: "Janet", Private: true}},
+		{Username: "janeroe", Status: models.StatusSuspended},
+		{Username: "janedeleted", Status: models.StatusDeactivated},
+		{Username: "johndoe", Status: models.StatusActive, Profile: models.Profile{DisplayName: "John Doe"}},
+		{Username: "aliparlakci", Profile: models.Profile{DisplayName: "Ali Parlakçı"}},
+	}
+	documents := make([]bson.M, 0, len(users))
+	for _, user := range users {
+		user.SearchGrams = SearchGrams(user.Username, user.Profile.DisplayName)
+		raw, err := bson.Marshal(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var document bson.M
+		if err := bson.Unmarshal(raw, &document); err != nil {
+			t.Fatal(err)
+		}
+		documents = append(documents, document)
+	}
+
+	tests := []struct {
+		Query       string
+		Skip        int64
+		Limit       int64
+		Expected    []string
+		ExpectedErr error
+	}{
+		{Query: "jane", Limit: 10, Expected: []string{"jane.smith", "janedoe"}},
+		{Query: "JNAE", Limit: 10, Expected: []string{"jane.smith", "janedoe"}},
+		{Query: "hane", Limit: 10, Expected: []string{"jane.smith", "janedoe"}},
+		{Query: "doe", Limit: 10, Expected: []string{"johndoe"}},
+		{Query: "parlkaçı", Limit: 10, Expected: []string{"aliparlakci"}},
+		{Query: "aliparlkacı", Limit: 10, Expected: []string{"aliparlakci"}},
+		{Query: "jane", Skip: 1, Limit: 10, Expected: []string{"janedoe"}},
+		{Query: "jane", Limit: 1, Expected: []string{"jane.smith"}},
+		{Query: "janet", Limit: 10, Expected: []string{"jane.smith", "janedoe"}},
+		{Query: "j", Limit: 10, ExpectedErr: ErrInvalidSearchQuery},
+		{Query: "..", Limit: 10, ExpectedErr: ErrInvalidSearchQuery},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			query, grams, err := parseSearchQuery(tt.Query)
+			if err != tt.ExpectedErr {
+				t.Fatalf("want %v, got %v", tt.ExpectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			filter := searchFilter(grams)
+			candidates := []searchCandidate{}
+			for _, document := range documents {
+				if !matches(document, filter) {
+					continue
+				}
+				raw, err := bson.Marshal(document)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var candidate searchCandidate
+				if err := bson.Unmarshal(raw, &candidate); err != nil {
+					t.Fatal(err)
+				}
+				candidates = append(candidates, candidate)
+			}
+
+			result := []string{}
+			for _, profile := range searchResults(query, candidates, tt.Skip, tt.Limit) {
+				result = append(result, profile.Username)
+			}
+			if !reflect.DeepEqual(result, tt.Expected) {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRankProfiles(t *testing.T) {
+	candidates := []searchCandidate{
+		{Username: "johndoe", Profile: models.Profile{DisplayName: "John Doe"}},
+		{Username: "ali", Profile: models.Profile{DisplayName: "Ali"}},
+		{Username: "aliparlakci", Profile: models.Profile{DisplayName: "Ali Parlakçı"}},
+		{Username: "veli", Profile: models.Profile{DisplayName: "Aliye Veli"}},
+		{Username: "janedoe"},
+		{Username: "jane.smith", Profile: models.Profile{DisplayName: "Jane Smith"}},
+	}
+
+	tests := []struct {
+		Query    string
+		Expected []string
+	}{
+		{Query: "ali", Expected: []string{"ali", "aliparlakci", "veli"}},
+		{Query: "doe", Expected: []string{"johndoe"}},
+		{Query: "parlakci", Expected: []string{"aliparlakci"}},
+		{Query: "parlkaçı", Expected: []string{"aliparlakci"}},
+		{Query: "smith", Expected: []string{"jane.smith"}},
+		{Query: "jane sm", Expected: []string{"jane.smith"}},
+		{Query: "jnae", Expected: []string{"jane.smith", "janedoe"}},
+		{Query: "jon", Expected: []string{}},
+		{Query: "xyz", Expected: []string{}},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			result := []string{}
+			for _, match := range rankProfiles(tt.Query, candidates) {
+				result = append(result, match.Username)
+			}
+
+			if !reflect.DeepEqual(result, tt.Expected) {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestSearchGrams(t *testing.T) {
+	grams := map[string]bool{}
+	for _, gram := range SearchGrams("aliparlakci", "Ali Parlakçı") {
+		grams[gram] = true
+	}
+
+	// Every prefix of a word of two characters or more must share a gram with the word
+	for _, query := range []string{"al", "ali", "Parl", "PARLAKÇI"} {
+		found := false
+		for _, gram := range queryGrams(CanonicalUsername(query)) {
+			found = found || grams[gram]
+		}
+		if !found {
+			t.Errorf("%v does not share a gram with the user", query)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package services
 
-//go:generate mockgen -destination=../mocks/mock_user_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services UserGetter,UserCreator
+//go:generate mockgen -destination=../mocks/mock_user_service.go -package=mocks github.com/aliparlakci/armut-backend-assessment/services UserGetter,UserCreator,UserUpdater,UserLister,UserStatusUpdater,AccountDeactivator,UserRenamer,SettingsUpdater,IdentityLinker
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/aliparlakci/armut-backend-assessment/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
 type UserService struct {
@@ -17,27 +23,164 @@ type UserService struct {
 
 type UserGetter interface {
 	GetUser(c context.Context, username string) (models.User, error)
+	GetUserByID(c context.Context, id primitive.ObjectID) (models.User, error)
 	UserExists(c context.Context, username string) (bool, error)
 }
 
+// UserIDResolver is used by the services which reference users by their ids, but are called with usernames.
+type UserIDResolver interface {
+	ResolveUserID(c context.Context, username string) (primitive.ObjectID, error)
+	ResolveUserIDs(c context.Context, usernames []string) (map[string]primitive.ObjectID, error)
+}
+
 type UserCreator interface {
-	CreateUser(c context.Context, username, password string) (string, error)
+	CreateUser(c context.Context, username, email, password string) (string, error)
+}
+
+// SignupCounter tells how many users have signed up recently.
+type SignupCounter interface {
+	CountSignups(c context.Context, since time.Time) (int64, error)
+}
+
+type UserUpdater interface {
+	UpdatePassword(c context.Context, username, password string) error
+	VerifyEmail(c context.Context, username, email string) error
+}
+
+type UserLister interface {
+	ListUsers(c context.Context, skip, limit int64) ([]models.User, error)
+}
+
+type UserStatusUpdater interface {
+	SetStatus(c context.Context, username, status, reason string) error
+}
+
+type AccountDeactivator interface {
+	Deactivate(c context.Context, username, reason string) error
+	Reactivate(c context.Context, username string) error
+}
+
+type UserRenamer interface {
+	ChangeUsername(c context.Context, id primitive.ObjectID, username string) error
+}
+
+type SettingsUpdater interface {
+	UpdateSettings(c context.Context, username string, form models.SettingsForm) (models.User, error)
+}
+
+// IdentityLinker links the identities of external identity providers to users.
+type IdentityLinker interface {
+	GetUserByIdentity(c context.Context, issuer, subject string) (models.User, error)
+	LinkIdentity(c context.Context, username string, identity models.Identity) error
+	ProvisionUser(c context.Context, preferredUsername string, identity models.Identity) (models.User, error)
+}
+
+func (u *UserService) EnsureIndexes(c context.Context) error {
+	_, err := u.Collection.Indexes().CreateMany(c, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"username": 1},
+			Options: options.Index().SetUnique(true),
+		}, {
+			Keys:    bson.M{"usernames": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"usernames": bson.M{"$exists": true}}),
+		}, {
+			Keys: bson.D{{Key: "identities.issuer", Value: 1}, {Key: "identities.subject", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+				bson.M{"identities.subject": bson.M{"$exists": true}},
+			),
+		}, {
+			Keys:    bson.M{"email": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"email": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while creating the user indexes: %v", err.Error())
+	}
+
+	return nil
 }
 
 func (u *UserService) GetUser(c context.Context, username string) (models.User, error) {
 	result := u.Collection.FindOne(c, bson.M{"username": username})
 
 	var user models.User
-	if err := result.Err(); err != nil 	{
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return user, ErrNoUser
+	} else if err != nil {
 		return user, err
 	}
 	err := result.Decode(&user)
 	return user, err
 }
 
+func (u *UserService) GetUserByID(c context.Context, id primitive.ObjectID) (models.User, error) {
+	var user models.User
+
+	result := u.Collection.FindOne(c, bson.M{"_id": id})
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return user, ErrNoUser
+	} else if err != nil {
+		return user, fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+
+	if err := result.Decode(&user); err != nil {
+		return user, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+// ResolveUserID returns the id of the user who currently has the username.
+func (u *UserService) ResolveUserID(c context.Context, username string) (primitive.ObjectID, error) {
+	var user models.User
+
+	result := u.Collection.FindOne(c, bson.M{"username": username}, options.FindOne().SetProjection(bson.M{"_id": 1}))
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return primitive.NilObjectID, ErrNoUser
+	} else if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("mongo driver raised an error while resolving the user: %v", err.Error())
+	}
+
+	if err := result.Decode(&user); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return user.UserID, nil
+}
+
+// ResolveUserIDs resolves the usernames at once. Usernames which no user currently has are left out.
+func (u *UserService) ResolveUserIDs(c context.Context, usernames []string) (map[string]primitive.ObjectID, error) {
+	ids := make(map[string]primitive.ObjectID, len(usernames))
+	if len(usernames) == 0 {
+		return ids, nil
+	}
+
+	cursor, err := u.Collection.Find(c,
+		bson.M{"username": bson.M{"$in": usernames}},
+		options.Find().SetProjection(bson.M{"_id": 1, "username": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while resolving the users: %v", err.Error())
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, fmt.Errorf("cannot decode user: %v", err.Error())
+		}
+		ids[user.Username] = user.UserID
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cannot iterate over the users: %v", err.Error())
+	}
+
+	return ids, nil
+}
+
 func (u *UserService) UserExists(c context.Context, username string) (bool, error) {
 	_, err := u.GetUser(c, username)
-	if err == mongo.ErrNoDocuments {
+	if err == ErrNoUser {
 		return false, nil
 	} else if err != nil {
 		return false, err
@@ -45,16 +188,395 @@ func (u *UserService) UserExists(c context.Context, username string) (bool, erro
 	return true, nil
 }
 
-func (u *UserService) CreateUser(c context.Context, username, password string) (string, error) {
-	if exists, err := u.UserExists(c, username); err != nil {
-		return "", err
-	} else if exists {
-		return "", ErrUserAlreadyExists
+// CreateUser relies on the unique indexes of the collection, rather than checking whether the user exists first,
+// so that concurrent signups cannot create the same user twice. username must be normalized with NormalizeUsername.
+func (u *UserService) CreateUser(c context.Context, username, email, password string) (string, error) {
+	result, err := u.Collection.InsertOne(c, models.User{
+		Username:    username,
+		Usernames:   []string{username},
+		Email:       NormalizeEmail(email),
+		Password:    password,
+		SearchGrams: SearchGrams(username, ""),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// The username and the email address are the only unique fields of a new user
+		if taken, err := u.exists(c, bson.M{"usernames": username}); err != nil {
+			return "", err
+		} else if taken {
+			return "", ErrUserAlreadyExists
+		}
+		return "", ErrEmailAlreadyExists
+	} else if err != nil {
+		return "", fmt.Errorf("mongo driver raised an error while creating the user: %v", err.Error())
+	}
+	return result.InsertedID.(primitive.ObjectID).String(), nil
+}
+
+// CountSignups relies on the ids of the users, which start with the time they are created at.
+func (u *UserService) CountSignups(c context.Context, since time.Time) (int64, error) {
+	count, err := u.Collection.CountDocuments(c, bson.M{"_id": bson.M{"$gte": primitive.NewObjectIDFromTimestamp(since)}})
+	if err != nil {
+		return 0, fmt.Errorf("mongo driver raised an error while counting the signups: %v", err.Error())
+	}
+
+	return count, nil
+}
+
+func (u *UserService) UpdatePassword(c context.Context, username, password string) error {
+	result, err := u.Collection.UpdateOne(c, bson.M{"username": username}, bson.M{"$set": bson.M{"password": password}})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while updating the password: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// VerifyEmail only verifies the email address if the user still has it.
+func (u *UserService) VerifyEmail(c context.Context, username, email string) error {
+	result, err := u.Collection.UpdateOne(c,
+		bson.M{"username": username, "email": email},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while verifying the email address: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+func (u *UserService) ListUsers(c context.Context, skip, limit int64) ([]models.User, error) {
+	results := make([]models.User, 0)
+
+	cursor, err := u.Collection.Find(c, bson.M{}, options.Find().SetSort(bson.M{"username": 1}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("mongo driver raised an error while listing users: %v", err.Error())
+	}
+
+	for cursor.Next(c) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, fmt.Errorf("cannot decode user: %v", err.Error())
+		}
+
+		results = append(results, user)
+	}
+
+	return results, nil
+}
+
+// SetStatus keeps the reason for the users who are not active. Activating a user cancels the deletion of their account.
+func (u *UserService) SetStatus(c context.Context, username, status, reason string) error {
+	if status != models.StatusActive && status != models.StatusSuspended && status != models.StatusDeactivated {
+		return ErrInvalidStatus
+	}
+
+	set, unset := bson.M{"status": status}, bson.M{"self_deactivated": ""}
+	if status == models.StatusActive || reason == "" {
+		unset["status_reason"] = ""
+	} else {
+		set["status_reason"] = reason
+	}
+	if status == models.StatusActive {
+		unset["deletion_scheduled_at"] = ""
+	}
+	update := bson.M{"$set": set, "$unset": unset}
+
+	result, err := u.Collection.UpdateOne(c, bson.M{"username": username}, update)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while setting the status of the user: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// Deactivate deactivates the account of the user, who has asked for it, and lets them activate it again.
+func (u *UserService) Deactivate(c context.Context, username, reason string) error {
+	set := bson.M{"status": models.StatusDeactivated, "self_deactivated": true}
+	update := bson.M{"$set": set}
+	if reason == "" {
+		update["$unset"] = bson.M{"status_reason": ""}
+	} else {
+		set["status_reason"] = reason
+	}
+
+	result, err := u.Collection.UpdateOne(c, bson.M{"username": username}, update)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while deactivating the user: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// Reactivate activates the account of the user again, only if they have deactivated it themselves. Accounts which
+// are scheduled for deletion are activated by cancelling the deletion instead.
+// Returns ErrCannotReactivate if an admin has deactivated or suspended the user since.
+func (u *UserService) Reactivate(c context.Context, username string) error {
+	result, err := u.Collection.UpdateOne(c,
+		bson.M{
+			"username":              username,
+			"status":                models.StatusDeactivated,
+			"self_deactivated":      true,
+			"deletion_scheduled_at": bson.M{"$exists": false},
+		},
+		bson.M{
+			"$set":   bson.M{"status": models.StatusActive},
+			"$unset": bson.M{"status_reason": "", "self_deactivated": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while reactivating the user: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrCannotReactivate
+	}
+
+	return nil
+}
+
+// ChangeUsername renames the user and keeps the username they had in their history. username must be normalized
+// with NormalizeUsername. Users can go back to their previous usernames, but cannot take those of others.
+func (u *UserService) ChangeUsername(c context.Context, id primitive.ObjectID, username string) error {
+	var user models.User
+
+	result := u.Collection.FindOne(c, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"username": 1, "profile": 1}))
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return ErrNoUser
+	} else if err != nil {
+		return fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+	if err := result.Decode(&user); err != nil {
+		return fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	if user.Username == username {
+		return nil
+	}
+
+	// The current username is matched, so that concurrent renames cannot lose a username from the history
+	update, err := u.Collection.UpdateOne(c,
+		bson.M{"_id": id, "username": user.Username},
+		bson.M{
+			"$set":      bson.M{"username": username, "search_bigrams": SearchGrams(username, user.Profile.DisplayName)},
+			"$addToSet": bson.M{"usernames": bson.M{"$each": bson.A{user.Username, username}}},
+			"$push":     bson.M{"username_history": models.UsernameChange{Username: user.Username, ChangedAt: time.Now()}},
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// The username is the only unique field which is changed
+		return ErrUserAlreadyExists
+	} else if err != nil {
+		return fmt.Errorf("mongo driver raised an error while changing the username: %v", err.Error())
+	}
+	if update.MatchedCount == 0 {
+		return ErrUsernameChanged
+	}
+
+	return nil
+}
+
+// UpdateSettings returns the user after the update, with only their settings and the privacy of their profile.
+func (u *UserService) UpdateSettings(c context.Context, username string, form models.SettingsForm) (models.User, error) {
+	update := bson.M{}
+	if form.WhoCanMessage != nil {
+		switch *form.WhoCanMessage {
+		case models.MessagesFromEveryone, models.MessagesFromContacts, models.MessagesFromNobody:
+			update["settings.who_can_message"] = *form.WhoCanMessage
+		default:
+			return models.User{}, ErrInvalidMessagePolicy
+		}
+	}
+	if form.SendReadReceipts != nil {
+		update["settings.hide_read_receipts"] = !*form.SendReadReceipts
+	}
+	if form.Private != nil {
+		update["profile.private"] = *form.Private
+	}
+	if form.HidePresence != nil {
+		update["profile.hide_presence"] = *form.HidePresence
+	}
+	projection := bson.M{"settings": 1, "profile.private": 1, "profile.hide_presence": 1}
+
+	// An empty $set is not allowed, so the settings are only fetched when nothing changes
+	var user models.User
+	var result *mongo.SingleResult
+	if len(update) == 0 {
+		result = u.Collection.FindOne(c, bson.M{"username": username}, options.FindOne().SetProjection(projection))
+	} else {
+		result = u.Collection.FindOneAndUpdate(c,
+			bson.M{"username": username},
+			bson.M{"$set": update},
+			options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(projection),
+		)
+	}
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return models.User{}, ErrNoUser
+	} else if err != nil {
+		return models.User{}, fmt.Errorf("mongo driver raised an error while updating the settings: %v", err.Error())
+	}
+	if err := result.Decode(&user); err != nil {
+		return models.User{}, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+// GrantRole is used to bootstrap the admins, there is no endpoint to grant roles.
+func (u *UserService) GrantRole(c context.Context, username, role string) error {
+	result, err := u.Collection.UpdateOne(c, bson.M{"username": username}, bson.M{"$addToSet": bson.M{"roles": role}})
+	if err != nil {
+		return fmt.Errorf("mongo driver raised an error while granting the role: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// NormalizeEmail lowercases the email address, so that it is unique regardless of its case.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (u *UserService) GetUserByIdentity(c context.Context, issuer, subject string) (models.User, error) {
+	var user models.User
+
+	result := u.Collection.FindOne(c, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"issuer": issuer, "subject": subject}},
+	})
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return user, ErrNoUser
+	} else if err != nil {
+		return user, fmt.Errorf("mongo driver raised an error while fetching the user: %v", err.Error())
+	}
+
+	if err := result.Decode(&user); err != nil {
+		return user, fmt.Errorf("cannot decode user: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+func (u *UserService) LinkIdentity(c context.Context, username string, identity models.Identity) error {
+	result, err := u.Collection.UpdateOne(c, bson.M{"username": username}, bson.M{"$push": bson.M{"identities": identity}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrIdentityLinked
+	} else if err != nil {
+		return fmt.Errorf("mongo driver raised an error while linking the identity: %v", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// ProvisionUser creates a user without a password for the identity. The username is derived from preferredUsername,
+// or from the email of the identity if it is not set, and is suffixed with a number if it is already taken.
+func (u *UserService) ProvisionUser(c context.Context, preferredUsername string, identity models.Identity) (models.User, error) {
+	base := provisionedUsername(preferredUsername, identity.Email)
+
+	for i := 1; i <= maxProvisioningAttempts; i++ {
+		username := base
+		if i > 1 {
+			username += strconv.Itoa(i)
+		}
+
+		user := models.User{
+			Username:    username,
+			Usernames:   []string{username},
+			Identities:  []models.Identity{identity},
+			SearchGrams: SearchGrams(username, ""),
+		}
+		result, err := u.Collection.InsertOne(c, user)
+		if mongo.IsDuplicateKeyError(err) {
+			// Either the identity is linked to another user, or the username is taken
+			if linked, err := u.exists(c, bson.M{"identities": bson.M{"$elemMatch": bson.M{"issuer": identity.Issuer, "subject": identity.Subject}}}); err != nil {
+				return models.User{}, err
+			} else if linked {
+				return models.User{}, ErrIdentityLinked
+			}
+			continue
+		} else if err != nil {
+			return models.User{}, fmt.Errorf("mongo driver raised an error while provisioning the user: %v", err.Error())
+		}
+
+		user.UserID = result.InsertedID.(primitive.ObjectID)
+		return user, nil
+	}
+
+	return models.User{}, ErrUserAlreadyExists
+}
+
+const maxProvisioningAttempts = 100
+
+// provisionedUsername drops the characters NormalizeUsername does not allow,
+// and leaves room for the suffix ProvisionUser adds to taken usernames.
+func provisionedUsername(preferredUsername, email string) string {
+	username := preferredUsername
+	if username == "" {
+		username = strings.SplitN(email, "@", 2)[0]
+	}
+
+	allowed := make([]rune, 0, maxUsernameLength)
+	for _, r := range CanonicalUsername(username) {
+		if (unicode.IsLetter(r) || unicode.IsDigit(r) || isUsernameSeparator(r)) && len(allowed) < maxUsernameLength-3 {
+			allowed = append(allowed, r)
+		}
+	}
+
+	if normalized, err := NormalizeUsername(string(allowed)); err == nil {
+		return normalized
+	}
+	return "user"
+}
+
+// AccountStatusError is returned for the users who are not active, and tells them why.
+type AccountStatusError struct {
+	Status string
+	Reason string
+}
+
+func (e AccountStatusError) Error() string {
+	return "account is " + e.Status
+}
+
+// CheckAccountStatus returns an AccountStatusError if the user is not active.
+func CheckAccountStatus(user models.User) error {
+	if status := user.AccountStatus(); status != models.StatusActive {
+		return AccountStatusError{Status: status, Reason: user.StatusReason}
+	}
+	return nil
+}
+
+// exists tells which of the unique fields a duplicate key error is raised for, by looking up the other user who has
+// the value, since the driver does not report the key pattern of the index.
+func (u *UserService) exists(c context.Context, filter bson.M) (bool, error) {
+	count, err := u.Collection.CountDocuments(c, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("mongo driver raised an error while looking up the duplicate user: %v", err.Error())
 	}
 
-	result, err := u.Collection.InsertOne(c, models.User{Username: username, Password: password})
-	return result.InsertedID.(primitive.ObjectID).String(), err
+	return count > 0, nil
 }
 
 var ErrNoUser error = errors.New("no such user exists")
-var ErrUserAlreadyExists error = errors.New("user already exists")
\ No newline at end of file
+var ErrUserAlreadyExists error = errors.New("user already exists")
+var ErrEmailAlreadyExists error = errors.New("email address is already in use")
+var ErrUsernameChanged error = errors.New("username has been changed in the meantime")
+var ErrInvalidMessagePolicy error = fmt.Errorf("who_can_message must be %q, %q or %q", models.MessagesFromEveryone, models.MessagesFromContacts, models.MessagesFromNobody)
+var ErrIdentityLinked error = errors.New("identity is already linked to a user")
+var ErrCannotReactivate error = errors.New("account is not deactivated by the user")
+var ErrInvalidStatus error = fmt.Errorf("status must be %q, %q or %q", models.StatusActive, models.StatusSuspended, models.StatusDeactivated)
\ No newline at end of file
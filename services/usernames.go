@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+)
+
+// reservedUsernames cannot be signed up with, since they could be mistaken for the service itself.
+var reservedUsernames = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"armut":         true,
+	"api":           true,
+	"help":          true,
+	"me":            true,
+	"moderator":     true,
+	"null":          true,
+	"root":          true,
+	"security":      true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+}
+
+var usernameFolder = cases.Fold()
+
+// CanonicalUsername returns the form usernames are stored and looked up in, so that usernames which only differ
+// in their case or in their Unicode representation, such as "Ali", "ali" and "ａｌｉ", belong to the same user.
+// Usernames from requests must be made canonical before they are used.
+func CanonicalUsername(username string) string {
+	// Case folding can denormalize the string, so it is normalized once more afterwards
+	return norm.NFKC.String(usernameFolder.String(norm.NFKC.String(strings.TrimSpace(username))))
+}
+
+// NormalizeUsername returns the canonical form of a new username,
+// or ErrInvalidUsername or ErrReservedUsername if it cannot be signed up with.
+//
+// Usernames are 3 to 32 letters and digits, which can be separated by single underscores, dots or hyphens.
+func NormalizeUsername(username string) (string, error) {
+	username = CanonicalUsername(username)
+
+	runes := []rune(username)
+	if len(runes) < minUsernameLength || len(runes) > maxUsernameLength {
+		return "", ErrInvalidUsername
+	}
+
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+		case unicode.Is(unicode.Mn, r) && i > 0 && !isUsernameSeparator(runes[i-1]):
+			// Combining marks which NFKC cannot compose, as in some scripts
+		case isUsernameSeparator(r) && i > 0 && i < len(runes)-1 && !isUsernameSeparator(runes[i-1]):
+		default:
+			return "", ErrInvalidUsername
+		}
+	}
+
+	if reservedUsernames[username] {
+		return "", ErrReservedUsername
+	}
+
+	return username, nil
+}
+
+func isUsernameSeparator(r rune) bool {
+	return r == '_' || r == '.' || r == '-'
+}
+
+var ErrInvalidUsername error = fmt.Errorf("usernames must be %d to %d letters and digits, which can be separated by single underscores, dots or hyphens", minUsernameLength, maxUsernameLength)
+var ErrReservedUsername error = fmt.Errorf("username is reserved")
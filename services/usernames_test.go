@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"github.com/aliparlakci/armut-backend-assessment/models"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		Username      string
+		Expected      string
+		ExpectedError error
+	}{
+		{Username: "aliparlakci", Expected: "aliparlakci", ExpectedError: nil},
+		{Username: "AliParlakci", Expected: "aliparlakci", ExpectedError: nil},
+		{Username: "  ali.parlakci ", Expected: "ali.parlakci", ExpectedError: nil},
+		{Username: "ＡＬＩ", Expected: "ali", ExpectedError: nil},
+		{Username: "Straße", Expected: "strasse", ExpectedError: nil},
+		{Username: "ÖZKAN_UĞUR", Expected: "özkan_uğur", ExpectedError: nil},
+		{Username: "Öz\u0308kan", Expected: "öz\u0308kan", ExpectedError: nil},
+		{Username: "ﬁne-user", Expected: "fine-user", ExpectedError: nil},
+		{Username: "al", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "a234567890123456789012345678901234", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "ali parlakci", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "ali__parlakci", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "_aliparlakci", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "aliparlakci.", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "ali@parlakci", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "ali\u200bparlakci", Expected: "", ExpectedError: ErrInvalidUsername},
+		{Username: "Admin", Expected: "", ExpectedError: ErrReservedUsername},
+		{Username: "ＲＯＯＴ", Expected: "", ExpectedError: ErrReservedUsername},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			result, err := NormalizeUsername(tt.Username)
+
+			if err != tt.ExpectedError {
+				t.Errorf("want %v, got %v", tt.ExpectedError, err)
+			}
+			if result != tt.Expected {
+				t.Errorf("want %q, got %q", tt.Expected, result)
+			}
+		})
+	}
+}
+
+func TestCanonicalUsername(t *testing.T) {
+	if CanonicalUsername("Ali") != CanonicalUsername("ａｌｉ") {
+		t.Errorf("want %q and %q to be the same user", "Ali", "ａｌｉ")
+	}
+}
+
+func TestUsernameCollisions(t *testing.T) {
+	tests := []struct {
+		Users    []models.User
+		Expected []string
+	}{
+		{
+			Users:    []models.User{{Username: "Ali", Usernames: []string{"Ali"}}, {Username: "veli"}},
+			Expected: []string{},
+		}, {
+			Users:    []models.User{{Username: "Ali"}, {Username: "ali"}},
+			Expected: []string{`"ali" is shared by ["Ali" "ali"]`},
+		}, {
+			Users:    []models.User{{Username: "veli", Usernames: []string{"ALI", "veli"}}, {Username: "ａｌｉ"}},
+			Expected: []string{`"ali" is shared by ["veli" "ａｌｉ"]`},
+		},
+	}
+
+	for i, tt := range tests {
+		testName := fmt.Sprintf("[%v]", i)
+		t.Run(testName, func(t *testing.T) {
+			if result := usernameCollisions(tt.Users); !reflect.DeepEqual(result, tt.Expected) {
+				t.Errorf("want %v, got %v", tt.Expected, result)
+			}
+		})
+	}
+}